@@ -62,9 +62,53 @@ export const logApi = {
   cleanLogs: (days: number) => api.post('/logs/clean', { days })
 }
 
+// 同步相关
+export const syncApi = {
+  getWatermarks: () => api.get('/watermarks'),
+  resetWatermark: (targetKey: string) => api.post('/watermarks/reset', { targetKey }),
+  getReports: (params: Record<string, any>) => api.get('/sync/reports', { params }),
+  getCDCSources: () => api.get('/cdc/sources'),
+  saveCDCSource: (source: Record<string, any>) => api.post('/cdc/sources', source),
+  deleteCDCSource: (id: number) => api.post('/cdc/sources/delete', { id }),
+  restartCDCSource: (id: number) => api.post('/cdc/sources/restart', { id }),
+  getSpools: () => api.get('/spools'),
+  deleteSpool: (id: number) => api.post('/spools/delete', { id }),
+  cleanSpools: () => api.post('/spools/clean')
+}
+
+// 查询库相关
+export const queryApi = {
+  getQueries: () => api.get('/queries'),
+  saveQuery: (query: Record<string, any>) => api.post('/queries', query),
+  deleteQuery: (id: number) => api.post('/queries/delete', { id }),
+  getVersions: (id: number) => api.get('/queries/versions', { params: { id } }),
+  getProfiles: () => api.get('/queries/profiles'),
+  saveProfile: (profile: Record<string, any>) => api.post('/queries/profiles', profile),
+  deleteProfile: (id: number) => api.post('/queries/profiles/delete', { id })
+}
+
+// 回写相关
+export const writebackApi = {
+  getTargets: () => api.get('/writeback/targets'),
+  saveTarget: (target: Record<string, any>) => api.post('/writeback/targets', target),
+  deleteTarget: (id: number) => api.post('/writeback/targets/delete', { id }),
+  getAudit: (params: Record<string, any>) => api.get('/writeback/audit', { params })
+}
+
+// 定时推送相关
+export const schedulerApi = {
+  getJobs: () => api.get('/scheduler/jobs'),
+  saveJob: (job: Record<string, any>) => api.post('/scheduler/jobs', job),
+  deleteJob: (id: number) => api.post('/scheduler/jobs/delete', { id }),
+  runJob: (id: number) => api.post('/scheduler/jobs/run', { id }),
+  getRuns: (params: Record<string, any>) => api.get('/scheduler/runs', { params })
+}
+
 // 系统相关
 export const systemApi = {
-  getInfo: () => api.get('/system/info')
+  getInfo: () => api.get('/system/info'),
+  getCostGuard: () => api.get('/cost_guard'),
+  saveCostGuard: (settings: Record<string, any>) => api.post('/cost_guard', settings)
 }
 
 export default api
@@ -25,6 +25,26 @@ const router = createRouter({
           name: 'Logs',
           component: () => import('../views/Logs.vue')
         },
+        {
+          path: 'sync',
+          name: 'Sync',
+          component: () => import('../views/Sync.vue')
+        },
+        {
+          path: 'queries',
+          name: 'Queries',
+          component: () => import('../views/Queries.vue')
+        },
+        {
+          path: 'writeback',
+          name: 'WriteBack',
+          component: () => import('../views/WriteBack.vue')
+        },
+        {
+          path: 'scheduler',
+          name: 'Scheduler',
+          component: () => import('../views/Scheduler.vue')
+        },
         {
           path: 'settings',
           name: 'Settings',
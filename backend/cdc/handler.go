@@ -0,0 +1,238 @@
+package cdc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/go-mysql-org/go-mysql/schema"
+)
+
+const (
+	// 缓冲的变更达到该数量时立即写入
+	flushBatchSize = 1000
+	// 缓冲的变更最长等待时间
+	flushInterval = time.Second
+)
+
+// eventHandler 处理binlog事件，将行变更写入变更日志
+type eventHandler struct {
+	canal.DummyEventHandler
+
+	runner *runner
+
+	mu        sync.Mutex
+	pending   []Change
+	pos       mysql.Position // 已解析到的事务边界位点
+	dirty     bool           // pos 是否尚未保存
+	lastFlush time.Time
+}
+
+func newEventHandler(r *runner) *eventHandler {
+	return &eventHandler{runner: r, lastFlush: time.Now()}
+}
+
+// OnRow 行变更事件
+func (h *eventHandler) OnRow(e *canal.RowsEvent) error {
+	if len(e.Table.PKColumns) == 0 {
+		h.runner.warnNoPrimaryKey(e.Table.Name)
+		return nil
+	}
+
+	var changes []Change
+	switch e.Action {
+	case canal.InsertAction:
+		for _, row := range e.Rows {
+			changes = append(changes, newChange(e.Table, OpInsert, row))
+		}
+	case canal.DeleteAction:
+		for _, row := range e.Rows {
+			changes = append(changes, newChange(e.Table, OpDelete, row))
+		}
+	case canal.UpdateAction:
+		// 更新事件成对出现：[更新前, 更新后]
+		for i := 0; i+1 < len(e.Rows); i += 2 {
+			before := newChange(e.Table, OpDelete, e.Rows[i])
+			after := newChange(e.Table, OpUpdate, e.Rows[i+1])
+			// 主键被修改时，旧主键视为删除
+			if before.RowKey != after.RowKey {
+				changes = append(changes, before)
+			}
+			changes = append(changes, after)
+		}
+	}
+
+	h.mu.Lock()
+	h.pending = append(h.pending, changes...)
+	h.mu.Unlock()
+
+	h.runner.touch()
+	return nil
+}
+
+// OnTableChanged 订阅表结构变更(canal 已清除表结构缓存，后续事件使用新结构解析)
+func (h *eventHandler) OnTableChanged(header *replication.EventHeader, db string, table string) error {
+	h.runner.schemaChanged(db, table)
+	return nil
+}
+
+// OnPosSynced 事务提交或位点切换时记录位点，按批量或时间间隔写入
+func (h *eventHandler) OnPosSynced(header *replication.EventHeader, pos mysql.Position, set mysql.GTIDSet, force bool) error {
+	h.mu.Lock()
+	h.pos = pos
+	h.dirty = true
+	shouldFlush := force || len(h.pending) >= flushBatchSize || time.Since(h.lastFlush) >= flushInterval
+	h.mu.Unlock()
+
+	if shouldFlush {
+		return h.flush()
+	}
+	return nil
+}
+
+// flush 将缓冲的变更和位点写入存储
+func (h *eventHandler) flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.dirty {
+		return nil
+	}
+	if err := GetStore().Commit(h.runner.source.ID, h.pending, h.pos.Name, h.pos.Pos); err != nil {
+		return fmt.Errorf("保存变更日志失败: %w", err)
+	}
+	h.pending = nil
+	h.dirty = false
+	h.lastFlush = time.Now()
+	h.runner.setCheckpoint(h.pos)
+	return nil
+}
+
+func (h *eventHandler) String() string {
+	return "mysql-sync-plugin-cdc"
+}
+
+// newChange 根据binlog行构建变更
+func newChange(table *schema.Table, op string, values []interface{}) Change {
+	row := make(Row, len(table.Columns))
+	for i, col := range table.Columns {
+		// 表结构变更前写入的binlog列数可能与当前结构不一致，缺失的列按NULL处理
+		if i >= len(values) {
+			row[col.Name] = nil
+			continue
+		}
+		row[col.Name] = newValue(&col, values[i])
+	}
+
+	keys := make([]*string, len(table.PKColumns))
+	for i, idx := range table.PKColumns {
+		if v := row[table.Columns[idx].Name]; v != nil {
+			text := keyText(v)
+			keys[i] = &text
+		}
+	}
+	rowKey, _ := json.Marshal(keys)
+
+	return Change{
+		Table:  table.Name,
+		RowKey: string(rowKey),
+		Op:     op,
+		Row:    row,
+	}
+}
+
+// newValue 将binlog解析出的值转换为与查询结果一致的表示
+func newValue(col *schema.TableColumn, v interface{}) *Value {
+	if v == nil {
+		return nil
+	}
+
+	switch col.Type {
+	case schema.TYPE_ENUM:
+		// ENUM 以序号(从1开始)记录
+		if n, ok := toInt64(v); ok {
+			if n <= 0 || int(n) > len(col.EnumValues) {
+				return &Value{Kind: "s", Text: ""}
+			}
+			return &Value{Kind: "s", Text: col.EnumValues[n-1]}
+		}
+	case schema.TYPE_SET:
+		// SET 以位图记录
+		if n, ok := toInt64(v); ok {
+			var items []string
+			for i, item := range col.SetValues {
+				if n&(1<<uint(i)) != 0 {
+					items = append(items, item)
+				}
+			}
+			return &Value{Kind: "s", Text: strings.Join(items, ",")}
+		}
+	case schema.TYPE_TIMESTAMP:
+		// TIMESTAMP 按UTC格式化，转换为带时区的文本，避免被当作源库时区的墙上时间
+		if s, ok := v.(string); ok {
+			if t, err := time.Parse("2006-01-02 15:04:05.999999", s); err == nil {
+				return &Value{Kind: "s", Text: t.UTC().Format(time.RFC3339Nano)}
+			}
+			return &Value{Kind: "s", Text: s}
+		}
+	}
+
+	switch val := v.(type) {
+	case int8, int16, int32, int64, int:
+		n, _ := toInt64(val)
+		return &Value{Kind: "i", Text: strconv.FormatInt(n, 10)}
+	case uint8, uint16, uint32, uint64, uint:
+		return &Value{Kind: "u", Text: fmt.Sprintf("%d", val)}
+	case float32:
+		return &Value{Kind: "f", Text: strconv.FormatFloat(float64(val), 'g', -1, 32)}
+	case float64:
+		return &Value{Kind: "f", Text: strconv.FormatFloat(val, 'g', -1, 64)}
+	case []byte:
+		if col.Type == schema.TYPE_BINARY || col.Type == schema.TYPE_POINT || !utf8.Valid(val) {
+			return &Value{Kind: "b", Text: base64.StdEncoding.EncodeToString(val)}
+		}
+		return &Value{Kind: "s", Text: string(val)}
+	case string:
+		if col.Type == schema.TYPE_BINARY || col.Type == schema.TYPE_POINT {
+			return &Value{Kind: "b", Text: base64.StdEncoding.EncodeToString([]byte(val))}
+		}
+		return &Value{Kind: "s", Text: val}
+	default:
+		return &Value{Kind: "s", Text: fmt.Sprintf("%v", val)}
+	}
+}
+
+// keyText 主键值的规范文本
+func keyText(v *Value) string {
+	if v.Kind == "b" {
+		if b, err := base64.StdEncoding.DecodeString(v.Text); err == nil {
+			return string(b)
+		}
+	}
+	return v.Text
+}
+
+// toInt64 将有符号整数转换为int64
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	}
+	return 0, false
+}
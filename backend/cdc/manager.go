@@ -0,0 +1,345 @@
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"mysql-sync-plugin/logger"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+const (
+	// 重连等待时间上限
+	maxReconnectBackoff = time.Minute
+	// 默认 server_id 基数，避免与常见的主从 server_id 冲突
+	defaultServerIDBase = 100000
+)
+
+// Manager 管理所有数据源的binlog订阅
+type Manager struct {
+	mu      sync.Mutex
+	runners map[int64]*runner
+	log     *logger.Logger
+}
+
+var (
+	manager     *Manager
+	managerOnce sync.Once
+)
+
+// GetManager 获取CDC管理器单例
+func GetManager() *Manager {
+	managerOnce.Do(func() {
+		manager = &Manager{
+			runners: make(map[int64]*runner),
+			log:     logger.New("cdc"),
+		}
+	})
+	return manager
+}
+
+// Start 启动所有已启用的数据源
+func (m *Manager) Start() error {
+	sources, err := GetStore().ListSources()
+	if err != nil {
+		return err
+	}
+	for i := range sources {
+		if sources[i].Enabled {
+			m.startSource(&sources[i])
+		}
+	}
+	return nil
+}
+
+// Restart 按最新配置重启数据源(已禁用的数据源仅停止)
+func (m *Manager) Restart(id int64) error {
+	m.Stop(id)
+
+	src, err := GetStore().GetSource(id)
+	if err != nil {
+		return err
+	}
+	if src != nil && src.Enabled {
+		m.startSource(src)
+	}
+	return nil
+}
+
+// Stop 停止数据源
+func (m *Manager) Stop(id int64) {
+	m.mu.Lock()
+	r := m.runners[id]
+	delete(m.runners, id)
+	m.mu.Unlock()
+
+	if r != nil {
+		r.stop()
+	}
+}
+
+// StopAll 停止所有数据源
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	runners := m.runners
+	m.runners = make(map[int64]*runner)
+	m.mu.Unlock()
+
+	for _, r := range runners {
+		r.stop()
+	}
+}
+
+// Status 获取数据源运行状态
+func (m *Manager) Status(id int64) SourceStatus {
+	m.mu.Lock()
+	r := m.runners[id]
+	m.mu.Unlock()
+
+	if r == nil {
+		return SourceStatus{}
+	}
+	return r.getStatus()
+}
+
+func (m *Manager) startSource(src *Source) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &runner{
+		source:         *src,
+		log:            m.log,
+		ctx:            ctx,
+		cancel:         cancel,
+		done:           make(chan struct{}),
+		warnedNoPKTabs: make(map[string]bool),
+	}
+
+	m.mu.Lock()
+	m.runners[src.ID] = r
+	m.mu.Unlock()
+
+	go r.run()
+}
+
+// runner 单个数据源的binlog订阅
+type runner struct {
+	source Source
+	log    *logger.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu             sync.Mutex
+	canal          *canal.Canal
+	status         SourceStatus
+	warnedNoPKTabs map[string]bool
+}
+
+// run 订阅循环：连接断开或出错后按退避时间重连，从已保存的位点继续
+func (r *runner) run() {
+	defer close(r.done)
+
+	r.mu.Lock()
+	r.status.Running = true
+	r.mu.Unlock()
+
+	backoff := time.Second
+	for {
+		err := r.runOnce()
+		if r.ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			r.setError(err)
+			r.log.Errorf("CDC订阅", "数据源 %s 订阅中断，%s 后重连: %v", r.source.Name, backoff, err)
+		}
+
+		select {
+		case <-r.ctx.Done():
+		case <-time.After(backoff):
+		}
+		if r.ctx.Err() != nil {
+			break
+		}
+		if backoff *= 2; backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+
+	r.mu.Lock()
+	r.status.Running = false
+	r.status.Connected = false
+	r.mu.Unlock()
+}
+
+// runOnce 建立一次binlog订阅，直到出错或停止
+func (r *runner) runOnce() error {
+	c, err := canal.NewCanal(r.canalConfig())
+	if err != nil {
+		return fmt.Errorf("创建binlog订阅失败: %w", err)
+	}
+	closeCanal := sync.OnceFunc(c.Close)
+	defer closeCanal()
+
+	if err := c.CheckBinlogRowImage("FULL"); err != nil {
+		return fmt.Errorf("需要将 binlog_row_image 设置为 FULL: %w", err)
+	}
+
+	handler := newEventHandler(r)
+	c.SetEventHandler(handler)
+
+	// 有位点时从位点继续，否则从当前位置开始
+	pos, err := r.startPosition(c)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.canal = c
+	r.status.Connected = true
+	r.mu.Unlock()
+	r.log.Infof("CDC订阅", "数据源 %s 从位点 %s 开始订阅", r.source.Name, pos)
+
+	// 定时写入缓冲的变更，避免低流量时变更长时间停留在内存中
+	flushDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-flushDone:
+				return
+			case <-ticker.C:
+				if err := handler.flush(); err != nil {
+					r.setError(err)
+				}
+			}
+		}
+	}()
+
+	// 停止时关闭连接以结束 RunFrom
+	stopWatch := make(chan struct{})
+	go func() {
+		select {
+		case <-r.ctx.Done():
+			closeCanal()
+		case <-stopWatch:
+		}
+	}()
+
+	runErr := c.RunFrom(pos)
+	close(stopWatch)
+	close(flushDone)
+
+	r.mu.Lock()
+	r.canal = nil
+	r.status.Connected = false
+	r.mu.Unlock()
+
+	// 保存已解析但尚未写入的变更
+	if err := handler.flush(); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+// canalConfig 构建binlog订阅配置
+func (r *runner) canalConfig() *canal.Config {
+	cfg := canal.NewDefaultConfig()
+	cfg.Addr = fmt.Sprintf("%s:%d", r.source.Host, r.source.Port)
+	cfg.User = r.source.Username
+	cfg.Password = r.source.Password
+	cfg.Charset = "utf8mb4"
+	cfg.ServerID = r.source.ServerID
+	if cfg.ServerID == 0 {
+		cfg.ServerID = uint32(defaultServerIDBase + r.source.ID)
+	}
+	cfg.UseDecimal = false
+	cfg.ParseTime = false
+	cfg.TimestampStringLocation = time.UTC
+	cfg.HeartbeatPeriod = 30 * time.Second
+	cfg.ReadTimeout = 90 * time.Second
+	cfg.MaxReconnectAttempts = 10
+	cfg.DiscardNoMetaRowEvent = true
+	// 不执行 mysqldump，仅订阅binlog
+	cfg.Dump.ExecutionPath = ""
+
+	for _, table := range r.source.Tables {
+		cfg.IncludeTableRegex = append(cfg.IncludeTableRegex,
+			"^"+regexp.QuoteMeta(r.source.Database)+"\\."+regexp.QuoteMeta(table)+"$")
+	}
+	return cfg
+}
+
+// startPosition 确定订阅起始位点
+func (r *runner) startPosition(c *canal.Canal) (mysql.Position, error) {
+	cp, err := GetStore().GetCheckpoint(r.source.ID)
+	if err != nil {
+		return mysql.Position{}, fmt.Errorf("读取位点失败: %w", err)
+	}
+	if cp != nil {
+		return mysql.Position{Name: cp.File, Pos: cp.Position}, nil
+	}
+
+	pos, err := c.GetMasterPos()
+	if err != nil {
+		return mysql.Position{}, fmt.Errorf("获取binlog位点失败: %w", err)
+	}
+	return pos, nil
+}
+
+func (r *runner) stop() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *runner) touch() {
+	now := time.Now()
+	r.mu.Lock()
+	r.status.LastEventAt = &now
+	r.mu.Unlock()
+}
+
+func (r *runner) setCheckpoint(pos mysql.Position) {
+	r.mu.Lock()
+	r.status.Checkpoint = pos.String()
+	r.mu.Unlock()
+}
+
+func (r *runner) setError(err error) {
+	now := time.Now()
+	r.mu.Lock()
+	r.status.LastError = err.Error()
+	r.status.LastErrorAt = &now
+	r.mu.Unlock()
+}
+
+// schemaChanged 记录表结构变更
+func (r *runner) schemaChanged(db, table string) {
+	now := time.Now()
+	r.mu.Lock()
+	r.status.SchemaChangedAt = &now
+	r.mu.Unlock()
+	r.log.Warnf("CDC订阅", "数据源 %s 的表 %s.%s 结构已变更，后续变更按新结构解析", r.source.Name, db, table)
+}
+
+// warnNoPrimaryKey 无主键的表无法按主键记录变更，仅提示一次
+func (r *runner) warnNoPrimaryKey(table string) {
+	r.mu.Lock()
+	warned := r.warnedNoPKTabs[table]
+	r.warnedNoPKTabs[table] = true
+	r.mu.Unlock()
+
+	if !warned {
+		r.log.Warnf("CDC订阅", "数据源 %s 的表 %s 没有主键，已忽略其变更", r.source.Name, table)
+	}
+}
+
+func (r *runner) getStatus() SourceStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
@@ -0,0 +1,104 @@
+package cdc
+
+import (
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+// 变更类型
+const (
+	OpInsert = "insert"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Source CDC数据源(一个MySQL实例上需要订阅binlog的表)
+type Source struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password,omitempty"` // 列表接口中不返回
+	Database  string    `json:"database"`
+	Tables    []string  `json:"tables"`   // 订阅的表
+	ServerID  uint32    `json:"serverId"` // 作为复制从库使用的 server_id，需在MySQL集群中唯一
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// HasTable 判断数据源是否订阅了指定表
+func (s *Source) HasTable(table string) bool {
+	for _, t := range s.Tables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// Checkpoint binlog消费位点
+type Checkpoint struct {
+	SourceID  int64     `json:"sourceId"`
+	File      string    `json:"file"`
+	Position  uint32    `json:"position"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Change 变更日志中的一条记录，同一主键只保留最新一次变更
+type Change struct {
+	Seq      int64  `json:"seq"` // 全局递增序号，用于增量读取
+	SourceID int64  `json:"sourceId"`
+	Table    string `json:"table"`
+	RowKey   string `json:"rowKey"` // 主键值(JSON数组)
+	Op       string `json:"op"`
+	Row      Row    `json:"row"` // 变更后的行(删除时为删除前的行)
+}
+
+// Row 行数据，列名 -> 值(NULL 为 nil)
+type Row map[string]*Value
+
+// Value 变更日志中保存的列值，保留原始类型以便还原为与查询结果一致的Go类型
+type Value struct {
+	Kind string `json:"k"` // i=有符号整数 u=无符号整数 f=浮点数 s=文本 b=二进制(Base64)
+	Text string `json:"v"`
+}
+
+// Interface 还原为Go值
+func (v *Value) Interface() interface{} {
+	if v == nil {
+		return nil
+	}
+	switch v.Kind {
+	case "i":
+		if n, err := strconv.ParseInt(v.Text, 10, 64); err == nil {
+			return n
+		}
+	case "u":
+		if n, err := strconv.ParseUint(v.Text, 10, 64); err == nil {
+			return n
+		}
+	case "f":
+		if f, err := strconv.ParseFloat(v.Text, 64); err == nil {
+			return f
+		}
+	case "b":
+		if b, err := base64.StdEncoding.DecodeString(v.Text); err == nil {
+			return b
+		}
+	}
+	return v.Text
+}
+
+// SourceStatus 数据源运行状态
+type SourceStatus struct {
+	Running         bool       `json:"running"`
+	Connected       bool       `json:"connected"`
+	LastError       string     `json:"lastError,omitempty"`
+	LastErrorAt     *time.Time `json:"lastErrorAt,omitempty"`
+	LastEventAt     *time.Time `json:"lastEventAt,omitempty"`
+	SchemaChangedAt *time.Time `json:"schemaChangedAt,omitempty"` // 最近一次订阅表结构变更时间
+	Checkpoint      string     `json:"checkpoint,omitempty"`
+}
@@ -0,0 +1,349 @@
+package cdc
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store CDC存储(数据源、消费位点、变更日志)
+type Store struct {
+	db      *sql.DB
+	mu      sync.RWMutex
+	lastSeq int64
+}
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// GetStore 获取CDC存储单例
+func GetStore() *Store {
+	once.Do(func() {
+		instance = &Store{}
+	})
+	return instance
+}
+
+// Init 初始化数据库
+func (s *Store) Init(dbPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	// 创建数据源、位点和变更日志表
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS cdc_sources (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		host TEXT NOT NULL,
+		port INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		password TEXT NOT NULL,
+		database_name TEXT NOT NULL,
+		tables TEXT NOT NULL,
+		server_id INTEGER NOT NULL,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS cdc_checkpoints (
+		source_id INTEGER PRIMARY KEY,
+		binlog_file TEXT NOT NULL,
+		binlog_pos INTEGER NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS cdc_changes (
+		seq INTEGER NOT NULL,
+		source_id INTEGER NOT NULL,
+		table_name TEXT NOT NULL,
+		row_key TEXT NOT NULL,
+		op TEXT NOT NULL,
+		row_data TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (source_id, table_name, row_key)
+	);
+	CREATE INDEX IF NOT EXISTS idx_cdc_changes_seq ON cdc_changes(source_id, table_name, seq);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		db.Close()
+		return fmt.Errorf("创建表失败: %w", err)
+	}
+
+	if err := db.QueryRow("SELECT COALESCE(MAX(seq), 0) FROM cdc_changes").Scan(&s.lastSeq); err != nil {
+		db.Close()
+		return fmt.Errorf("读取变更序号失败: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+const sourceColumns = "id, name, host, port, username, password, database_name, tables, server_id, enabled, created_at, updated_at"
+
+// scanSource 扫描数据源
+func scanSource(scanner interface{ Scan(...interface{}) error }) (*Source, error) {
+	var src Source
+	var tables string
+	if err := scanner.Scan(&src.ID, &src.Name, &src.Host, &src.Port, &src.Username, &src.Password,
+		&src.Database, &tables, &src.ServerID, &src.Enabled, &src.CreatedAt, &src.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(tables), &src.Tables)
+	return &src, nil
+}
+
+// ListSources 获取所有数据源
+func (s *Store) ListSources() ([]Source, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query("SELECT " + sourceColumns + " FROM cdc_sources ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Source
+	for rows.Next() {
+		src, err := scanSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *src)
+	}
+	return list, rows.Err()
+}
+
+// GetSource 根据ID获取数据源
+func (s *Store) GetSource(id int64) (*Source, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	src, err := scanSource(s.db.QueryRow("SELECT "+sourceColumns+" FROM cdc_sources WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return src, err
+}
+
+// FindSource 查找订阅了指定表的已启用数据源
+func (s *Store) FindSource(host string, port int, database, table string) (*Source, error) {
+	sources, err := s.ListSources()
+	if err != nil {
+		return nil, err
+	}
+	for i := range sources {
+		src := &sources[i]
+		if src.Enabled && strings.EqualFold(src.Host, host) && src.Port == port && src.Database == database && src.HasTable(table) {
+			return src, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveSource 保存数据源(ID为0时新建)，密码为空时保留原密码
+func (s *Store) SaveSource(src *Source) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tables, _ := json.Marshal(src.Tables)
+	if src.ID == 0 {
+		result, err := s.db.Exec(
+			"INSERT INTO cdc_sources (name, host, port, username, password, database_name, tables, server_id, enabled) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			src.Name, src.Host, src.Port, src.Username, src.Password, src.Database, string(tables), src.ServerID, src.Enabled,
+		)
+		if err != nil {
+			return err
+		}
+		src.ID, err = result.LastInsertId()
+		return err
+	}
+
+	query := "UPDATE cdc_sources SET name = ?, host = ?, port = ?, username = ?, database_name = ?, tables = ?, server_id = ?, enabled = ?, updated_at = ?"
+	args := []interface{}{src.Name, src.Host, src.Port, src.Username, src.Database, string(tables), src.ServerID, src.Enabled, time.Now()}
+	if src.Password != "" {
+		query += ", password = ?"
+		args = append(args, src.Password)
+	}
+	query += " WHERE id = ?"
+	args = append(args, src.ID)
+
+	_, err := s.db.Exec(query, args...)
+	return err
+}
+
+// DeleteSource 删除数据源及其位点和变更日志
+func (s *Store) DeleteSource(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM cdc_sources WHERE id = ?",
+		"DELETE FROM cdc_checkpoints WHERE source_id = ?",
+		"DELETE FROM cdc_changes WHERE source_id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCheckpoint 获取消费位点，不存在时返回nil
+func (s *Store) GetCheckpoint(sourceID int64) (*Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var cp Checkpoint
+	err := s.db.QueryRow(
+		"SELECT source_id, binlog_file, binlog_pos, updated_at FROM cdc_checkpoints WHERE source_id = ?",
+		sourceID,
+	).Scan(&cp.SourceID, &cp.File, &cp.Position, &cp.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// Commit 在同一事务中写入变更并推进位点，保证位点不会越过未保存的变更
+func (s *Store) Commit(sourceID int64, changes []Change, file string, pos uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	seq := s.lastSeq
+	for _, c := range changes {
+		rowData, err := json.Marshal(c.Row)
+		if err != nil {
+			return err
+		}
+		seq++
+		if _, err := tx.Exec(`
+			INSERT INTO cdc_changes (seq, source_id, table_name, row_key, op, row_data, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(source_id, table_name, row_key) DO UPDATE SET
+				seq = excluded.seq,
+				op = excluded.op,
+				row_data = excluded.row_data,
+				updated_at = excluded.updated_at`,
+			seq, sourceID, c.Table, c.RowKey, c.Op, string(rowData), time.Now(),
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO cdc_checkpoints (source_id, binlog_file, binlog_pos, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(source_id) DO UPDATE SET
+			binlog_file = excluded.binlog_file,
+			binlog_pos = excluded.binlog_pos,
+			updated_at = excluded.updated_at`,
+		sourceID, file, pos, time.Now(),
+	); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.lastSeq = seq
+	return nil
+}
+
+// MaxSeq 获取表的最新变更序号
+func (s *Store) MaxSeq(sourceID int64, table string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var seq int64
+	err := s.db.QueryRow(
+		"SELECT COALESCE(MAX(seq), 0) FROM cdc_changes WHERE source_id = ? AND table_name = ?",
+		sourceID, table,
+	).Scan(&seq)
+	return seq, err
+}
+
+// CountChanges 统计序号在 (after, upTo] 范围内的变更数
+func (s *Store) CountChanges(sourceID int64, table string, after, upTo int64) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM cdc_changes WHERE source_id = ? AND table_name = ? AND seq > ? AND seq <= ?",
+		sourceID, table, after, upTo,
+	).Scan(&count)
+	return count, err
+}
+
+// ListChanges 按序号读取 (after, upTo] 范围内的变更
+func (s *Store) ListChanges(sourceID int64, table string, after, upTo int64, limit int) ([]Change, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT seq, source_id, table_name, row_key, op, row_data
+		FROM cdc_changes
+		WHERE source_id = ? AND table_name = ? AND seq > ? AND seq <= ?
+		ORDER BY seq
+		LIMIT ?`,
+		sourceID, table, after, upTo, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Change
+	for rows.Next() {
+		var c Change
+		var rowData string
+		if err := rows.Scan(&c.Seq, &c.SourceID, &c.Table, &c.RowKey, &c.Op, &rowData); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(rowData), &c.Row); err != nil {
+			return nil, fmt.Errorf("解析变更数据失败: %w", err)
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,288 @@
+// fake-platform 模拟飞书多维表格和钉钉AI表格的记录接口，用于联调定时推送任务
+//
+// 启动后将任务的开放平台地址(baseUrl)设为 http://localhost:9100，应用凭证可任意填写:
+//
+//	go run ./cmd/fake-platform -addr :9100 -fail-rate 0.2
+//
+// GET /records 查看各表格当前的全部记录；-fail-rate 按比例对记录接口返回限流错误，用于验证重试。
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	feishuToken   = "fake-feishu-token"
+	dingTalkToken = "fake-dingtalk-token"
+)
+
+// server 内存中的表格，键为 平台/表格/数据表
+type server struct {
+	mu       sync.Mutex
+	tables   map[string]map[string]map[string]interface{} // 表 -> 记录ID -> 字段
+	seq      int
+	failRate float64
+	maxBatch map[string]int
+	created  map[string]interface{} // 飞书 client_token -> 新增结果，重复请求返回同一结果
+}
+
+func main() {
+	addr := flag.String("addr", ":9100", "监听地址")
+	failRate := flag.Float64("fail-rate", 0, "记录接口返回限流错误的比例(0-1)")
+	flag.Parse()
+
+	s := &server{
+		tables:   make(map[string]map[string]map[string]interface{}),
+		failRate: *failRate,
+		maxBatch: map[string]int{"feishu": 1000, "dingtalk": 100},
+		created:  make(map[string]interface{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/open-apis/auth/v3/tenant_access_token/internal", s.feishuToken)
+	mux.HandleFunc("/open-apis/bitable/v1/apps/", s.feishuRecords)
+	mux.HandleFunc("/v1.0/oauth2/accessToken", s.dingTalkToken)
+	mux.HandleFunc("/v1.0/notable/bases/", s.dingTalkRecords)
+	mux.HandleFunc("/records", s.dump)
+
+	log.Printf("模拟开放平台监听 %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// table 获取(不存在时创建)数据表
+func (s *server) table(key string) map[string]map[string]interface{} {
+	t := s.tables[key]
+	if t == nil {
+		t = make(map[string]map[string]interface{})
+		s.tables[key] = t
+	}
+	return t
+}
+
+func (s *server) newID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s%06d", prefix, s.seq)
+}
+
+// throttled 按比例模拟限流
+func (s *server) throttled() bool {
+	return s.failRate > 0 && rand.Float64() < s.failRate
+}
+
+func (s *server) dump(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.tables)
+}
+
+// ==================== 飞书 ====================
+
+func (s *server) feishuToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AppID     string `json:"app_id"`
+		AppSecret string `json:"app_secret"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.AppID == "" || req.AppSecret == "" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 10003, "msg": "invalid param"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"code": 0, "msg": "ok", "tenant_access_token": feishuToken, "expire": 7200})
+}
+
+// feishuRecords /open-apis/bitable/v1/apps/:app_token/tables/:table_id/records/:action
+func (s *server) feishuRecords(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/open-apis/bitable/v1/apps/"), "/")
+	if r.Method != http.MethodPost || len(parts) != 5 || parts[1] != "tables" || parts[3] != "records" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+feishuToken {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 99991663, "msg": "invalid access token"})
+		return
+	}
+	if s.throttled() {
+		if rand.Intn(2) == 0 {
+			writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{"code": 99991400, "msg": "request trigger frequency limit"})
+		} else {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"code": 1254290, "msg": "TooManyRequest"})
+		}
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.table("feishu/" + parts[0] + "/" + parts[2])
+
+	fail := func(code int, msg string) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": code, "msg": msg})
+	}
+	ok := func(data interface{}) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"code": 0, "msg": "success", "data": data})
+	}
+
+	clientToken := r.URL.Query().Get("client_token")
+	if parts[4] == "batch_create" && clientToken != "" {
+		if data, exists := s.created[parts[0]+"/"+parts[2]+"/"+clientToken]; exists {
+			ok(data)
+			return
+		}
+	}
+
+	switch parts[4] {
+	case "batch_create", "batch_update":
+		var req struct {
+			Records []struct {
+				RecordID string                 `json:"record_id"`
+				Fields   map[string]interface{} `json:"fields"`
+			} `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			fail(1254000, "WrongRequestBody")
+			return
+		}
+		if len(req.Records) > s.maxBatch["feishu"] {
+			fail(1254104, "RecordAddOnceExceedLimit")
+			return
+		}
+		var result []map[string]interface{}
+		for _, rec := range req.Records {
+			id := rec.RecordID
+			if parts[4] == "batch_create" {
+				id = s.newID("rec")
+			} else if _, exists := t[id]; !exists {
+				fail(1254043, "RecordIdNotFound")
+				return
+			}
+			t[id] = rec.Fields
+			result = append(result, map[string]interface{}{"record_id": id, "fields": rec.Fields})
+		}
+		data := map[string]interface{}{"records": result}
+		if parts[4] == "batch_create" && clientToken != "" {
+			s.created[parts[0]+"/"+parts[2]+"/"+clientToken] = data
+		}
+		ok(data)
+	case "batch_delete":
+		var req struct {
+			Records []string `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			fail(1254000, "WrongRequestBody")
+			return
+		}
+		var result []map[string]interface{}
+		for _, id := range req.Records {
+			_, exists := t[id]
+			delete(t, id)
+			result = append(result, map[string]interface{}{"record_id": id, "deleted": exists})
+		}
+		ok(map[string]interface{}{"records": result})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// ==================== 钉钉 ====================
+
+func (s *server) dingTalkToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AppKey    string `json:"appKey"`
+		AppSecret string `json:"appSecret"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.AppKey == "" || req.AppSecret == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"code": "InvalidParameter", "message": "appKey or appSecret missing"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"accessToken": dingTalkToken, "expireIn": 7200})
+}
+
+// dingTalkRecords /v1.0/notable/bases/:baseId/sheets/:sheetId/records[/delete]?operatorId=
+func (s *server) dingTalkRecords(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1.0/notable/bases/"), "/")
+	if len(parts) < 4 || parts[1] != "sheets" || parts[3] != "records" {
+		http.NotFound(w, r)
+		return
+	}
+	fail := func(status int, code, msg string) {
+		writeJSON(w, status, map[string]interface{}{"code": code, "message": msg})
+	}
+	if r.Header.Get("x-acs-dingtalk-access-token") != dingTalkToken {
+		fail(http.StatusUnauthorized, "InvalidAuthentication", "不合法的access_token")
+		return
+	}
+	if r.URL.Query().Get("operatorId") == "" {
+		fail(http.StatusBadRequest, "MissingoperatorId", "operatorId is mandatory for this action")
+		return
+	}
+	if s.throttled() {
+		fail(http.StatusForbidden, "Forbidden.AccessDenied.QpsLimitForApi", "调用该接口触发了限流")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.table("dingtalk/" + parts[0] + "/" + parts[2])
+
+	switch {
+	case len(parts) == 5 && parts[4] == "delete" && r.Method == http.MethodPost:
+		var req struct {
+			RecordIDs []string `json:"recordIds"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			fail(http.StatusBadRequest, "InvalidParameter", err.Error())
+			return
+		}
+		if len(req.RecordIDs) > s.maxBatch["dingtalk"] {
+			fail(http.StatusBadRequest, "InvalidParameter", "recordIds too many")
+			return
+		}
+		for _, id := range req.RecordIDs {
+			delete(t, id)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+	case len(parts) == 4 && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+		var req struct {
+			Records []struct {
+				ID     string                 `json:"id"`
+				Fields map[string]interface{} `json:"fields"`
+			} `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			fail(http.StatusBadRequest, "InvalidParameter", err.Error())
+			return
+		}
+		if len(req.Records) > s.maxBatch["dingtalk"] {
+			fail(http.StatusBadRequest, "InvalidParameter", "records too many")
+			return
+		}
+		var value []map[string]interface{}
+		for _, rec := range req.Records {
+			id := rec.ID
+			if r.Method == http.MethodPost {
+				id = s.newID("r")
+			} else if _, exists := t[id]; !exists {
+				fail(http.StatusBadRequest, "RecordNotFound", "record "+id+" not found")
+				return
+			}
+			t[id] = rec.Fields
+			value = append(value, map[string]interface{}{"id": id})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"value": value})
+	default:
+		http.NotFound(w, r)
+	}
+}
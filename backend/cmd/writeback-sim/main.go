@@ -0,0 +1,115 @@
+// writeback-sim 模拟表格发送记录变更回调，用于联调回写目标
+//
+// 示例:
+//
+//	go run ./cmd/writeback-sim -target orders -secret <密钥> -record 42 -version 3 -set status=done -set remark='"已处理"'
+//	go run ./cmd/writeback-sim -target orders -secret <密钥> -file changes.json
+//
+// -set 的值按JSON解析(数字、true/false、null、带引号的文本)，解析失败时作为文本；
+// -file 为完整的回调内容(Callback JSON)。
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mysql-sync-plugin/writeback"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setFlags 可重复的 列=值 参数
+type setFlags []string
+
+func (s *setFlags) String() string     { return strings.Join(*s, ", ") }
+func (s *setFlags) Set(v string) error { *s = append(*s, v); return nil }
+
+func main() {
+	server := flag.String("url", "http://localhost:7138", "插件服务地址")
+	target := flag.String("target", "", "回写目标名称")
+	secret := flag.String("secret", "", "回写目标的回调签名密钥")
+	recordID := flag.String("record", "", "记录ID(同步时生成的ID，单列整数主键即为主键值)")
+	version := flag.String("version", "", "表格中记录的版本值(版本号或更新时间的毫秒时间戳)")
+	operator := flag.String("operator", "writeback-sim", "操作人")
+	file := flag.String("file", "", "从文件读取完整的回调内容")
+	var sets setFlags
+	flag.Var(&sets, "set", "回写的列，格式 列=值，可重复")
+	flag.Parse()
+
+	if *target == "" || *secret == "" {
+		log.Fatal("请指定 -target 和 -secret")
+	}
+
+	var body []byte
+	if *file != "" {
+		b, err := os.ReadFile(*file)
+		if err != nil {
+			log.Fatalf("读取文件失败: %v", err)
+		}
+		body = b
+	} else {
+		if *recordID == "" || len(sets) == 0 {
+			log.Fatal("请指定 -record 和至少一个 -set，或使用 -file")
+		}
+		change := writeback.RecordChange{RecordID: *recordID, Fields: make(map[string]interface{})}
+		if *version != "" {
+			change.Version = *version
+		}
+		for _, s := range sets {
+			i := strings.Index(s, "=")
+			if i <= 0 {
+				log.Fatalf("无效的 -set: %s", s)
+			}
+			var v interface{}
+			if err := json.Unmarshal([]byte(s[i+1:]), &v); err != nil {
+				v = s[i+1:]
+			}
+			change.Fields[s[:i]] = v
+		}
+		cb := writeback.Callback{
+			EventID:  "sim-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+			Operator: *operator,
+			Records:  []writeback.RecordChange{change},
+		}
+		b, err := json.Marshal(cb)
+		if err != nil {
+			log.Fatal(err)
+		}
+		body = b
+	}
+
+	nonceBytes := make([]byte, 8)
+	rand.Read(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := hex.EncodeToString(nonceBytes)
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(*server, "/")+"/writeback/api/callback/"+*target, bytes.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(writeback.HeaderTimestamp, timestamp)
+	req.Header.Set(writeback.HeaderNonce, nonce)
+	req.Header.Set(writeback.HeaderSignature, writeback.Sign(*secret, timestamp, nonce, body))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, respBody, "", "  ") == nil {
+		respBody = pretty.Bytes()
+	}
+	fmt.Printf("HTTP %d\n%s\n", resp.StatusCode, respBody)
+}
@@ -11,7 +11,8 @@ type Config struct {
 	SecretKey string // 与钉钉约定的签名密钥
 
 	// 数据库配置
-	DBPath string // SQLite数据库路径
+	DBPath    string // SQLite数据库路径
+	SpoolPath string // 查询结果缓存数据库路径(与主库分开，避免缓存数据膨胀主库)
 
 	// 应用配置
 	Debug bool
@@ -23,6 +24,7 @@ func Load() *Config {
 		ServerPort: getEnv("SERVER_PORT", "7138"),
 		SecretKey:  getEnv("SECRET_KEY", "your-secret-key-here"),
 		DBPath:     getEnv("DB_PATH", "./data/app.db"),
+		SpoolPath:  getEnv("SPOOL_PATH", "./data/spool.db"),
 		Debug:      getEnv("DEBUG", "false") == "true",
 	}
 }
@@ -0,0 +1,29 @@
+package costguard
+
+import "time"
+
+// 超出阈值时的处理方式
+const (
+	ActionOff    = "off"    // 不检查
+	ActionWarn   = "warn"   // 继续执行，告警写入同步日志
+	ActionReject = "reject" // 拒绝执行
+)
+
+// Settings 查询代价限制，由管理员设置，阈值为0表示不限制
+type Settings struct {
+	Action       string    `json:"action"`
+	MaxCost      float64   `json:"maxCost"`      // 优化器估算的查询代价(query_cost)上限
+	MaxRows      int64     `json:"maxRows"`      // 估算检查的行数上限
+	FullScanRows int64     `json:"fullScanRows"` // 允许全表扫描的表行数上限
+	UpdatedBy    string    `json:"updatedBy"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ValidAction 校验处理方式
+func ValidAction(action string) bool {
+	switch action {
+	case ActionOff, ActionWarn, ActionReject:
+		return true
+	}
+	return false
+}
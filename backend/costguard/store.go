@@ -0,0 +1,104 @@
+package costguard
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store 查询代价限制存储
+type Store struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// GetStore 获取查询代价限制存储单例
+func GetStore() *Store {
+	once.Do(func() {
+		instance = &Store{}
+	})
+	return instance
+}
+
+// Init 初始化数据库
+func (s *Store) Init(dbPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	// 只有一行的设置表，默认只告警不拒绝
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS cost_guard_settings (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		action TEXT NOT NULL DEFAULT 'warn',
+		max_cost REAL NOT NULL DEFAULT 0,
+		max_rows INTEGER NOT NULL DEFAULT 0,
+		full_scan_rows INTEGER NOT NULL DEFAULT 0,
+		updated_by TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME
+	);
+	INSERT OR IGNORE INTO cost_guard_settings (id) VALUES (1);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		db.Close()
+		return fmt.Errorf("创建表失败: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// Get 获取当前设置
+func (s *Store) Get() (*Settings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var st Settings
+	var updatedAt sql.NullTime
+	err := s.db.QueryRow(
+		"SELECT action, max_cost, max_rows, full_scan_rows, updated_by, updated_at FROM cost_guard_settings WHERE id = 1",
+	).Scan(&st.Action, &st.MaxCost, &st.MaxRows, &st.FullScanRows, &st.UpdatedBy, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	st.UpdatedAt = updatedAt.Time
+	return &st, nil
+}
+
+// Save 保存设置
+func (s *Store) Save(st *Settings, updatedBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st.UpdatedBy = updatedBy
+	st.UpdatedAt = time.Now()
+	_, err := s.db.Exec(
+		"UPDATE cost_guard_settings SET action = ?, max_cost = ?, max_rows = ?, full_scan_rows = ?, updated_by = ?, updated_at = ? WHERE id = 1",
+		st.Action, st.MaxCost, st.MaxRows, st.FullScanRows, st.UpdatedBy, st.UpdatedAt,
+	)
+	return err
+}
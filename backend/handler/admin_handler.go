@@ -1,9 +1,20 @@
 package handler
 
 import (
+	"mysql-sync-plugin/auth"
+	"mysql-sync-plugin/cdc"
+	"mysql-sync-plugin/costguard"
 	"mysql-sync-plugin/logger"
 	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/querylib"
+	"mysql-sync-plugin/scheduler"
+	"mysql-sync-plugin/service"
+	"mysql-sync-plugin/spool"
+	"mysql-sync-plugin/syncstate"
+	"mysql-sync-plugin/writeback"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -105,13 +116,1008 @@ func (h *AdminHandler) CleanLogs(c *gin.Context) {
 	})
 }
 
+// GetWatermarks 获取增量同步水位列表
+func (h *AdminHandler) GetWatermarks(c *gin.Context) {
+	list, err := syncstate.GetStore().ListWatermarks()
+	if err != nil {
+		h.log.Errorf("查询水位", "查询水位失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询水位失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: list,
+	})
+}
+
+// ResetWatermark 重置增量同步水位，下次同步将执行全量同步
+func (h *AdminHandler) ResetWatermark(c *gin.Context) {
+	var req struct {
+		TargetKey string `json:"targetKey"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.TargetKey == "" {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定同步目标",
+		})
+		return
+	}
+
+	affected, err := syncstate.GetStore().DeleteWatermark(req.TargetKey)
+	if err != nil {
+		h.log.Errorf("重置水位", "重置水位失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "重置水位失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("重置水位", "重置了同步目标 %s 的水位", req.TargetKey)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"affected": affected,
+		},
+	})
+}
+
+// GetSyncReports 获取同步报告列表
+func (h *AdminHandler) GetSyncReports(c *gin.Context) {
+	var query syncstate.ReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	list, total, err := syncstate.GetStore().ListReports(&query)
+	if err != nil {
+		h.log.Errorf("查询同步报告", "查询同步报告失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询同步报告失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"list":     list,
+			"total":    total,
+			"page":     query.Page,
+			"pageSize": query.PageSize,
+		},
+	})
+}
+
+// cdcSourceView 数据源及其运行状态
+type cdcSourceView struct {
+	cdc.Source
+	Status cdc.SourceStatus `json:"status"`
+}
+
+// GetCDCSources 获取CDC数据源列表
+func (h *AdminHandler) GetCDCSources(c *gin.Context) {
+	sources, err := cdc.GetStore().ListSources()
+	if err != nil {
+		h.log.Errorf("查询CDC数据源", "查询失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询CDC数据源失败: " + err.Error(),
+		})
+		return
+	}
+
+	list := make([]cdcSourceView, len(sources))
+	for i, src := range sources {
+		src.Password = ""
+		list[i] = cdcSourceView{
+			Source: src,
+			Status: cdc.GetManager().Status(src.ID),
+		}
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: list,
+	})
+}
+
+// SaveCDCSource 新建或更新CDC数据源，保存后按新配置重启订阅
+func (h *AdminHandler) SaveCDCSource(c *gin.Context) {
+	var src cdc.Source
+	if err := c.ShouldBindJSON(&src); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+	if src.Name == "" || src.Host == "" || src.Port <= 0 || src.Username == "" || src.Database == "" || len(src.Tables) == 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请填写名称、连接信息、数据库和订阅的表",
+		})
+		return
+	}
+	if src.ID == 0 && src.Password == "" {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请填写密码",
+		})
+		return
+	}
+
+	if err := cdc.GetStore().SaveSource(&src); err != nil {
+		h.log.Errorf("保存CDC数据源", "保存失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "保存CDC数据源失败: " + err.Error(),
+		})
+		return
+	}
+
+	if err := cdc.GetManager().Restart(src.ID); err != nil {
+		h.log.Errorf("保存CDC数据源", "重启订阅失败: %v", err)
+	}
+
+	h.log.Infof("保存CDC数据源", "保存了CDC数据源 %s", src.Name)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"id": src.ID,
+		},
+	})
+}
+
+// DeleteCDCSource 删除CDC数据源及其变更日志
+func (h *AdminHandler) DeleteCDCSource(c *gin.Context) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定数据源",
+		})
+		return
+	}
+
+	cdc.GetManager().Stop(req.ID)
+	if err := cdc.GetStore().DeleteSource(req.ID); err != nil {
+		h.log.Errorf("删除CDC数据源", "删除失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "删除CDC数据源失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("删除CDC数据源", "删除了CDC数据源 %d", req.ID)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+	})
+}
+
+// RestartCDCSource 重启CDC数据源订阅
+func (h *AdminHandler) RestartCDCSource(c *gin.Context) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定数据源",
+		})
+		return
+	}
+
+	if err := cdc.GetManager().Restart(req.ID); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "重启订阅失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+	})
+}
+
+// GetQueryProfiles 获取查询库的连接配置列表
+func (h *AdminHandler) GetQueryProfiles(c *gin.Context) {
+	profiles, err := querylib.GetStore().ListProfiles()
+	if err != nil {
+		h.log.Errorf("查询连接配置", "查询失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询连接配置失败: " + err.Error(),
+		})
+		return
+	}
+
+	for i := range profiles {
+		profiles[i].Password = ""
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: profiles,
+	})
+}
+
+// SaveQueryProfile 新建或更新连接配置
+func (h *AdminHandler) SaveQueryProfile(c *gin.Context) {
+	var p querylib.Profile
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+	if p.Name == "" || p.Host == "" || p.Port <= 0 || p.Username == "" || p.Database == "" {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请填写名称、连接信息和数据库",
+		})
+		return
+	}
+	if p.ID == 0 && p.Password == "" {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请填写密码",
+		})
+		return
+	}
+	if p.TimeZone != "" {
+		if _, err := time.LoadLocation(p.TimeZone); err != nil {
+			c.JSON(http.StatusOK, models.Response{
+				Code: models.CodeParamError,
+				Msg:  "无效的时区: " + p.TimeZone,
+			})
+			return
+		}
+	}
+
+	if err := querylib.GetStore().SaveProfile(&p); err != nil {
+		h.log.Errorf("保存连接配置", "保存失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "保存连接配置失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("保存连接配置", "保存了连接配置 %s", p.Name)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"id": p.ID,
+		},
+	})
+}
+
+// DeleteQueryProfile 删除连接配置
+func (h *AdminHandler) DeleteQueryProfile(c *gin.Context) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定连接配置",
+		})
+		return
+	}
+
+	if err := querylib.GetStore().DeleteProfile(req.ID); err != nil {
+		h.log.Errorf("删除连接配置", "删除失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "删除连接配置失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("删除连接配置", "删除了连接配置 %d", req.ID)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+	})
+}
+
+// GetSavedQueries 获取已发布查询列表
+func (h *AdminHandler) GetSavedQueries(c *gin.Context) {
+	queries, err := querylib.GetStore().ListQueries()
+	if err != nil {
+		h.log.Errorf("查询已发布查询", "查询失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询已发布查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: queries,
+	})
+}
+
+// SaveSavedQuery 新建或更新已发布查询，SQL或参数变化时发布新版本
+func (h *AdminHandler) SaveSavedQuery(c *gin.Context) {
+	var q querylib.Query
+	if err := c.ShouldBindJSON(&q); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+	q.Name = strings.TrimSpace(q.Name)
+	if q.Name == "" || q.ProfileID <= 0 || strings.TrimSpace(q.SQL) == "" {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请填写名称、连接配置和SQL",
+		})
+		return
+	}
+
+	store := querylib.GetStore()
+	if profile, err := store.GetProfile(q.ProfileID); err != nil || profile == nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "连接配置不存在",
+		})
+		return
+	}
+	if err := service.ValidateSavedQuery(&q); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "SQL模板无效: " + err.Error(),
+		})
+		return
+	}
+
+	author := ""
+	if user, ok := c.Get("user"); ok {
+		author = user.(*auth.User).Username
+	}
+	if q.Owner == "" {
+		q.Owner = author
+	}
+
+	if err := store.SaveQuery(&q, author); err != nil {
+		h.log.Errorf("保存已发布查询", "保存失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "保存已发布查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("保存已发布查询", "保存了已发布查询 %s (版本 %d)", q.Name, q.Version)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"id":      q.ID,
+			"version": q.Version,
+		},
+	})
+}
+
+// GetSavedQueryVersions 获取已发布查询的版本历史
+func (h *AdminHandler) GetSavedQueryVersions(c *gin.Context) {
+	var req struct {
+		ID int64 `form:"id"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定已发布查询",
+		})
+		return
+	}
+
+	versions, err := querylib.GetStore().ListVersions(req.ID)
+	if err != nil {
+		h.log.Errorf("查询版本历史", "查询失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询版本历史失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: versions,
+	})
+}
+
+// DeleteSavedQuery 删除已发布查询及其版本历史
+func (h *AdminHandler) DeleteSavedQuery(c *gin.Context) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定已发布查询",
+		})
+		return
+	}
+
+	if err := querylib.GetStore().DeleteQuery(req.ID); err != nil {
+		h.log.Errorf("删除已发布查询", "删除失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "删除已发布查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("删除已发布查询", "删除了已发布查询 %d", req.ID)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+	})
+}
+
+// GetCostGuard 获取查询代价限制
+func (h *AdminHandler) GetCostGuard(c *gin.Context) {
+	settings, err := costguard.GetStore().Get()
+	if err != nil {
+		h.log.Errorf("查询代价限制", "查询设置失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询设置失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: settings,
+	})
+}
+
+// SaveCostGuard 保存查询代价限制
+func (h *AdminHandler) SaveCostGuard(c *gin.Context) {
+	var settings costguard.Settings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+	if !costguard.ValidAction(settings.Action) {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "不支持的处理方式: " + settings.Action,
+		})
+		return
+	}
+	if settings.MaxCost < 0 || settings.MaxRows < 0 || settings.FullScanRows < 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "阈值不能为负数",
+		})
+		return
+	}
+
+	author := ""
+	if user, ok := c.Get("user"); ok {
+		author = user.(*auth.User).Username
+	}
+
+	if err := costguard.GetStore().Save(&settings, author); err != nil {
+		h.log.Errorf("查询代价限制", "保存设置失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "保存设置失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("查询代价限制", "处理方式: %s, 代价上限: %.0f, 行数上限: %d, 全表扫描上限: %d",
+		settings.Action, settings.MaxCost, settings.MaxRows, settings.FullScanRows)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: settings,
+	})
+}
+
 // GetSystemInfo 获取系统信息
 func (h *AdminHandler) GetSystemInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, models.Response{
 		Code: models.CodeSuccess,
 		Data: gin.H{
-			"service": "mysql-sync-plugin",
-			"version": "1.0.0",
+			"service": "mysql-sync-plugin",
+			"version": "1.0.0",
+		},
+	})
+}
+
+// GetSpools 获取查询结果缓存列表及命中统计
+func (h *AdminHandler) GetSpools(c *gin.Context) {
+	store := spool.GetStore()
+	list, err := store.List()
+	if err != nil {
+		h.log.Errorf("查询缓存", "查询缓存失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询缓存失败: " + err.Error(),
+		})
+		return
+	}
+
+	stats, err := store.GetStats()
+	if err != nil {
+		h.log.Errorf("查询缓存", "查询缓存统计失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询缓存统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"list":  list,
+			"stats": stats,
+		},
+	})
+}
+
+// DeleteSpool 删除查询结果缓存
+func (h *AdminHandler) DeleteSpool(c *gin.Context) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定缓存",
+		})
+		return
+	}
+
+	if err := spool.GetStore().Delete(req.ID); err != nil {
+		h.log.Errorf("删除缓存", "删除缓存失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "删除缓存失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("删除缓存", "删除了查询结果缓存 %d", req.ID)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+	})
+}
+
+// CleanSpools 清理已过期的查询结果缓存
+func (h *AdminHandler) CleanSpools(c *gin.Context) {
+	affected, err := spool.GetStore().CleanExpired(0)
+	if err != nil {
+		h.log.Errorf("清理缓存", "清理缓存失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "清理缓存失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("清理缓存", "清理了 %d 个过期的查询结果缓存", affected)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"affected": affected,
+		},
+	})
+}
+
+// GetWriteBackTargets 获取回写目标列表
+func (h *AdminHandler) GetWriteBackTargets(c *gin.Context) {
+	targets, err := writeback.GetStore().ListTargets()
+	if err != nil {
+		h.log.Errorf("查询回写目标", "查询失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询回写目标失败: " + err.Error(),
+		})
+		return
+	}
+
+	for i := range targets {
+		targets[i].Password = ""
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: targets,
+	})
+}
+
+// SaveWriteBackTarget 新建或更新回写目标，保存前使用可写账号连接源库校验列配置
+func (h *AdminHandler) SaveWriteBackTarget(c *gin.Context) {
+	var req struct {
+		writeback.Target
+		RotateSecret bool `json:"rotateSecret"` // 重新生成回调签名密钥
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+	t := req.Target
+
+	store := writeback.GetStore()
+	t.Secret = ""
+	if t.ID == 0 {
+		if t.Password == "" {
+			c.JSON(http.StatusOK, models.Response{
+				Code: models.CodeParamError,
+				Msg:  "请填写可写账号的密码",
+			})
+			return
+		}
+	} else {
+		old, err := store.GetTarget(t.ID)
+		if err != nil || old == nil {
+			c.JSON(http.StatusOK, models.Response{
+				Code: models.CodeParamError,
+				Msg:  "回写目标不存在",
+			})
+			return
+		}
+		if t.Password == "" {
+			t.Password = old.Password
+		}
+	}
+	if req.RotateSecret {
+		secret, err := writeback.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusOK, models.Response{
+				Code: models.CodeThirdPartyError,
+				Msg:  err.Error(),
+			})
+			return
+		}
+		t.Secret = secret
+	}
+
+	if err := service.NewMySQLService().ValidateWriteBackTarget(&t); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "回写目标无效: " + err.Error(),
+		})
+		return
+	}
+
+	if err := store.SaveTarget(&t); err != nil {
+		h.log.Errorf("保存回写目标", "保存失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "保存回写目标失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("保存回写目标", "保存了回写目标 %s (%s.%s，可回写列: %s)",
+		t.Name, t.Database, t.Table, strings.Join(t.WritableColumns, ", "))
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"id": t.ID,
+		},
+	})
+}
+
+// DeleteWriteBackTarget 删除回写目标，审计记录保留
+func (h *AdminHandler) DeleteWriteBackTarget(c *gin.Context) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定回写目标",
+		})
+		return
+	}
+
+	if err := writeback.GetStore().DeleteTarget(req.ID); err != nil {
+		h.log.Errorf("删除回写目标", "删除失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "删除回写目标失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("删除回写目标", "删除了回写目标 %d", req.ID)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+	})
+}
+
+// GetWriteBackAudit 分页查询回写审计记录
+func (h *AdminHandler) GetWriteBackAudit(c *gin.Context) {
+	var query writeback.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	list, total, err := writeback.GetStore().QueryAudit(&query)
+	if err != nil {
+		h.log.Errorf("查询回写审计", "查询失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询回写审计失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"list":     list,
+			"total":    total,
+			"page":     query.Page,
+			"pageSize": query.PageSize,
+		},
+	})
+}
+
+// schedulerJobView 定时推送任务及其运行状态
+type schedulerJobView struct {
+	scheduler.Job
+	Running   bool           `json:"running"`
+	LastRun   *scheduler.Run `json:"lastRun"`
+	NextRunAt *time.Time     `json:"nextRunAt"`
+}
+
+// GetSchedulerJobs 获取定时推送任务列表
+func (h *AdminHandler) GetSchedulerJobs(c *gin.Context) {
+	jobs, err := scheduler.GetStore().ListJobs()
+	if err != nil {
+		h.log.Errorf("查询定时推送任务", "查询失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询定时推送任务失败: " + err.Error(),
+		})
+		return
+	}
+	lastRuns, err := scheduler.GetStore().LastRuns()
+	if err != nil {
+		h.log.Errorf("查询定时推送任务", "查询运行记录失败: %v", err)
+	}
+
+	now := time.Now()
+	list := make([]schedulerJobView, len(jobs))
+	for i, job := range jobs {
+		job.Source = scheduler.MaskSourcePassword(job.Source)
+		job.Target.AppSecret = ""
+		view := schedulerJobView{
+			Job:     job,
+			Running: scheduler.GetManager().Running(job.ID),
+		}
+		if run, ok := lastRuns[job.ID]; ok {
+			view.LastRun = &run
+		}
+		if schedule, err := scheduler.ParseCron(job.Cron); err == nil && job.Enabled {
+			if next := schedule.Next(now); !next.IsZero() {
+				view.NextRunAt = &next
+			}
+		}
+		list[i] = view
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"list":      list,
+			"platforms": scheduler.Platforms(),
+		},
+	})
+}
+
+// SaveSchedulerJob 新建或更新定时推送任务
+func (h *AdminHandler) SaveSchedulerJob(c *gin.Context) {
+	var job scheduler.Job
+	if err := c.ShouldBindJSON(&job); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+	job.Name = strings.TrimSpace(job.Name)
+	job.Cron = strings.TrimSpace(job.Cron)
+	if job.Name == "" || job.Cron == "" || job.Source == "" {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请填写任务名称、定时表达式和取数配置",
+		})
+		return
+	}
+	if _, err := scheduler.ParseCron(job.Cron); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "定时表达式无效: " + err.Error(),
+		})
+		return
+	}
+
+	store := scheduler.GetStore()
+	if job.ID > 0 {
+		old, err := store.GetJob(job.ID)
+		if err != nil || old == nil {
+			c.JSON(http.StatusOK, models.Response{
+				Code: models.CodeParamError,
+				Msg:  "任务不存在",
+			})
+			return
+		}
+		if job.Target.AppSecret == "" && job.Target.Platform == old.Target.Platform && job.Target.AppID == old.Target.AppID {
+			job.Target.AppSecret = old.Target.AppSecret
+		}
+		source, err := scheduler.MergeSourcePassword(job.Source, old.Source)
+		if err != nil {
+			c.JSON(http.StatusOK, models.Response{
+				Code: models.CodeParamError,
+				Msg:  err.Error(),
+			})
+			return
+		}
+		job.Source = source
+	}
+
+	source, err := scheduler.PrepareSource(job.Source)
+	if err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	job.Source = source
+	if _, err := scheduler.NewClient(job.Target); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "推送目标无效: " + err.Error(),
+		})
+		return
+	}
+
+	if err := store.SaveJob(&job); err != nil {
+		h.log.Errorf("保存定时推送任务", "保存失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "保存定时推送任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("保存定时推送任务", "保存了定时推送任务 %s (%s，推送到 %s %s/%s)",
+		job.Name, job.Cron, job.Target.Platform, job.Target.BaseID, job.Target.TableID)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"id": job.ID,
+		},
+	})
+}
+
+// DeleteSchedulerJob 删除定时推送任务及其运行记录，目标表格中已推送的记录保留
+func (h *AdminHandler) DeleteSchedulerJob(c *gin.Context) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定任务",
+		})
+		return
+	}
+	if scheduler.GetManager().Running(req.ID) {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "任务正在运行，请稍后再删除",
+		})
+		return
+	}
+
+	if err := scheduler.GetStore().DeleteJob(req.ID); err != nil {
+		h.log.Errorf("删除定时推送任务", "删除失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "删除定时推送任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("删除定时推送任务", "删除了定时推送任务 %d", req.ID)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+	})
+}
+
+// RunSchedulerJob 立即运行定时推送任务
+func (h *AdminHandler) RunSchedulerJob(c *gin.Context) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请指定任务",
+		})
+		return
+	}
+
+	if err := scheduler.GetManager().RunNow(req.ID); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "运行任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.log.Infof("运行定时推送任务", "手动运行了定时推送任务 %d", req.ID)
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+	})
+}
+
+// GetSchedulerRuns 分页查询定时推送的运行记录
+func (h *AdminHandler) GetSchedulerRuns(c *gin.Context) {
+	var query scheduler.RunQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	list, total, err := scheduler.GetStore().QueryRuns(&query)
+	if err != nil {
+		h.log.Errorf("查询定时推送记录", "查询失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "查询运行记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"list":     list,
+			"total":    total,
+			"page":     query.Page,
+			"pageSize": query.PageSize,
 		},
 	})
 }
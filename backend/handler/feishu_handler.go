@@ -7,8 +7,6 @@ import (
 	"mysql-sync-plugin/models"
 	"mysql-sync-plugin/service"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -62,23 +60,74 @@ func (h *FeishuHandler) TableMeta(c *gin.Context) {
 		}
 	}
 
-	// 解析MySQL配置
-	var config models.MySQLConfig
-	if err := json.Unmarshal([]byte(feishuParams.DatasourceConfig), &config); err != nil {
+	// 解析数据源配置(兼容旧版单表配置)
+	sources, err := models.ParseFeishuDatasourceConfig(feishuParams.DatasourceConfig)
+	if err != nil {
 		h.log.LogWithRequest(logger.LevelError, "获取表结构", "数据源配置解析失败", err.Error(), ip, c.GetHeader("User-Agent"), 0)
 		c.JSON(http.StatusOK, models.FeishuResponse{
 			Code: models.FeishuCodeConfigError,
-			Msg:  models.NewFeishuErrorMsg("数据源配置格式错误", "Invalid datasource config format"),
+			Msg:  models.NewFeishuErrorMsg("数据源配置格式错误: "+err.Error(), "Invalid datasource config format: "+err.Error()),
 		})
 		return
 	}
 
+	// 多表数据源未指定 tableID 时返回全部表的结构
+	if feishuParams.TableID == "" && (len(sources) > 1 || sources[0].TableID != "") {
+		tables := make([]models.FeishuTableMetaResponse, 0, len(sources))
+		for i := range sources {
+			meta, err := h.tableMeta(c, &sources[i], &feishuContext, start)
+			if err != nil {
+				c.JSON(http.StatusOK, models.FeishuResponse{
+					Code: models.FeishuCodeSystemError,
+					Msg:  models.NewFeishuErrorMsg("获取表 "+sources[i].TableID+" 的结构失败: "+err.Error(), "Failed to get table meta of "+sources[i].TableID+": "+err.Error()),
+				})
+				return
+			}
+			tables = append(tables, *meta)
+		}
+		c.JSON(http.StatusOK, models.FeishuResponse{
+			Code: models.FeishuCodeSuccess,
+			Data: &models.FeishuTablesMetaResponse{Tables: tables},
+		})
+		return
+	}
+
+	source, err := models.FindFeishuTable(sources, feishuParams.TableID)
+	if err != nil {
+		h.log.LogWithRequest(logger.LevelError, "获取表结构", err.Error(), "", ip, c.GetHeader("User-Agent"), 0)
+		c.JSON(http.StatusOK, models.FeishuResponse{
+			Code: models.FeishuCodeConfigError,
+			Msg:  models.NewFeishuErrorMsg(err.Error(), "Table not found in datasource config: "+feishuParams.TableID),
+		})
+		return
+	}
+
+	feishuData, err := h.tableMeta(c, source, &feishuContext, start)
+	if err != nil {
+		c.JSON(http.StatusOK, models.FeishuResponse{
+			Code: models.FeishuCodeSystemError,
+			Msg:  models.NewFeishuErrorMsg("获取表结构失败: "+err.Error(), "Failed to get table meta: "+err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.FeishuResponse{
+		Code: models.FeishuCodeSuccess,
+		Data: feishuData,
+	})
+}
+
+// tableMeta 获取数据源中一张表的结构并转换为飞书格式
+func (h *FeishuHandler) tableMeta(c *gin.Context, source *models.FeishuTableSource, feishuContext *models.FeishuRequestContext, start time.Time) (*models.FeishuTableMetaResponse, error) {
+	ip := c.ClientIP()
+	config := source.Config
+
 	// 保存字段映射，稍后用于飞书格式转换
 	fieldMappings := config.FieldMappings
 
 	// 飞书要求 fieldID 只能包含英文数字下划线
-	// 因此不能让服务层应用别名到 ID，需要清空 fieldMappings
-	config.FieldMappings = nil
+	// 因此不能让服务层应用别名到 ID，只保留字段映射中的值映射
+	config.FieldMappings = withoutAliases(config.FieldMappings)
 	configWithoutMappings, _ := json.Marshal(config)
 
 	// 构建日志详情
@@ -86,9 +135,12 @@ func (h *FeishuHandler) TableMeta(c *gin.Context) {
 	if config.QueryMode == "sql" && config.CustomSQL != "" {
 		executedSQL = fmt.Sprintf("SELECT * FROM (%s) AS t LIMIT 1", config.CustomSQL)
 	} else {
-		executedSQL = fmt.Sprintf("SELECT COLUMN_NAME, DATA_TYPE, COLUMN_KEY, COLUMN_COMMENT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s'", config.Database, config.Table)
+		executedSQL = fmt.Sprintf("SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, COLUMN_KEY, COLUMN_COMMENT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s'", config.Database, config.Table)
 	}
 	detail := fmt.Sprintf("主机: %s:%d, 数据库: %s, 表: %s, 模式: %s\nSQL: %s", config.Host, config.Port, config.Database, config.Table, config.QueryMode, executedSQL)
+	if source.TableID != "" {
+		detail = fmt.Sprintf("tableID: %s, %s", source.TableID, detail)
+	}
 
 	h.log.InfoWithDetail("获取表结构", "开始获取表结构", detail)
 
@@ -109,22 +161,18 @@ func (h *FeishuHandler) TableMeta(c *gin.Context) {
 
 	if err != nil {
 		h.log.LogWithRequest(logger.LevelError, "获取表结构", "获取表结构失败: "+err.Error(), detail, ip, c.GetHeader("User-Agent"), duration)
-		c.JSON(http.StatusOK, models.FeishuResponse{
-			Code: models.FeishuCodeSystemError,
-			Msg:  models.NewFeishuErrorMsg("获取表结构失败: "+err.Error(), "Failed to get table meta: "+err.Error()),
-		})
-		return
+		return nil, err
 	}
 
 	// 转换为飞书格式，并应用字段映射到 fieldName
 	feishuData := models.ConvertToFeishuTableMetaWithMappings(data, fieldMappings)
+	feishuData.TableID = source.TableID
+	if source.TableName != "" {
+		feishuData.TableName = source.TableName
+	}
 
 	h.log.LogWithRequest(logger.LevelInfo, "获取表结构", fmt.Sprintf("成功获取 %d 个字段", len(feishuData.Fields)), detail, ip, c.GetHeader("User-Agent"), duration)
-
-	c.JSON(http.StatusOK, models.FeishuResponse{
-		Code: models.FeishuCodeSuccess,
-		Data: feishuData,
-	})
+	return feishuData, nil
 }
 
 // Records 获取表记录（飞书格式）
@@ -161,20 +209,30 @@ func (h *FeishuHandler) Records(c *gin.Context) {
 		}
 	}
 
-	// 解析MySQL配置
-	var config models.MySQLConfig
-	if err := json.Unmarshal([]byte(feishuParams.DatasourceConfig), &config); err != nil {
+	// 解析数据源配置(兼容旧版单表配置)，多表数据源按 tableID 选择表
+	sources, err := models.ParseFeishuDatasourceConfig(feishuParams.DatasourceConfig)
+	if err != nil {
 		h.log.LogWithRequest(logger.LevelError, "获取记录", "数据源配置解析失败", err.Error(), ip, c.GetHeader("User-Agent"), 0)
 		c.JSON(http.StatusOK, models.FeishuResponse{
 			Code: models.FeishuCodeConfigError,
-			Msg:  models.NewFeishuErrorMsg("数据源配置格式错误", "Invalid datasource config format"),
+			Msg:  models.NewFeishuErrorMsg("数据源配置格式错误: "+err.Error(), "Invalid datasource config format: "+err.Error()),
+		})
+		return
+	}
+	source, err := models.FindFeishuTable(sources, feishuParams.TableID)
+	if err != nil {
+		h.log.LogWithRequest(logger.LevelError, "获取记录", err.Error(), "", ip, c.GetHeader("User-Agent"), 0)
+		c.JSON(http.StatusOK, models.FeishuResponse{
+			Code: models.FeishuCodeConfigError,
+			Msg:  models.NewFeishuErrorMsg(err.Error(), "Table not found in datasource config: "+feishuParams.TableID),
 		})
 		return
 	}
+	config := source.Config
 
 	// 飞书要求 fieldID 只能包含英文数字下划线
-	// 因此不能让服务层应用别名到字段key，需要清空 fieldMappings
-	config.FieldMappings = nil
+	// 因此不能让服务层应用别名到字段key，只保留字段映射中的值映射
+	config.FieldMappings = withoutAliases(config.FieldMappings)
 	configWithoutMappings, _ := json.Marshal(config)
 
 	// 转换分页参数：飞书pageToken -> 钉钉nextToken
@@ -190,13 +248,7 @@ func (h *FeishuHandler) Records(c *gin.Context) {
 	}
 
 	// 解析分页参数用于构建SQL
-	offset := 0
-	if nextToken != "" {
-		parts := strings.Split(nextToken, ":")
-		if len(parts) == 2 {
-			offset, _ = strconv.Atoi(parts[1])
-		}
-	}
+	offset := service.ParsePageToken(nextToken).Offset
 
 	// 构建日志详情
 	var executedSQL string
@@ -207,6 +259,9 @@ func (h *FeishuHandler) Records(c *gin.Context) {
 	}
 	detail := fmt.Sprintf("主机: %s:%d, 数据库: %s, 表: %s, 模式: %s\nSQL: %s",
 		config.Host, config.Port, config.Database, config.Table, config.QueryMode, executedSQL)
+	if source.TableID != "" {
+		detail = fmt.Sprintf("tableID: %s, %s", source.TableID, detail)
+	}
 
 	h.log.InfoWithDetail("获取记录", "开始获取表记录", detail)
 
@@ -221,6 +276,8 @@ func (h *FeishuHandler) Records(c *gin.Context) {
 			UnionID: feishuContext.ScriptArgs.BaseOpenID,
 			CorpID:  feishuContext.TenantKey,
 		},
+		SyncID:    feishuSyncID(feishuParams.TransactionID, source.TableID),
+		TargetKey: feishuTargetKey(&feishuContext, source.TableID),
 	}
 
 	// 调用服务层
@@ -236,8 +293,13 @@ func (h *FeishuHandler) Records(c *gin.Context) {
 		return
 	}
 
-	// 转换为飞书格式
-	feishuData := models.ConvertToFeishuRecords(data)
+	// 转换为飞书格式，fieldID 由与表结构相同的字段列表分配
+	feishuData := models.ConvertToFeishuRecords(data, models.FeishuFieldIDs(data.Fields))
+
+	// 数值精度等转换告警写入同步日志
+	for _, warning := range data.Warnings {
+		h.log.LogWithRequest(logger.LevelWarn, "获取记录", warning, detail, ip, c.GetHeader("User-Agent"), duration)
+	}
 
 	h.log.LogWithRequest(logger.LevelInfo, "获取记录",
 		fmt.Sprintf("成功获取 %d 条记录, 还有更多: %v", len(feishuData.Records), feishuData.HasMore),
@@ -248,3 +310,34 @@ func (h *FeishuHandler) Records(c *gin.Context) {
 		Data: feishuData,
 	})
 }
+
+// feishuTargetKey 飞书同步目标标识：使用数据源实例ID，缺失时返回空(不能保存跨同步的状态)
+// 同步事务ID每次同步都会变化，不能作为标识；多表数据源中各表分别保存同步状态，追加 tableID 区分
+func feishuTargetKey(ctx *models.FeishuRequestContext, tableID string) string {
+	if ctx.BizInstanceID == "" {
+		return ""
+	}
+	if tableID != "" {
+		return fmt.Sprintf("feishu:%s:%s:%s", ctx.TenantKey, ctx.BizInstanceID, tableID)
+	}
+	return fmt.Sprintf("feishu:%s:%s", ctx.TenantKey, ctx.BizInstanceID)
+}
+
+// feishuSyncID 同步会话ID：多表数据源的各表在同一同步事务中分别跟踪重复记录和快照
+func feishuSyncID(transactionID, tableID string) string {
+	if transactionID == "" || tableID == "" {
+		return transactionID
+	}
+	return transactionID + ":" + tableID
+}
+
+// withoutAliases 去掉字段映射中的别名，只保留配置了值映射的项
+func withoutAliases(mappings []models.FieldMapping) []models.FieldMapping {
+	var result []models.FieldMapping
+	for _, m := range mappings {
+		if m.ValueMap != nil {
+			result = append(result, models.FieldMapping{MysqlField: m.MysqlField, AliasField: m.MysqlField, ValueMap: m.ValueMap})
+		}
+	}
+	return result
+}
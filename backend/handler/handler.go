@@ -7,8 +7,6 @@ import (
 	"mysql-sync-plugin/models"
 	"mysql-sync-plugin/service"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -52,7 +50,7 @@ func (h *Handler) SheetMeta(c *gin.Context) {
 	if config.QueryMode == "sql" && config.CustomSQL != "" {
 		executedSQL = fmt.Sprintf("SELECT * FROM (%s) AS t LIMIT 1", config.CustomSQL)
 	} else {
-		executedSQL = fmt.Sprintf("SELECT COLUMN_NAME, DATA_TYPE, COLUMN_KEY, COLUMN_COMMENT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s'", config.Database, config.Table)
+		executedSQL = fmt.Sprintf("SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, COLUMN_KEY, COLUMN_COMMENT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s'", config.Database, config.Table)
 	}
 	detail := fmt.Sprintf("主机: %s:%d, 数据库: %s, 表: %s, 模式: %s\nSQL: %s", config.Host, config.Port, config.Database, config.Table, config.QueryMode, executedSQL)
 
@@ -99,13 +97,7 @@ func (h *Handler) Records(c *gin.Context) {
 	json.Unmarshal([]byte(req.Params), &config)
 
 	// 解析分页参数用于构建SQL
-	offset := 0
-	if req.NextToken != "" {
-		parts := strings.Split(req.NextToken, ":")
-		if len(parts) == 2 {
-			offset, _ = strconv.Atoi(parts[1])
-		}
-	}
+	offset := service.ParsePageToken(req.NextToken).Offset
 	maxResults := req.MaxResults
 	if maxResults <= 0 {
 		maxResults = 300
@@ -136,6 +128,11 @@ func (h *Handler) Records(c *gin.Context) {
 		return
 	}
 
+	// 数值精度等转换告警写入同步日志
+	for _, warning := range data.Warnings {
+		h.log.LogWithRequest(logger.LevelWarn, "获取记录", warning, detail, ip, c.GetHeader("User-Agent"), duration)
+	}
+
 	h.log.LogWithRequest(logger.LevelInfo, "获取记录",
 		fmt.Sprintf("成功获取 %d 条记录, 总数: %d, 还有更多: %v", len(data.Records), data.Total, data.HasMore),
 		detail, ip, c.GetHeader("User-Agent"), duration)
@@ -238,7 +235,7 @@ func (h *Handler) GetFields(c *gin.Context) {
 
 	detail := fmt.Sprintf("主机: %s:%d, 数据库: %s, 表: %s", config.Host, config.Port, config.Database, config.Table)
 
-	fields, err := h.mysqlService.GetFields(&config)
+	preview, err := h.mysqlService.GetFields(&config)
 	duration := time.Since(start).Milliseconds()
 
 	if err != nil {
@@ -250,15 +247,15 @@ func (h *Handler) GetFields(c *gin.Context) {
 		return
 	}
 
-	h.log.LogWithRequest(logger.LevelInfo, "获取字段", fmt.Sprintf("成功获取 %d 个字段", len(fields)), detail, ip, c.GetHeader("User-Agent"), duration)
+	h.log.LogWithRequest(logger.LevelInfo, "获取字段", fmt.Sprintf("成功获取 %d 个字段, 预览 %d 行", len(preview.Fields), len(preview.Records)), detail, ip, c.GetHeader("User-Agent"), duration)
 
 	c.JSON(http.StatusOK, models.Response{
 		Code: models.CodeSuccess,
-		Data: fields,
+		Data: preview,
 	})
 }
 
-// PreviewSQL 预览SQL执行结果（获取字段列表）
+// PreviewSQL 预览SQL执行结果（获取字段列表及数据预览）
 func (h *Handler) PreviewSQL(c *gin.Context) {
 	start := time.Now()
 	ip := c.ClientIP()
@@ -273,7 +270,7 @@ func (h *Handler) PreviewSQL(c *gin.Context) {
 		return
 	}
 
-	if config.CustomSQL == "" {
+	if config.CustomSQL == "" && config.QueryMode != service.QueryModeSaved && config.QueryMode != service.QueryModeJoin {
 		h.log.LogWithRequest(logger.LevelWarn, "预览SQL", "SQL语句为空", "", ip, c.GetHeader("User-Agent"), 0)
 		c.JSON(http.StatusOK, models.Response{
 			Code: models.CodeParamError,
@@ -283,9 +280,19 @@ func (h *Handler) PreviewSQL(c *gin.Context) {
 	}
 
 	detail := fmt.Sprintf("主机: %s:%d, 数据库: %s, SQL: %s", config.Host, config.Port, config.Database, config.CustomSQL)
+	switch config.QueryMode {
+	case service.QueryModeSaved:
+		detail = fmt.Sprintf("已发布查询: %s, 版本: %d", config.SavedQuery, config.SavedQueryVersion)
+	case service.QueryModeJoin:
+		detail = fmt.Sprintf("主机: %s:%d, 数据库: %s, 多表关联", config.Host, config.Port, config.Database)
+		if config.Join != nil {
+			detail += fmt.Sprintf(", 主表: %s, 关联 %d 张表", config.Join.Base, len(config.Join.Joins))
+		}
+	}
 	h.log.InfoWithDetail("预览SQL", "开始执行SQL预览", detail)
 
-	fields, err := h.mysqlService.PreviewSQL(&config)
+	// 配置页面预览时没有平台上下文，corp_id/user_id 变量为空
+	preview, err := h.mysqlService.PreviewSQL(&config, models.Context{})
 	duration := time.Since(start).Milliseconds()
 
 	if err != nil {
@@ -297,11 +304,56 @@ func (h *Handler) PreviewSQL(c *gin.Context) {
 		return
 	}
 
-	h.log.LogWithRequest(logger.LevelInfo, "预览SQL", fmt.Sprintf("SQL预览成功, 返回 %d 个字段", len(fields)), detail, ip, c.GetHeader("User-Agent"), duration)
+	h.log.LogWithRequest(logger.LevelInfo, "预览SQL", fmt.Sprintf("SQL预览成功, 返回 %d 个字段, 预览 %d 行", len(preview.Fields), len(preview.Records)), detail, ip, c.GetHeader("User-Agent"), duration)
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: preview,
+	})
+}
+
+// GetJoinSchema 获取多表关联所需的列和外键(前端辅助接口)
+func (h *Handler) GetJoinSchema(c *gin.Context) {
+	var config models.MySQLConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	schema, err := h.mysqlService.GetJoinSchema(&config)
+	if err != nil {
+		h.log.Errorf("获取关联结构", "获取失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "获取关联结构失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: schema,
+	})
+}
+
+// GetSavedQueries 获取可选的已发布查询(前端辅助接口)
+func (h *Handler) GetSavedQueries(c *gin.Context) {
+	list, err := h.mysqlService.ListSavedQueries()
+	if err != nil {
+		h.log.Errorf("获取已发布查询", "获取失败: %v", err)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "获取已发布查询失败: " + err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, models.Response{
 		Code: models.CodeSuccess,
-		Data: fields,
+		Data: list,
 	})
 }
 
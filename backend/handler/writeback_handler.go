@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mysql-sync-plugin/logger"
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/service"
+	"mysql-sync-plugin/writeback"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCallbackBodySize 回调请求体大小上限
+const maxCallbackBodySize = 4 << 20
+
+// WriteBackHandler 回写回调处理器
+type WriteBackHandler struct {
+	mysqlService *service.MySQLService
+	log          *logger.Logger
+}
+
+// NewWriteBackHandler 创建回写回调处理器
+func NewWriteBackHandler() *WriteBackHandler {
+	return &WriteBackHandler{
+		mysqlService: service.NewMySQLService(),
+		log:          logger.New("writeback"),
+	}
+}
+
+// Callback 接收表格记录变更回调，按回写目标的配置写回MySQL
+// 回调需使用回写目标的密钥签名，见 writeback.Sign
+func (h *WriteBackHandler) Callback(c *gin.Context) {
+	start := time.Now()
+	ip := c.ClientIP()
+	ua := c.GetHeader("User-Agent")
+	name := c.Param("target")
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCallbackBodySize+1))
+	if err != nil || len(body) > maxCallbackBodySize {
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "读取请求体失败或请求体过大",
+		})
+		return
+	}
+
+	target, err := writeback.GetStore().FindTarget(name)
+	if err != nil {
+		h.log.LogWithRequest(logger.LevelError, "回写", "读取回写目标失败: "+err.Error(), name, ip, ua, 0)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "读取回写目标失败",
+		})
+		return
+	}
+	if target == nil || !target.Enabled {
+		h.log.LogWithRequest(logger.LevelWarn, "回写", "回写目标不存在或未启用", name, ip, ua, 0)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeConfigError,
+			Msg:  "回写目标不存在或未启用",
+		})
+		return
+	}
+
+	if ok, reason := writeback.VerifySignature(target.Secret, c.GetHeader(writeback.HeaderTimestamp),
+		c.GetHeader(writeback.HeaderNonce), c.GetHeader(writeback.HeaderSignature), body); !ok {
+		h.log.LogWithRequest(logger.LevelWarn, "回写", reason, name, ip, ua, 0)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeAuthFailed,
+			Msg:  reason,
+		})
+		return
+	}
+
+	// 签名有效后才登记随机串，同一随机串在有效期内只能使用一次
+	fresh, err := writeback.GetStore().UseNonce(target.ID, c.GetHeader(writeback.HeaderNonce))
+	if err != nil {
+		h.log.LogWithRequest(logger.LevelError, "回写", "登记随机串失败: "+err.Error(), name, ip, ua, 0)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "校验随机串失败",
+		})
+		return
+	}
+	if !fresh {
+		h.log.LogWithRequest(logger.LevelWarn, "回写", "重复的随机串，疑似重放的回调", name, ip, ua, 0)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeAuthFailed,
+			Msg:  "随机串已使用，请勿重放回调",
+		})
+		return
+	}
+
+	var cb writeback.Callback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		h.log.LogWithRequest(logger.LevelError, "回写", "参数解析失败", err.Error(), ip, ua, 0)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeParamError,
+			Msg:  "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	results, err := h.mysqlService.ApplyWriteBack(target, &cb, ip)
+	duration := time.Since(start).Milliseconds()
+	detail := fmt.Sprintf("目标: %s, 事件: %s, 操作人: %s, 记录数: %d", target.Name, cb.EventID, cb.Operator, len(cb.Records))
+	if err != nil {
+		h.log.LogWithRequest(logger.LevelError, "回写", "回写失败: "+err.Error(), detail, ip, ua, duration)
+		c.JSON(http.StatusOK, models.Response{
+			Code: models.CodeThirdPartyError,
+			Msg:  "回写失败: " + err.Error(),
+		})
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	level := logger.LevelInfo
+	if counts[writeback.StatusApplied] < len(results) {
+		level = logger.LevelWarn
+	}
+	h.log.LogWithRequest(level, "回写", fmt.Sprintf("写入 %d 条，冲突 %d 条，拒绝 %d 条，未找到 %d 条，失败 %d 条",
+		counts[writeback.StatusApplied], counts[writeback.StatusConflict], counts[writeback.StatusRejected],
+		counts[writeback.StatusNotFound], counts[writeback.StatusFailed]), detail, ip, ua, duration)
+
+	c.JSON(http.StatusOK, models.Response{
+		Code: models.CodeSuccess,
+		Data: gin.H{
+			"results": results,
+		},
+	})
+}
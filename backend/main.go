@@ -3,9 +3,16 @@ package main
 import (
 	"log"
 	"mysql-sync-plugin/auth"
+	"mysql-sync-plugin/cdc"
 	"mysql-sync-plugin/config"
+	"mysql-sync-plugin/costguard"
 	"mysql-sync-plugin/handler"
 	"mysql-sync-plugin/logger"
+	"mysql-sync-plugin/querylib"
+	"mysql-sync-plugin/scheduler"
+	"mysql-sync-plugin/spool"
+	"mysql-sync-plugin/syncstate"
+	"mysql-sync-plugin/writeback"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,9 +33,63 @@ func main() {
 	}
 	defer auth.GetStore().Close()
 
+	// 初始化同步状态存储
+	if err := syncstate.GetStore().Init(cfg.DBPath); err != nil {
+		log.Fatalf("初始化同步状态数据库失败: %v", err)
+	}
+	defer syncstate.GetStore().Close()
+
+	// 初始化CDC存储
+	if err := cdc.GetStore().Init(cfg.DBPath); err != nil {
+		log.Fatalf("初始化CDC数据库失败: %v", err)
+	}
+	defer cdc.GetStore().Close()
+
+	// 初始化查询库存储
+	if err := querylib.GetStore().Init(cfg.DBPath); err != nil {
+		log.Fatalf("初始化查询库数据库失败: %v", err)
+	}
+	defer querylib.GetStore().Close()
+
+	// 初始化查询代价限制存储
+	if err := costguard.GetStore().Init(cfg.DBPath); err != nil {
+		log.Fatalf("初始化查询代价限制数据库失败: %v", err)
+	}
+	defer costguard.GetStore().Close()
+
+	// 初始化回写存储
+	if err := writeback.GetStore().Init(cfg.DBPath); err != nil {
+		log.Fatalf("初始化回写数据库失败: %v", err)
+	}
+	defer writeback.GetStore().Close()
+
+	// 初始化定时推送存储
+	if err := scheduler.GetStore().Init(cfg.DBPath); err != nil {
+		log.Fatalf("初始化定时推送数据库失败: %v", err)
+	}
+	defer scheduler.GetStore().Close()
+
+	// 初始化查询结果缓存存储
+	if err := spool.GetStore().Init(cfg.SpoolPath); err != nil {
+		log.Fatalf("初始化查询结果缓存数据库失败: %v", err)
+	}
+	defer spool.GetStore().Close()
+
 	mainLog := logger.New("main")
 	mainLog.Info("启动", "MySQL同步插件服务正在启动")
 
+	// 启动binlog订阅
+	if err := cdc.GetManager().Start(); err != nil {
+		mainLog.Errorf("启动", "启动CDC订阅失败: %v", err)
+	}
+	defer cdc.GetManager().StopAll()
+
+	// 启动定时推送调度
+	if err := scheduler.GetManager().Start(); err != nil {
+		mainLog.Errorf("启动", "启动定时推送失败: %v", err)
+	}
+	defer scheduler.GetManager().StopAll()
+
 	// 设置Gin模式
 	if !cfg.Debug {
 		gin.SetMode(gin.ReleaseMode)
@@ -56,6 +117,7 @@ func main() {
 	feishuH := handler.NewFeishuHandler()
 	adminH := handler.NewAdminHandler()
 	authH := handler.NewAuthHandler()
+	writebackH := handler.NewWriteBackHandler()
 
 	// ==================== 公共接口 ====================
 
@@ -84,6 +146,8 @@ func main() {
 			dingtalkAPI.POST("/tables", h.GetTables)
 			dingtalkAPI.POST("/fields", h.GetFields)
 			dingtalkAPI.POST("/preview_sql", h.PreviewSQL)
+			dingtalkAPI.POST("/join_schema", h.GetJoinSchema)
+			dingtalkAPI.POST("/saved_queries", h.GetSavedQueries)
 			// AI表格服务端调用的API
 			dingtalkAPI.POST("/sheet_meta", h.SheetMeta)
 			dingtalkAPI.POST("/records", h.Records)
@@ -109,12 +173,22 @@ func main() {
 			feishuAPI.POST("/tables", h.GetTables)
 			feishuAPI.POST("/fields", h.GetFields)
 			feishuAPI.POST("/preview_sql", h.PreviewSQL)
+			feishuAPI.POST("/join_schema", h.GetJoinSchema)
+			feishuAPI.POST("/saved_queries", h.GetSavedQueries)
 			// 多维表格服务端调用的API
 			feishuAPI.POST("/table_meta", feishuH.TableMeta)
 			feishuAPI.POST("/records", feishuH.Records)
 		}
 	}
 
+	// ==================== 回写路由组 ====================
+
+	// 表格记录变更回调(使用回写目标的密钥签名)
+	writebackAPI := r.Group("/writeback/api")
+	{
+		writebackAPI.POST("/callback/:target", writebackH.Callback)
+	}
+
 	// ==================== 管理后台路由组 ====================
 
 	// 管理后台认证API（无需登录）
@@ -140,6 +214,34 @@ func main() {
 		adminAPI.GET("/logs/stats", adminH.GetLogStats)
 		adminAPI.POST("/logs/clean", adminH.CleanLogs)
 		adminAPI.GET("/system/info", adminH.GetSystemInfo)
+		adminAPI.GET("/cost_guard", adminH.GetCostGuard)
+		adminAPI.POST("/cost_guard", adminH.SaveCostGuard)
+		adminAPI.GET("/watermarks", adminH.GetWatermarks)
+		adminAPI.POST("/watermarks/reset", adminH.ResetWatermark)
+		adminAPI.GET("/sync/reports", adminH.GetSyncReports)
+		adminAPI.GET("/spools", adminH.GetSpools)
+		adminAPI.POST("/spools/delete", adminH.DeleteSpool)
+		adminAPI.POST("/spools/clean", adminH.CleanSpools)
+		adminAPI.GET("/queries", adminH.GetSavedQueries)
+		adminAPI.POST("/queries", adminH.SaveSavedQuery)
+		adminAPI.GET("/queries/versions", adminH.GetSavedQueryVersions)
+		adminAPI.POST("/queries/delete", adminH.DeleteSavedQuery)
+		adminAPI.GET("/queries/profiles", adminH.GetQueryProfiles)
+		adminAPI.POST("/queries/profiles", adminH.SaveQueryProfile)
+		adminAPI.POST("/queries/profiles/delete", adminH.DeleteQueryProfile)
+		adminAPI.GET("/cdc/sources", adminH.GetCDCSources)
+		adminAPI.POST("/cdc/sources", adminH.SaveCDCSource)
+		adminAPI.POST("/cdc/sources/delete", adminH.DeleteCDCSource)
+		adminAPI.POST("/cdc/sources/restart", adminH.RestartCDCSource)
+		adminAPI.GET("/writeback/targets", adminH.GetWriteBackTargets)
+		adminAPI.POST("/writeback/targets", adminH.SaveWriteBackTarget)
+		adminAPI.POST("/writeback/targets/delete", adminH.DeleteWriteBackTarget)
+		adminAPI.GET("/writeback/audit", adminH.GetWriteBackAudit)
+		adminAPI.GET("/scheduler/jobs", adminH.GetSchedulerJobs)
+		adminAPI.POST("/scheduler/jobs", adminH.SaveSchedulerJob)
+		adminAPI.POST("/scheduler/jobs/delete", adminH.DeleteSchedulerJob)
+		adminAPI.POST("/scheduler/jobs/run", adminH.RunSchedulerJob)
+		adminAPI.GET("/scheduler/runs", adminH.GetSchedulerRuns)
 	}
 
 	// 管理后台静态文件服务
@@ -192,6 +294,7 @@ func main() {
 	mainLog.Info("启动", "管理后台: http://localhost:"+cfg.ServerPort+"/admin")
 	mainLog.Info("启动", "钉钉API: /dingtalk/api/*")
 	mainLog.Info("启动", "飞书API: /feishu/api/*")
+	mainLog.Info("启动", "回写回调: /writeback/api/callback/:target")
 	mainLog.Info("启动", "===============================")
 
 	if err := r.Run(addr); err != nil {
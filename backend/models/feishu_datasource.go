@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FeishuConfigVersionMultiTable 多表数据源配置的版本号
+//
+// 多表配置示例(连接信息写在顶层，各表的取数配置写在 tables 中，与顶层合并后使用):
+//
+//	{"version": 2, "host": "...", "port": 3306, "database": "shop", "username": "...", "password": "...",
+//	 "tables": [
+//	   {"tableID": "orders", "tableName": "订单", "table": "orders", "fieldMappings": [...]},
+//	   {"tableID": "top_customers", "queryMode": "sql", "customSQL": "SELECT ..."}
+//	 ]}
+//
+// 未设置 version 的配置为旧版单表配置，整个配置即一张表的 MySQLConfig。
+const FeishuConfigVersionMultiTable = 2
+
+// FeishuTableSource 数据源中的一张表
+type FeishuTableSource struct {
+	TableID   string      // 表标识，旧版单表配置为空
+	TableName string      // 表名称，为空时使用数据表名
+	Config    MySQLConfig // 合并顶层连接信息后的取数配置
+}
+
+// feishuTableHeader 多表配置中每张表的标识
+type feishuTableHeader struct {
+	TableID   string `json:"tableID"`
+	TableName string `json:"tableName,omitempty"`
+}
+
+// ParseFeishuDatasourceConfig 解析飞书数据源配置，旧版单表配置解析为一张 TableID 为空的表
+func ParseFeishuDatasourceConfig(raw string) ([]FeishuTableSource, error) {
+	var header struct {
+		Version int               `json:"version"`
+		Tables  []json.RawMessage `json:"tables"`
+	}
+	if err := json.Unmarshal([]byte(raw), &header); err != nil {
+		return nil, err
+	}
+
+	if header.Version == 0 {
+		var config MySQLConfig
+		if err := json.Unmarshal([]byte(raw), &config); err != nil {
+			return nil, err
+		}
+		return []FeishuTableSource{{Config: config}}, nil
+	}
+	if header.Version != FeishuConfigVersionMultiTable {
+		return nil, fmt.Errorf("不支持的数据源配置版本: %d", header.Version)
+	}
+	if len(header.Tables) == 0 {
+		return nil, fmt.Errorf("数据源配置中没有表")
+	}
+
+	sources := make([]FeishuTableSource, 0, len(header.Tables))
+	seen := make(map[string]bool)
+	for i, tableRaw := range header.Tables {
+		var t feishuTableHeader
+		if err := json.Unmarshal(tableRaw, &t); err != nil {
+			return nil, fmt.Errorf("第 %d 张表的配置格式错误: %w", i+1, err)
+		}
+		if t.TableID == "" || sanitizeFieldID(t.TableID) != t.TableID {
+			return nil, fmt.Errorf("第 %d 张表的 tableID 只能包含英文、数字、下划线", i+1)
+		}
+		if seen[t.TableID] {
+			return nil, fmt.Errorf("tableID %s 重复", t.TableID)
+		}
+		seen[t.TableID] = true
+
+		// 每张表重新解析顶层配置，再以表配置覆盖，避免各表共享切片和指针
+		var config MySQLConfig
+		if err := json.Unmarshal([]byte(raw), &config); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(tableRaw, &config); err != nil {
+			return nil, fmt.Errorf("表 %s 的配置格式错误: %w", t.TableID, err)
+		}
+		sources = append(sources, FeishuTableSource{TableID: t.TableID, TableName: t.TableName, Config: config})
+	}
+	return sources, nil
+}
+
+// FindFeishuTable 按 tableID 选择表；旧版单表配置忽略 tableID
+func FindFeishuTable(sources []FeishuTableSource, tableID string) (*FeishuTableSource, error) {
+	if len(sources) == 1 && sources[0].TableID == "" {
+		return &sources[0], nil
+	}
+	if tableID == "" {
+		return nil, fmt.Errorf("多表数据源需要指定 tableID")
+	}
+	for i := range sources {
+		if sources[i].TableID == tableID {
+			return &sources[i], nil
+		}
+	}
+	return nil, fmt.Errorf("数据源中没有 tableID 为 %s 的表", tableID)
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
 // 飞书多维表格数据源接口模型定义
 
 // 飞书字段类型枚举
@@ -70,6 +76,7 @@ type FeishuParams struct {
 	TransactionID    string `json:"transactionID"`    // 同步事务ID
 	PageToken        string `json:"pageToken"`        // 分页token
 	MaxPageSize      int    `json:"maxPageSize"`      // 最大页大小
+	TableID          string `json:"tableID"`          // 多表数据源中的表标识
 }
 
 // FeishuResponse 飞书通用响应结构
@@ -87,10 +94,16 @@ type FeishuErrorMsg struct {
 
 // FeishuTableMetaResponse 飞书表结构响应数据
 type FeishuTableMetaResponse struct {
+	TableID   string        `json:"tableID,omitempty"` // 多表数据源中的表标识
 	TableName string        `json:"tableName"`
 	Fields    []FeishuField `json:"fields"`
 }
 
+// FeishuTablesMetaResponse 多表数据源未指定 tableID 时返回全部表的结构
+type FeishuTablesMetaResponse struct {
+	Tables []FeishuTableMetaResponse `json:"tables"`
+}
+
 // FeishuField 飞书字段定义
 type FeishuField struct {
 	FieldID     string                 `json:"fieldID"`
@@ -182,9 +195,11 @@ func ConvertToFeishuTableMetaWithMappings(dingtalk *SheetMetaResponse, mappings
 	feishuFields := make([]FeishuField, len(dingtalk.Fields))
 	primaryFound := false // 飞书只允许一个主键字段
 
+	// 飞书要求 fieldID 只能包含英文、数字、下划线
+	fieldIDs := FeishuFieldIDs(dingtalk.Fields)
+
 	for i, f := range dingtalk.Fields {
-		// 飞书要求 fieldID 只能包含英文、数字、下划线
-		fieldID := sanitizeFieldID(f.ID)
+		fieldID := fieldIDs[f.ID]
 
 		// fieldName 使用别名（如果有的话）
 		fieldName := f.Name
@@ -223,6 +238,57 @@ func ConvertToFeishuTableMetaWithMappings(dingtalk *SheetMetaResponse, mappings
 	}
 }
 
+// 含非法字符的字段ID中保留的英文数字部分最大长度
+const maxFeishuFieldIDPrefix = 40
+
+// FeishuFieldIDs 为字段分配飞书 fieldID，返回 字段ID -> 飞书fieldID 的映射
+// 只含英文、数字、下划线的ID原样使用；其他ID保留合法字符部分并追加原ID的哈希后缀；
+// 分配只由字段ID的集合决定，与字段顺序无关。冲突的字段改用更长的后缀，因此新增字段可能改变
+// 与其冲突的已有字段的 fieldID；records 必须使用由表结构字段列表得到的映射，不能自行推导
+func FeishuFieldIDs(fields []Field) map[string]string {
+	ids := make(map[string]string, len(fields))
+	owners := make(map[string][]string, len(fields))
+	for _, f := range fields {
+		if _, ok := ids[f.ID]; ok {
+			continue
+		}
+		id := feishuFieldID(f.ID)
+		ids[f.ID] = id
+		owners[id] = append(owners[id], f.ID)
+	}
+	// 极少数情况下仍可能冲突，冲突的字段都改用更长的哈希后缀
+	for _, fieldIDs := range owners {
+		if len(fieldIDs) < 2 {
+			continue
+		}
+		for _, fieldID := range fieldIDs {
+			h := fnv.New64a()
+			h.Write([]byte(fieldID))
+			ids[fieldID] = fmt.Sprintf("%s_%016x", feishuFieldIDPrefix(fieldID), h.Sum64())
+		}
+	}
+	return ids
+}
+
+// feishuFieldID 将单个字段ID转换为飞书合法的 fieldID
+func feishuFieldID(id string) string {
+	if sanitizeFieldID(id) == id {
+		return id
+	}
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return fmt.Sprintf("%s_%08x", feishuFieldIDPrefix(id), h.Sum32())
+}
+
+// feishuFieldIDPrefix 字段ID中保留的合法字符部分，用作哈希后缀前的前缀
+func feishuFieldIDPrefix(id string) string {
+	sanitized := sanitizeFieldID(id)
+	if len(sanitized) > maxFeishuFieldIDPrefix {
+		sanitized = sanitized[:maxFeishuFieldIDPrefix]
+	}
+	return strings.TrimSuffix(sanitized, "_")
+}
+
 // sanitizeFieldID 清理字段ID，确保只包含英文、数字、下划线
 func sanitizeFieldID(id string) string {
 	var result []rune
@@ -257,19 +323,47 @@ func convertPropertyToFeishu(property map[string]interface{}, fieldType int) map
 	case FeishuFieldTypeDate:
 		// 飞书日期格式
 		if formatter, ok := property["formatter"].(string); ok {
-			result["formatter"] = formatter
+			result["formatter"] = convertDateFormatter(formatter)
 		} else {
 			result["formatter"] = "yyyy/MM/dd"
 		}
+	case FeishuFieldTypeSingleSel, FeishuFieldTypeMultiSel:
+		// 钉钉选项为 choices，飞书为 options
+		if options := convertChoicesToFeishu(property["choices"]); len(options) > 0 {
+			result["options"] = options
+		}
 	case FeishuFieldTypeCurrency:
 		if formatter, ok := property["formatter"].(string); ok {
-			result["formatter"] = formatter
+			result["formatter"] = convertNumberFormatter(formatter)
 		}
 		if code, ok := property["currencyCode"].(string); ok {
 			result["currencyCode"] = code
 		} else {
 			result["currencyCode"] = "CNY"
 		}
+	case FeishuFieldTypeProgress:
+		if formatter, ok := property["formatter"].(string); ok {
+			result["formatter"] = convertNumberFormatter(formatter)
+		} else {
+			result["formatter"] = "0%"
+		}
+		if min, ok := property["min"]; ok {
+			result["min"] = min
+			result["rangeCustomize"] = true
+		}
+		if max, ok := property["max"]; ok {
+			result["max"] = max
+			result["rangeCustomize"] = true
+		}
+	case FeishuFieldTypeRating:
+		result["formatter"] = "0"
+		if min, ok := property["min"]; ok {
+			result["min"] = min
+		}
+		if max, ok := property["max"]; ok {
+			result["max"] = max
+		}
+		result["rating"] = map[string]interface{}{"symbol": "star"}
 	default:
 		// 其他类型直接复制
 		for k, v := range property {
@@ -283,6 +377,28 @@ func convertPropertyToFeishu(property map[string]interface{}, fieldType int) map
 	return result
 }
 
+// convertChoicesToFeishu 转换选项列表为飞书 options 格式
+func convertChoicesToFeishu(choices interface{}) []map[string]interface{} {
+	var options []map[string]interface{}
+	switch list := choices.(type) {
+	case []map[string]interface{}:
+		for _, c := range list {
+			if name, ok := c["name"].(string); ok {
+				options = append(options, map[string]interface{}{"name": name})
+			}
+		}
+	case []interface{}:
+		for _, item := range list {
+			if c, ok := item.(map[string]interface{}); ok {
+				if name, ok := c["name"].(string); ok {
+					options = append(options, map[string]interface{}{"name": name})
+				}
+			}
+		}
+	}
+	return options
+}
+
 // convertNumberFormatter 转换数字格式化器
 func convertNumberFormatter(formatter string) string {
 	// 钉钉格式到飞书格式的映射
@@ -293,6 +409,22 @@ func convertNumberFormatter(formatter string) string {
 		return "0.00"
 	case "DECIMAL", "decimal":
 		return "0.00"
+	case "FLOAT_1":
+		return "0.0"
+	case "FLOAT_2":
+		return "0.00"
+	case "FLOAT_3":
+		return "0.000"
+	case "FLOAT_4":
+		return "0.0000"
+	case "THOUSAND":
+		return "#,##0"
+	case "THOUSAND_FLOAT":
+		return "#,##0.00"
+	case "PERCENT":
+		return "0%"
+	case "PERCENT_FLOAT":
+		return "0.00%"
 	default:
 		// 如果已经是飞书格式，直接返回
 		if formatter == "0" || formatter == "0.0" || formatter == "0.00" ||
@@ -305,19 +437,26 @@ func convertNumberFormatter(formatter string) string {
 	}
 }
 
+// convertDateFormatter 转换日期格式化器(钉钉 YYYY-MM-DD -> 飞书 yyyy-MM-dd)
+func convertDateFormatter(formatter string) string {
+	return strings.NewReplacer("YYYY", "yyyy", "DD", "dd").Replace(formatter)
+}
+
 // ConvertToFeishuRecords 将钉钉记录响应转换为飞书格式
-func ConvertToFeishuRecords(dingtalk *RecordsResponse) *FeishuRecordsResponse {
+// fieldIDs 为表结构字段列表的 fieldID 映射(见 FeishuFieldIDs)，不在表结构中的字段不输出
+func ConvertToFeishuRecords(dingtalk *RecordsResponse, fieldIDs map[string]string) *FeishuRecordsResponse {
 	feishuRecords := make([]FeishuRecord, len(dingtalk.Records))
 	for i, r := range dingtalk.Records {
-		// 清理记录中的字段key，确保与表结构中的fieldID一致
+		// 转换记录中的字段key，确保与表结构中的fieldID一致
 		sanitizedData := make(map[string]interface{})
 		for key, value := range r.Fields {
-			sanitizedKey := sanitizeFieldID(key)
-			sanitizedData[sanitizedKey] = value
+			if sanitizedKey, ok := fieldIDs[key]; ok {
+				sanitizedData[sanitizedKey] = value
+			}
 		}
 
 		feishuRecords[i] = FeishuRecord{
-			PrimaryID: sanitizeFieldID(r.ID), // primaryID也需要清理
+			PrimaryID: r.ID, // 记录ID已由服务端编码为合法字符
 			Data:      sanitizedData,
 		}
 	}
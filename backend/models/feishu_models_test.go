@@ -0,0 +1,91 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+)
+
+var feishuFieldIDPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+func TestFeishuFieldIDs(t *testing.T) {
+	// 合法ID与另一字段转换后的ID相同
+	taken := feishuFieldID("fid_订单-号")
+
+	tests := []struct {
+		name     string
+		ids      []string
+		verbatim []string // 原样使用的ID
+	}{
+		{"合法ID原样使用", []string{"fid_id", "fid_name"}, []string{"fid_id", "fid_name"}},
+		{"非法字符", []string{"fid_订单号", "fid_order no", "fid_金额(元)"}, nil},
+		{"清理后相同", []string{"fid_a-b", "fid_a b", "fid_a.b", "fid_ab"}, []string{"fid_ab"}},
+		{"只有非法字符", []string{"fid_名称", "fid_数量", "名称", "数量"}, nil},
+		{"与转换后的ID冲突", []string{"fid_订单-号", taken}, nil},
+		{"超长ID", []string{
+			"fid_very_long_column_name_that_exceeds_the_prefix_limit-1",
+			"fid_very_long_column_name_that_exceeds_the_prefix_limit-2",
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := make([]Field, len(tt.ids))
+			for i, id := range tt.ids {
+				fields[i] = Field{ID: id}
+			}
+			ids := FeishuFieldIDs(fields)
+
+			owner := make(map[string]string)
+			for _, id := range tt.ids {
+				fieldID := ids[id]
+				if !feishuFieldIDPattern.MatchString(fieldID) {
+					t.Errorf("%q -> %q 包含非法字符", id, fieldID)
+				}
+				if prev, ok := owner[fieldID]; ok {
+					t.Errorf("%q 和 %q 都分配到 %q", prev, id, fieldID)
+				}
+				owner[fieldID] = id
+			}
+			for _, id := range tt.verbatim {
+				if ids[id] != id {
+					t.Errorf("%q -> %q, want unchanged", id, ids[id])
+				}
+			}
+
+			// 分配与字段顺序无关
+			reversed := make([]Field, len(fields))
+			for i, f := range fields {
+				reversed[len(fields)-1-i] = f
+			}
+			for id, fieldID := range FeishuFieldIDs(reversed) {
+				if ids[id] != fieldID {
+					t.Errorf("倒序后 %q -> %q, want %q", id, fieldID, ids[id])
+				}
+			}
+		})
+	}
+}
+
+func TestConvertToFeishuRecordsFieldIDs(t *testing.T) {
+	fields := []Field{{ID: "fid_订单-号"}, {ID: feishuFieldID("fid_订单-号")}, {ID: "fid_id"}}
+	meta := ConvertToFeishuTableMeta(&SheetMetaResponse{Fields: fields})
+	records := ConvertToFeishuRecords(&RecordsResponse{
+		Fields: fields,
+		Records: []Record{{ID: "1", Fields: map[string]interface{}{
+			fields[0].ID: "a",
+			fields[1].ID: "b",
+			fields[2].ID: int64(1),
+			"fid_extra":  "x",
+		}}},
+	}, FeishuFieldIDs(fields))
+
+	data := records.Records[0].Data
+	if len(data) != len(meta.Fields) {
+		t.Errorf("记录包含 %d 个字段, want %d: %v", len(data), len(meta.Fields), data)
+	}
+	for _, f := range meta.Fields {
+		if _, ok := data[f.FieldID]; !ok {
+			t.Errorf("记录中缺少表结构字段 %q", f.FieldID)
+		}
+	}
+}
@@ -16,31 +16,174 @@ type RecordsRequest struct {
 	NextToken  string  `json:"nextToken"`
 	Params     string  `json:"params"` // JSON字符串
 	Context    Context `json:"context"`
+	SyncID     string  `json:"-"` // 同步会话ID(飞书使用 transactionID)，为空时由服务端生成
+	TargetKey  string  `json:"-"` // 同步目标标识，用于保存增量同步水位，为空时由服务端生成
 }
 
 // Context 请求上下文
 type Context struct {
 	UnionID string `json:"unionId"`
 	CorpID  string `json:"corpId"`
+	BaseID  string `json:"baseId,omitempty"`  // AI表格ID
+	SheetID string `json:"sheetId,omitempty"` // 数据表ID
 }
 
 // FieldMapping 字段映射配置
 type FieldMapping struct {
-	MysqlField string `json:"mysqlField"` // MySQL原始字段名
-	AliasField string `json:"aliasField"` // AI表格显示的别名
+	MysqlField string        `json:"mysqlField"`         // MySQL原始字段名
+	AliasField string        `json:"aliasField"`         // AI表格显示的别名
+	ValueMap   *ValueMapping `json:"valueMap,omitempty"` // 值映射，将代码替换为显示值并作为单选字段输出
+}
+
+// ValueMapping 值映射(代码 -> 显示值)，映射来源为内联配置或同库的查找表
+type ValueMapping struct {
+	Values       []ValueLabel `json:"values,omitempty"`       // 内联映射，按顺序作为单选选项
+	Table        string       `json:"table,omitempty"`        // 查找表，设置后从该表读取映射
+	KeyColumn    string       `json:"keyColumn,omitempty"`    // 查找表的代码列
+	LabelColumn  string       `json:"labelColumn,omitempty"`  // 查找表的显示值列
+	CacheTTL     int          `json:"cacheTTL,omitempty"`     // 查找表缓存时间(秒)，默认 300
+	DropUnmapped bool         `json:"dropUnmapped,omitempty"` // 未映射的值写入空值，默认保留原值
+}
+
+// ValueLabel 单个代码与显示值
+type ValueLabel struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// FieldOverride 字段类型覆盖及格式配置
+type FieldOverride struct {
+	MysqlField   string   `json:"mysqlField"`             // MySQL原始字段名
+	Type         string   `json:"type"`                   // 目标类型: text/number/date(dateTime)/checkbox/currency/hyperlink/phone/progress/rating
+	Formatter    string   `json:"formatter,omitempty"`    // 数字格式: INT/FLOAT_1~FLOAT_4/THOUSAND/THOUSAND_FLOAT/PERCENT/PERCENT_FLOAT
+	CurrencyCode string   `json:"currencyCode,omitempty"` // 货币代码，如 CNY、USD
+	DateFormat   string   `json:"dateFormat,omitempty"`   // 日期格式，如 YYYY-MM-DD、YYYY-MM-DD HH:mm
+	Divisor      float64  `json:"divisor,omitempty"`      // 数值除数，如金额以分存储时填 100
+	Min          *float64 `json:"min,omitempty"`          // 进度/评分最小值
+	Max          *float64 `json:"max,omitempty"`          // 进度/评分最大值
+}
+
+// ComputedField 计算字段，由表达式基于同一行转换后的字段值计算得出
+type ComputedField struct {
+	Name         string   `json:"name"`                   // 字段名，不能与源列重名
+	Type         string   `json:"type,omitempty"`         // 字段类型，可选值同 FieldOverride.Type，默认 text
+	Expression   string   `json:"expression"`             // 表达式，如 first_name & " " & last_name
+	Formatter    string   `json:"formatter,omitempty"`    // 数字格式
+	CurrencyCode string   `json:"currencyCode,omitempty"` // 货币代码
+	DateFormat   string   `json:"dateFormat,omitempty"`   // 日期格式
+	Min          *float64 `json:"min,omitempty"`          // 进度/评分最小值
+	Max          *float64 `json:"max,omitempty"`          // 进度/评分最大值
+}
+
+// FilterNode 筛选条件树节点：Logic 非空时为条件组，否则为单个条件
+type FilterNode struct {
+	Logic      string       `json:"logic,omitempty"`      // 条件组逻辑: and/or
+	Conditions []FilterNode `json:"conditions,omitempty"` // 条件组的子条件
+	Column     string       `json:"column,omitempty"`     // 条件列
+	Operator   string       `json:"operator,omitempty"`   // 运算符: eq/ne/gt/gte/lt/lte/in/notIn/between/isNull/isNotNull/like/notLike/lastDays/lastHours/today/thisMonth
+	Value      interface{}  `json:"value,omitempty"`      // 条件值；in/notIn/between 为数组，lastDays/lastHours 为数量
+}
+
+// SortField 排序配置
+type SortField struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc,omitempty"`
+}
+
+// SQLParam 自定义SQL的模板参数
+type SQLParam struct {
+	Name    string `json:"name"`           // 参数名，SQL中以 :name 或 {{name}} 引用
+	Type    string `json:"type,omitempty"` // 参数类型: string(默认)/number/date/datetime
+	Default string `json:"default"`        // 参数值，可使用内置表达式，如 {{today - 7d}}
+}
+
+// JoinConfig 多表关联取数配置
+type JoinConfig struct {
+	Base    string       `json:"base"`    // 主表
+	Joins   []JoinTable  `json:"joins"`   // 依次关联的表
+	Columns []JoinColumn `json:"columns"` // 选取的列
+}
+
+// JoinTable 关联的表
+type JoinTable struct {
+	Table string    `json:"table"`
+	Alias string    `json:"alias,omitempty"` // 表别名，同一张表关联多次时必填，默认为表名
+	Type  string    `json:"type"`            // 关联方式: inner/left
+	On    []JoinKey `json:"on"`              // 关联键，多个键之间为 AND
+}
+
+// JoinKey 关联键：已关联表的列 = 本表的列
+type JoinKey struct {
+	LeftTable   string `json:"leftTable"`   // 主表或前面已关联的表(别名)
+	LeftColumn  string `json:"leftColumn"`  // 已关联表的列
+	RightColumn string `json:"rightColumn"` // 本表的列
+}
+
+// JoinColumn 选取的列
+type JoinColumn struct {
+	Table  string `json:"table"`           // 表名或别名
+	Column string `json:"column"`          // 列名
+	Alias  string `json:"alias,omitempty"` // 结果列名，默认为列名，与其他列重名时为 表名_列名
+}
+
+// ForeignKey 外键关系，用于推荐关联键
+type ForeignKey struct {
+	Name       string   `json:"name"`       // 约束名
+	Table      string   `json:"table"`      // 引用方表
+	Columns    []string `json:"columns"`    // 引用方列
+	RefTable   string   `json:"refTable"`   // 被引用表
+	RefColumns []string `json:"refColumns"` // 被引用列
+}
+
+// JoinSchema 关联配置所需的表结构
+type JoinSchema struct {
+	Columns     map[string][]string `json:"columns"`     // 表名 -> 列名(按列顺序)
+	ForeignKeys []ForeignKey        `json:"foreignKeys"` // 涉及这些表的外键
 }
 
 // MySQLConfig MySQL连接配置(从params中解析)
 type MySQLConfig struct {
-	Host          string         `json:"host"`
-	Port          int            `json:"port"`
-	Database      string         `json:"database"`
-	Username      string         `json:"username"`
-	Password      string         `json:"password"`
-	Table         string         `json:"table,omitempty"`
-	QueryMode     string         `json:"queryMode,omitempty"`     // 取数模式: "table" 或 "sql"
-	CustomSQL     string         `json:"customSQL,omitempty"`     // 自定义SQL语句
-	FieldMappings []FieldMapping `json:"fieldMappings,omitempty"` // 字段映射配置
+	Host           string          `json:"host"`
+	Port           int             `json:"port"`
+	Database       string          `json:"database"`
+	Username       string          `json:"username"`
+	Password       string          `json:"password"`
+	TimeZone       string          `json:"timeZone,omitempty"` // 源库时区(IANA名称如 Asia/Shanghai，或 +08:00)，同时作为会话时区；默认沿用源库会话时区并视为服务器本地时区
+	Table          string          `json:"table,omitempty"`
+	QueryMode      string          `json:"queryMode,omitempty"`      // 取数模式: "table"、"sql"、"saved"(已发布查询) 或 "join"(多表关联)
+	CustomSQL      string          `json:"customSQL,omitempty"`      // 自定义SQL语句，可引用模板参数
+	SQLParams      []SQLParam      `json:"sqlParams,omitempty"`      // 自定义SQL的模板参数
+	Join           *JoinConfig     `json:"join,omitempty"`           // 多表关联配置
+	FieldMappings  []FieldMapping  `json:"fieldMappings,omitempty"`  // 字段映射配置
+	FieldOverrides []FieldOverride `json:"fieldOverrides,omitempty"` // 字段类型覆盖配置
+	ComputedFields []ComputedField `json:"computedFields,omitempty"` // 计算字段，按顺序计算，可引用前面的计算字段
+	PrimaryKeys    []string        `json:"primaryKeys,omitempty"`    // 记录ID使用的键列，默认使用表主键(自定义SQL模式使用第一列)
+	Filter         *FilterNode     `json:"filter,omitempty"`         // 筛选条件
+	Sort           []SortField     `json:"sort,omitempty"`           // 排序
+
+	// 已发布查询配置(连接信息和SQL由服务端按名称读取，用户只能为声明的参数赋值)
+	SavedQuery        string            `json:"savedQuery,omitempty"`        // 已发布查询名称
+	SavedQueryVersion int               `json:"savedQueryVersion,omitempty"` // 使用的版本，为空时使用最新版本
+	ParamValues       map[string]string `json:"paramValues,omitempty"`       // 参数值，覆盖查询声明的默认值
+
+	// 值转换配置
+	UnsafeNumberAsString bool   `json:"unsafeNumberAsString,omitempty"` // 可能超出安全精度(2^53)的 BIGINT/DECIMAL 列按文本输出
+	BinaryMode           string `json:"binaryMode,omitempty"`           // 二进制列处理方式: skip/hex/base64/size/mime，默认 size
+	MaxTextLength        int    `json:"maxTextLength,omitempty"`        // 单元格文本最大字符数，超出截断，默认 10000
+
+	// 增量同步配置
+	SyncMode        string `json:"syncMode,omitempty"`        // 同步模式: "full"(默认)、"incremental"(水位列) 或 "cdc"(binlog变更日志)
+	WatermarkColumn string `json:"watermarkColumn,omitempty"` // 增量同步的水位列，需单调递增(如 updated_at 或自增ID)
+
+	// 读取配置
+	Snapshot  bool   `json:"snapshot,omitempty"`  // 快照模式：同一次同步的所有分页读取同一一致性快照(需InnoDB表)
+	Spool     bool   `json:"spool,omitempty"`     // 查询结果缓存：自定义SQL每次同步只执行一次，结果写入本地缓存后分页读取
+	SpoolTTL  int    `json:"spoolTTL,omitempty"`  // 查询结果缓存有效期(分钟)，有效期内的同步共享缓存，默认 30
+	CountMode string `json:"countMode,omitempty"` // 总数统计方式: "exact"(默认，精确COUNT)、"estimate"(估算) 或 "none"(不统计)，每次同步只统计一次
+
+	// 删除行与内容变化检测配置
+	DeleteMode  string `json:"deleteMode,omitempty"`  // 删除行处理方式: 为空不检测，"report"(同步报告)、"checkbox"(_deleted 复选框) 或 "status"(_status 状态列)
+	ChangedOnly bool   `json:"changedOnly,omitempty"` // 只输出新增和内容变化的记录(按记录内容摘要与上次同步比较)
 }
 
 // Response 通用响应结构
@@ -56,6 +199,48 @@ type SheetMetaResponse struct {
 	Fields    []Field `json:"fields"`
 }
 
+// SavedQueryInfo 配置页面可选的已发布查询(不含SQL和连接信息)
+type SavedQueryInfo struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Owner       string     `json:"owner"`
+	Version     int        `json:"version"`
+	Params      []SQLParam `json:"params"`
+}
+
+// PreviewResponse 字段与数据预览响应数据
+type PreviewResponse struct {
+	Fields         []Field         `json:"fields"`
+	Records        []Record        `json:"records"`               // 前若干行，已按同步时的方式转换并应用字段映射
+	EstimatedTotal int             `json:"estimatedTotal"`        // 估算的记录总数
+	ElapsedMs      int64           `json:"elapsedMs"`             // 预览查询的执行耗时(毫秒)
+	ResolvedSQL    string          `json:"resolvedSQL,omitempty"` // 模板变量替换为 ? 占位符后的SQL(自定义SQL)
+	Params         []BoundSQLParam `json:"params,omitempty"`      // 按占位符顺序绑定的参数值
+	Explain        *ExplainSummary `json:"explain,omitempty"`     // 执行计划摘要(自定义SQL或有筛选条件时)
+}
+
+// ExplainSummary 查询执行计划摘要
+type ExplainSummary struct {
+	QueryCost     float64        `json:"queryCost"`          // 优化器估算的查询代价
+	EstimatedRows int64          `json:"estimatedRows"`      // 估算检查的行数(连接按前序表输出行数累乘)
+	Tables        []ExplainTable `json:"tables"`             // 各表的访问方式
+	Warnings      []string       `json:"warnings,omitempty"` // 超出查询代价限制的项
+}
+
+// ExplainTable 执行计划中单个表的访问方式
+type ExplainTable struct {
+	Table      string `json:"table"`
+	AccessType string `json:"accessType"`    // ALL 为全表扫描
+	Key        string `json:"key,omitempty"` // 使用的索引
+	Rows       int64  `json:"rows"`          // 每次扫描检查的行数
+}
+
+// BoundSQLParam 绑定到占位符的模板变量
+type BoundSQLParam struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
 // Field 字段定义
 type Field struct {
 	ID          string                 `json:"id"`
@@ -64,6 +249,16 @@ type Field struct {
 	IsPrimary   bool                   `json:"isPrimary"`
 	Property    map[string]interface{} `json:"property,omitempty"`
 	Description string                 `json:"description,omitempty"`
+
+	FeishuFieldID string   `json:"feishuFieldId,omitempty"` // 飞书 fieldID，仅在字段预览接口中返回
+	Warnings      []string `json:"warnings,omitempty"`      // 预览数据的告警(转换失败、空值比例等)，仅在字段预览接口中返回
+
+	// 以下为服务端内部使用的源列信息，不输出到JSON
+	Column     string  `json:"-"` // MySQL源列名
+	SourceType string  `json:"-"` // MySQL源列类型(COLUMN_TYPE，如 tinyint(1)、enum('a','b'))
+	Precision  int     `json:"-"` // 数值精度，-1表示未知
+	Scale      int     `json:"-"` // 数值小数位数，-1表示未知
+	Divisor    float64 `json:"-"` // 数值除数(来自字段类型覆盖配置)
 }
 
 // RecordsResponse 表记录响应数据
@@ -72,6 +267,9 @@ type RecordsResponse struct {
 	HasMore   bool     `json:"hasMore"`
 	Records   []Record `json:"records"`
 	Total     int      `json:"total,omitempty"`
+
+	Fields   []Field  `json:"-"` // 记录对应的字段定义，用于转换飞书 fieldID
+	Warnings []string `json:"-"` // 转换告警(如数值精度溢出)，仅写入同步日志
 }
 
 // Record 单条记录
@@ -0,0 +1,44 @@
+package querylib
+
+import (
+	"mysql-sync-plugin/models"
+	"time"
+)
+
+// Profile 连接配置：管理员维护的源库连接，已发布查询通过它连接源库
+type Profile struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password,omitempty"` // 列表接口中不返回
+	Database  string    `json:"database"`
+	TimeZone  string    `json:"timeZone,omitempty"` // 源库时区(IANA名称或 +08:00)，同时作为会话时区
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Query 已发布查询，SQL 和参数为当前版本的内容
+type Query struct {
+	ID          int64             `json:"id"`
+	Name        string            `json:"name"` // 唯一名称，用户配置中按名称引用
+	Description string            `json:"description"`
+	Owner       string            `json:"owner"`
+	ProfileID   int64             `json:"profileId"`
+	Version     int               `json:"version"` // 当前版本号，SQL或参数变化时递增
+	SQL         string            `json:"sql"`
+	Params      []models.SQLParam `json:"params"` // 声明的参数及默认值
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+// Version 已发布查询的一个版本
+type Version struct {
+	QueryID   int64             `json:"queryId"`
+	Version   int               `json:"version"`
+	SQL       string            `json:"sql"`
+	Params    []models.SQLParam `json:"params"`
+	CreatedBy string            `json:"createdBy"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
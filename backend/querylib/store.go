@@ -0,0 +1,395 @@
+package querylib
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store 查询库存储(连接配置、已发布查询及其版本)
+type Store struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// GetStore 获取查询库存储单例
+func GetStore() *Store {
+	once.Do(func() {
+		instance = &Store{}
+	})
+	return instance
+}
+
+// Init 初始化数据库
+func (s *Store) Init(dbPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	// 创建连接配置、已发布查询和版本表
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS query_profiles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		host TEXT NOT NULL,
+		port INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		password TEXT NOT NULL,
+		database_name TEXT NOT NULL,
+		time_zone TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS saved_queries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		owner TEXT NOT NULL DEFAULT '',
+		profile_id INTEGER NOT NULL,
+		version INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS saved_query_versions (
+		query_id INTEGER NOT NULL,
+		version INTEGER NOT NULL,
+		sql_text TEXT NOT NULL,
+		params TEXT NOT NULL,
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (query_id, version)
+	);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		db.Close()
+		return fmt.Errorf("创建表失败: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+const profileColumns = "id, name, host, port, username, password, database_name, time_zone, created_at, updated_at"
+
+// scanProfile 扫描连接配置
+func scanProfile(scanner interface{ Scan(...interface{}) error }) (*Profile, error) {
+	var p Profile
+	if err := scanner.Scan(&p.ID, &p.Name, &p.Host, &p.Port, &p.Username, &p.Password,
+		&p.Database, &p.TimeZone, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListProfiles 获取所有连接配置
+func (s *Store) ListProfiles() ([]Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query("SELECT " + profileColumns + " FROM query_profiles ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Profile
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	return list, rows.Err()
+}
+
+// GetProfile 根据ID获取连接配置
+func (s *Store) GetProfile(id int64) (*Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := scanProfile(s.db.QueryRow("SELECT "+profileColumns+" FROM query_profiles WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// SaveProfile 保存连接配置(ID为0时新建)，密码为空时保留原密码
+func (s *Store) SaveProfile(p *Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.ID == 0 {
+		result, err := s.db.Exec(
+			"INSERT INTO query_profiles (name, host, port, username, password, database_name, time_zone) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			p.Name, p.Host, p.Port, p.Username, p.Password, p.Database, p.TimeZone,
+		)
+		if err != nil {
+			return err
+		}
+		p.ID, err = result.LastInsertId()
+		return err
+	}
+
+	query := "UPDATE query_profiles SET name = ?, host = ?, port = ?, username = ?, database_name = ?, time_zone = ?, updated_at = ?"
+	args := []interface{}{p.Name, p.Host, p.Port, p.Username, p.Database, p.TimeZone, time.Now()}
+	if p.Password != "" {
+		query += ", password = ?"
+		args = append(args, p.Password)
+	}
+	query += " WHERE id = ?"
+	args = append(args, p.ID)
+
+	_, err := s.db.Exec(query, args...)
+	return err
+}
+
+// DeleteProfile 删除连接配置，仍被已发布查询使用时拒绝删除
+func (s *Store) DeleteProfile(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var used int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM saved_queries WHERE profile_id = ?", id).Scan(&used); err != nil {
+		return err
+	}
+	if used > 0 {
+		return fmt.Errorf("连接配置正在被 %d 个已发布查询使用", used)
+	}
+
+	_, err := s.db.Exec("DELETE FROM query_profiles WHERE id = ?", id)
+	return err
+}
+
+const queryColumns = `q.id, q.name, q.description, q.owner, q.profile_id, q.version, v.sql_text, v.params, q.created_at, q.updated_at
+	FROM saved_queries q JOIN saved_query_versions v ON v.query_id = q.id AND v.version = q.version`
+
+// scanQuery 扫描已发布查询
+func scanQuery(scanner interface{ Scan(...interface{}) error }) (*Query, error) {
+	var q Query
+	var params string
+	if err := scanner.Scan(&q.ID, &q.Name, &q.Description, &q.Owner, &q.ProfileID, &q.Version,
+		&q.SQL, &params, &q.CreatedAt, &q.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(params), &q.Params)
+	return &q, nil
+}
+
+// ListQueries 获取所有已发布查询(当前版本)
+func (s *Store) ListQueries() ([]Query, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query("SELECT " + queryColumns + " ORDER BY q.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Query
+	for rows.Next() {
+		q, err := scanQuery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *q)
+	}
+	return list, rows.Err()
+}
+
+// GetQuery 根据ID获取已发布查询
+func (s *Store) GetQuery(id int64) (*Query, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q, err := scanQuery(s.db.QueryRow("SELECT "+queryColumns+" WHERE q.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// FindQuery 根据名称获取已发布查询
+func (s *Store) FindQuery(name string) (*Query, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q, err := scanQuery(s.db.QueryRow("SELECT "+queryColumns+" WHERE q.name = ?", name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// SaveQuery 保存已发布查询(ID为0时新建)；SQL或参数变化时生成新版本，仅修改描述等信息时版本不变
+func (s *Store) SaveQuery(q *Query, author string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	params, err := json.Marshal(q.Params)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if q.ID == 0 {
+		result, err := tx.Exec(
+			"INSERT INTO saved_queries (name, description, owner, profile_id, version, created_at, updated_at) VALUES (?, ?, ?, ?, 1, ?, ?)",
+			q.Name, q.Description, q.Owner, q.ProfileID, now, now,
+		)
+		if err != nil {
+			return uniqueNameError(err, q.Name)
+		}
+		if q.ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+		q.Version = 1
+	} else {
+		var current int
+		var currentSQL, currentParams string
+		err := tx.QueryRow(
+			"SELECT q.version, v.sql_text, v.params FROM saved_queries q JOIN saved_query_versions v ON v.query_id = q.id AND v.version = q.version WHERE q.id = ?",
+			q.ID,
+		).Scan(&current, &currentSQL, &currentParams)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("已发布查询不存在")
+		}
+		if err != nil {
+			return err
+		}
+
+		q.Version = current
+		if currentSQL != q.SQL || currentParams != string(params) {
+			q.Version = current + 1
+		}
+		if _, err := tx.Exec(
+			"UPDATE saved_queries SET name = ?, description = ?, owner = ?, profile_id = ?, version = ?, updated_at = ? WHERE id = ?",
+			q.Name, q.Description, q.Owner, q.ProfileID, q.Version, now, q.ID,
+		); err != nil {
+			return uniqueNameError(err, q.Name)
+		}
+		if q.Version == current {
+			return tx.Commit()
+		}
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO saved_query_versions (query_id, version, sql_text, params, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		q.ID, q.Version, q.SQL, string(params), author, now,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// uniqueNameError 将名称唯一约束冲突转换为可读的错误
+func uniqueNameError(err error, name string) error {
+	if strings.Contains(err.Error(), "UNIQUE") {
+		return fmt.Errorf("查询名称 %s 已存在", name)
+	}
+	return err
+}
+
+// GetVersion 获取已发布查询的指定版本，不存在时返回nil
+func (s *Store) GetVersion(queryID int64, version int) (*Version, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var v Version
+	var params string
+	err := s.db.QueryRow(
+		"SELECT query_id, version, sql_text, params, created_by, created_at FROM saved_query_versions WHERE query_id = ? AND version = ?",
+		queryID, version,
+	).Scan(&v.QueryID, &v.Version, &v.SQL, &params, &v.CreatedBy, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(params), &v.Params)
+	return &v, nil
+}
+
+// ListVersions 获取已发布查询的所有版本(新版本在前)
+func (s *Store) ListVersions(queryID int64) ([]Version, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(
+		"SELECT query_id, version, sql_text, params, created_by, created_at FROM saved_query_versions WHERE query_id = ? ORDER BY version DESC",
+		queryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Version
+	for rows.Next() {
+		var v Version
+		var params string
+		if err := rows.Scan(&v.QueryID, &v.Version, &v.SQL, &params, &v.CreatedBy, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(params), &v.Params)
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// DeleteQuery 删除已发布查询及其所有版本
+func (s *Store) DeleteQuery(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM saved_queries WHERE id = ?",
+		"DELETE FROM saved_query_versions WHERE query_id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 解析后的定时表达式
+//
+// 支持标准的5段格式: 分(0-59) 时(0-23) 日(1-31) 月(1-12) 周(0-7，0和7均为周日)，
+// 每段可使用 *、数值、范围 a-b、步长 */n 或 a-b/n，以及逗号分隔的列表；
+// 也支持 @hourly、@daily、@weekly、@monthly 等简写。
+// 与 cron 相同，日和周都有限制时满足其一即可。
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// cronMacros 定时表达式简写
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron 解析定时表达式
+func ParseCron(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("定时表达式应为5段(分 时 日 月 周): %s", expr)
+	}
+
+	var s Schedule
+	var err error
+	if s.minute, err = parseCronField(parts[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("分钟: %w", err)
+	}
+	if s.hour, err = parseCronField(parts[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("小时: %w", err)
+	}
+	if s.dom, err = parseCronField(parts[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("日期: %w", err)
+	}
+	if s.month, err = parseCronField(parts[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("月份: %w", err)
+	}
+	if s.dow, err = parseCronField(parts[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("星期: %w", err)
+	}
+	// 7 与 0 同为周日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(parts[2], "*")
+	s.dowStar = strings.HasPrefix(parts[4], "*")
+	return &s, nil
+}
+
+// parseCronField 解析单段表达式为位集合
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("无效的步长: %s", item)
+			}
+			rangePart, step = item[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			i := strings.Index(rangePart, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(rangePart[:i])
+			hi, err2 = strconv.Atoi(rangePart[i+1:])
+			if err1 != nil || err2 != nil || lo > hi {
+				return 0, fmt.Errorf("无效的范围: %s", item)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("无效的值: %s", item)
+			}
+			lo, hi = n, n
+			// 单个值带步长时表示从该值开始到最大值
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max {
+			return 0, fmt.Errorf("%s 超出范围 %d-%d", item, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Match 判断时间(精确到分钟)是否满足定时表达式
+func (s *Schedule) Match(t time.Time) bool {
+	return s.minute&(1<<uint(t.Minute())) != 0 &&
+		s.hour&(1<<uint(t.Hour())) != 0 &&
+		s.month&(1<<uint(t.Month())) != 0 &&
+		s.dayMatch(t)
+}
+
+// dayMatch 日和周都有限制时满足其一即可
+func (s *Schedule) dayMatch(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next 返回晚于 t 的下一次运行时间，5年内没有满足的时间时返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatch(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
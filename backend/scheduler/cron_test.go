@@ -0,0 +1,129 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func cronTime(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseCronMatch(t *testing.T) {
+	// 2026-01-01 为周四，2026-01-04、2026-01-11 为周日
+	tests := []struct {
+		name  string
+		expr  string
+		at    string
+		match bool
+	}{
+		{"每分钟", "* * * * *", "2026-01-01 13:37", true},
+		{"步长命中", "*/15 * * * *", "2026-01-01 10:45", true},
+		{"步长未命中", "*/15 * * * *", "2026-01-01 10:50", false},
+		{"范围步长起点", "10-30/10 * * * *", "2026-01-01 10:10", true},
+		{"范围步长终点", "10-30/10 * * * *", "2026-01-01 10:30", true},
+		{"范围步长中间", "10-30/10 * * * *", "2026-01-01 10:15", false},
+		{"范围步长之外", "10-30/10 * * * *", "2026-01-01 10:40", false},
+		{"单值步长到最大值", "0 5/6 * * *", "2026-01-01 23:00", true},
+		{"单值步长之前", "0 5/6 * * *", "2026-01-01 04:00", false},
+		{"列表", "0 9,12,18 * * *", "2026-01-01 12:00", true},
+		{"周日写作7", "0 0 * * 7", "2026-01-04 00:00", true},
+		{"周日写作0", "0 0 * * 0", "2026-01-04 00:00", true},
+		{"周范围含7", "0 0 * * 5-7", "2026-01-04 00:00", true},
+		{"周范围含7不含周四", "0 0 * * 5-7", "2026-01-01 00:00", false},
+		{"日和周取并集命中日", "0 0 1 * 1", "2026-01-01 00:00", true},
+		{"日和周取并集命中周", "0 0 15 * 0", "2026-01-04 00:00", true},
+		{"日和周都不命中", "0 0 15 * 1", "2026-01-04 00:00", false},
+		{"周为星号时只看日", "0 0 1 * *", "2026-01-04 00:00", false},
+		{"日为星号时只看周", "0 0 * * 1", "2026-01-01 00:00", false},
+		{"日为星号步长时取交集不命中日", "0 0 */2 * 0", "2026-01-04 00:00", false},
+		{"日为星号步长时取交集都命中", "0 0 */2 * 0", "2026-01-11 00:00", true},
+		{"月份限制", "0 0 * 2 *", "2026-01-01 00:00", false},
+		{"简写", "@daily", "2026-01-01 00:00", true},
+		{"简写不区分大小写", "@HOURLY", "2026-01-01 13:00", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := s.Match(cronTime(tt.at)); got != tt.match {
+				t.Errorf("ParseCron(%q).Match(%s) = %v, want %v", tt.expr, tt.at, got, tt.match)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string
+	}{
+		{"下一分钟", "* * * * *", "2026-01-01 10:00", "2026-01-01 10:01"},
+		{"步长跨小时", "*/20 * * * *", "2026-01-01 10:45", "2026-01-01 11:00"},
+		{"跨天", "30 8 * * *", "2026-01-01 09:00", "2026-01-02 08:30"},
+		{"31日跳过小月", "0 0 31 * *", "2026-04-01 00:00", "2026-05-31 00:00"},
+		{"每月1日跨月", "0 0 1 * *", "2026-01-31 12:00", "2026-02-01 00:00"},
+		{"跨年", "0 0 1 * *", "2026-12-31 23:59", "2027-01-01 00:00"},
+		{"2月29日跳到闰年", "0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		{"月底周日取并集", "0 0 31 * 0", "2026-04-27 00:00", "2026-05-03 00:00"},
+		{"周日写作7", "0 0 * * 7", "2026-01-01 00:00", "2026-01-04 00:00"},
+		{"不存在的日期", "0 0 30 2 *", "2026-01-01 00:00", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			got := s.Next(cronTime(tt.from).Add(30 * time.Second))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("ParseCron(%q).Next(%s) = %v, want zero", tt.expr, tt.from, got)
+				}
+				return
+			}
+			if want := cronTime(tt.want); !got.Equal(want) {
+				t.Errorf("ParseCron(%q).Next(%s) = %v, want %v", tt.expr, tt.from, got, want)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"* * * *", "应为5段"},
+		{"@every 5m", "应为5段"},
+		{"60 * * * *", "超出范围"},
+		{"* 24 * * *", "超出范围"},
+		{"* * 0 * *", "超出范围"},
+		{"* * * 13 *", "超出范围"},
+		{"* * * * 8", "超出范围"},
+		{"*/0 * * * *", "无效的步长"},
+		{"*/x * * * *", "无效的步长"},
+		{"30-10 * * * *", "无效的范围"},
+		{"a * * * *", "无效的值"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCron(%q) err = %v, want containing %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
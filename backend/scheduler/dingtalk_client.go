@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	dingTalkBaseURL    = "https://api.dingtalk.com"
+	dingTalkBatchSize  = 100
+	dingTalkDefaultQPS = 5
+)
+
+func init() {
+	RegisterPlatform(PlatformDingTalk, newDingTalkClient)
+}
+
+// dingTalkClient 钉钉AI表格记录接口
+type dingTalkClient struct {
+	api    *apiClient
+	target Target
+	token  tokenCache
+}
+
+func newDingTalkClient(target Target) (Client, error) {
+	if target.AppID == "" || target.AppSecret == "" || target.BaseID == "" || target.TableID == "" || target.OperatorID == "" {
+		return nil, fmt.Errorf("请填写钉钉应用的 appKey、appSecret、AI表格的 baseId、sheetId 以及操作人 unionId")
+	}
+	baseURL, qps := target.BaseURL, target.QPS
+	if baseURL == "" {
+		baseURL = dingTalkBaseURL
+	}
+	if qps <= 0 {
+		qps = dingTalkDefaultQPS
+	}
+	return &dingTalkClient{api: newAPIClient(baseURL, qps), target: target}, nil
+}
+
+func (c *dingTalkClient) BatchSize() int {
+	return dingTalkBatchSize
+}
+
+// dingTalkError 钉钉接口的错误响应
+type dingTalkError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// checkDingTalkResponse 解析钉钉接口响应，非2xx为错误
+func checkDingTalkResponse(status int, body []byte, data interface{}) error {
+	if status < 200 || status >= 300 {
+		var e dingTalkError
+		json.Unmarshal(body, &e)
+		if e.Message == "" {
+			e.Message = truncate(string(body), 200)
+		}
+		// 限流错误码形如 Forbidden.AccessDenied.QpsLimitForApi、Throttling.Api
+		retryable := strings.Contains(e.Code, "QpsLimit") || strings.HasPrefix(e.Code, "Throttling")
+		// 记录不存在的错误码形如 RecordNotFound
+		notFound := strings.Contains(strings.ToLower(e.Code), "recordnotfound")
+		return &apiError{Status: status, Code: e.Code, Msg: e.Message, Retryable: retryable, NotFound: notFound}
+	}
+	if data != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, data); err != nil {
+			return &apiError{Status: status, Msg: "解析响应失败: " + truncate(string(body), 200)}
+		}
+	}
+	return nil
+}
+
+// headers 携带 accessToken 的请求头
+func (c *dingTalkClient) headers() (map[string]string, error) {
+	token, err := c.token.get(c.fetchToken)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"x-acs-dingtalk-access-token": token}, nil
+}
+
+// fetchToken 获取企业内部应用的 accessToken
+func (c *dingTalkClient) fetchToken() (string, int64, error) {
+	var resp struct {
+		AccessToken string `json:"accessToken"`
+		ExpireIn    int64  `json:"expireIn"`
+	}
+	err := c.api.do(context.Background(), http.MethodPost, "/v1.0/oauth2/accessToken", nil,
+		map[string]string{"appKey": c.target.AppID, "appSecret": c.target.AppSecret},
+		func(status int, body []byte) error {
+			return checkDingTalkResponse(status, body, &resp)
+		})
+	if err != nil {
+		return "", 0, fmt.Errorf("获取钉钉访问凭证失败: %w", err)
+	}
+	return resp.AccessToken, resp.ExpireIn, nil
+}
+
+// call 调用AI表格记录接口
+func (c *dingTalkClient) call(ctx context.Context, method, action string, body interface{}, data interface{}) error {
+	return c.api.do(ctx, method, c.recordsPath(action), c.headers, body, c.check(data))
+}
+
+// recordsPath AI表格记录接口的路径
+func (c *dingTalkClient) recordsPath(action string) string {
+	return fmt.Sprintf("/v1.0/notable/bases/%s/sheets/%s/records%s?operatorId=%s",
+		url.PathEscape(c.target.BaseID), url.PathEscape(c.target.TableID), action, url.QueryEscape(c.target.OperatorID))
+}
+
+// check 解析响应，访问凭证失效时清除缓存并重试
+func (c *dingTalkClient) check(data interface{}) func(status int, respBody []byte) error {
+	return func(status int, respBody []byte) error {
+		err := checkDingTalkResponse(status, respBody, data)
+		if apiErr, ok := err.(*apiError); ok && status == http.StatusUnauthorized {
+			c.token.reset()
+			apiErr.Retryable = true
+		}
+		return err
+	}
+}
+
+func (c *dingTalkClient) BatchCreate(ctx context.Context, records []PlatformRecord) ([]string, error) {
+	items := make([]map[string]interface{}, len(records))
+	for i, r := range records {
+		items[i] = map[string]interface{}{"fields": r.Fields}
+	}
+
+	var data struct {
+		Value []struct {
+			ID string `json:"id"`
+		} `json:"value"`
+	}
+	// 新增接口没有幂等键，结果未知时重试可能重复新增，交由下次运行处理
+	err := c.api.doOnce(ctx, http.MethodPost, c.recordsPath(""), c.headers, map[string]interface{}{"records": items}, c.check(&data))
+	if err != nil {
+		if resultUnknown(err) && ctx.Err() == nil {
+			return nil, fmt.Errorf("新增结果未知，记录可能已写入，请检查目标表格是否有重复记录: %w", err)
+		}
+		return nil, err
+	}
+	if len(data.Value) != len(records) {
+		return nil, fmt.Errorf("新增 %d 条记录，钉钉返回 %d 条", len(records), len(data.Value))
+	}
+
+	ids := make([]string, len(data.Value))
+	for i, r := range data.Value {
+		ids[i] = r.ID
+	}
+	return ids, nil
+}
+
+func (c *dingTalkClient) BatchUpdate(ctx context.Context, records []PlatformRecord) error {
+	items := make([]map[string]interface{}, len(records))
+	for i, r := range records {
+		items[i] = map[string]interface{}{"id": r.ID, "fields": r.Fields}
+	}
+	return c.call(ctx, http.MethodPut, "", map[string]interface{}{"records": items}, nil)
+}
+
+func (c *dingTalkClient) BatchDelete(ctx context.Context, ids []string) error {
+	return c.call(ctx, http.MethodPost, "/delete", map[string]interface{}{"recordIds": ids}, nil)
+}
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	feishuBaseURL    = "https://open.feishu.cn"
+	feishuBatchSize  = 500
+	feishuDefaultQPS = 5
+)
+
+// 飞书开放平台错误码
+var (
+	// 频率限制、写冲突等可重试的错误
+	feishuRetryableCodes = map[int]bool{
+		99991400: true, // 请求频率超限
+		1254290:  true, // TooManyRequest
+		1254291:  true, // 同一数据表并发写冲突
+		1254607:  true, // 数据未就绪
+	}
+	// 访问凭证失效
+	feishuTokenCodes = map[int]bool{
+		99991661: true,
+		99991663: true,
+		99991668: true,
+	}
+)
+
+// feishuRecordNotFoundCode 要更新的记录不存在(RecordIdNotFound)
+const feishuRecordNotFoundCode = 1254043
+
+func init() {
+	RegisterPlatform(PlatformFeishu, newFeishuClient)
+}
+
+// feishuClient 飞书多维表格记录接口
+type feishuClient struct {
+	api    *apiClient
+	target Target
+	token  tokenCache
+}
+
+func newFeishuClient(target Target) (Client, error) {
+	if target.AppID == "" || target.AppSecret == "" || target.BaseID == "" || target.TableID == "" {
+		return nil, fmt.Errorf("请填写飞书应用的 app_id、app_secret 以及多维表格的 app_token、table_id")
+	}
+	baseURL, qps := target.BaseURL, target.QPS
+	if baseURL == "" {
+		baseURL = feishuBaseURL
+	}
+	if qps <= 0 {
+		qps = feishuDefaultQPS
+	}
+	return &feishuClient{api: newAPIClient(baseURL, qps), target: target}, nil
+}
+
+func (c *feishuClient) BatchSize() int {
+	return feishuBatchSize
+}
+
+// feishuResponse 飞书接口的通用响应
+type feishuResponse struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// headers 携带 tenant_access_token 的请求头
+func (c *feishuClient) headers() (map[string]string, error) {
+	token, err := c.token.get(c.fetchToken)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"Authorization": "Bearer " + token}, nil
+}
+
+// fetchToken 获取 tenant_access_token
+func (c *feishuClient) fetchToken() (string, int64, error) {
+	var resp struct {
+		Code   int    `json:"code"`
+		Msg    string `json:"msg"`
+		Token  string `json:"tenant_access_token"`
+		Expire int64  `json:"expire"`
+	}
+	err := c.api.do(context.Background(), http.MethodPost, "/open-apis/auth/v3/tenant_access_token/internal", nil,
+		map[string]string{"app_id": c.target.AppID, "app_secret": c.target.AppSecret},
+		func(status int, body []byte) error {
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return &apiError{Status: status, Msg: "解析响应失败: " + truncate(string(body), 200)}
+			}
+			if resp.Code != 0 {
+				return &apiError{Status: status, Code: strconv.Itoa(resp.Code), Msg: resp.Msg}
+			}
+			return nil
+		})
+	if err != nil {
+		return "", 0, fmt.Errorf("获取飞书访问凭证失败: %w", err)
+	}
+	return resp.Token, resp.Expire, nil
+}
+
+// call 调用多维表格记录接口，action 为接口名(可附带查询参数)，data 为响应中 data 字段的解析目标
+func (c *feishuClient) call(ctx context.Context, action string, body interface{}, data interface{}) error {
+	path := fmt.Sprintf("/open-apis/bitable/v1/apps/%s/tables/%s/records/%s",
+		url.PathEscape(c.target.BaseID), url.PathEscape(c.target.TableID), action)
+	return c.api.do(ctx, http.MethodPost, path, c.headers, body, func(status int, respBody []byte) error {
+		var resp feishuResponse
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return &apiError{Status: status, Msg: "解析响应失败: " + truncate(string(respBody), 200)}
+		}
+		if resp.Code != 0 {
+			retryable := feishuRetryableCodes[resp.Code]
+			if feishuTokenCodes[resp.Code] {
+				c.token.reset()
+				retryable = true
+			}
+			return &apiError{Status: status, Code: strconv.Itoa(resp.Code), Msg: resp.Msg, Retryable: retryable,
+				NotFound: resp.Code == feishuRecordNotFoundCode}
+		}
+		if data != nil && len(resp.Data) > 0 {
+			if err := json.Unmarshal(resp.Data, data); err != nil {
+				return &apiError{Status: status, Msg: "解析响应失败: " + err.Error()}
+			}
+		}
+		return nil
+	})
+}
+
+func (c *feishuClient) BatchCreate(ctx context.Context, records []PlatformRecord) ([]string, error) {
+	items := make([]map[string]interface{}, len(records))
+	for i, r := range records {
+		items[i] = map[string]interface{}{"fields": r.Fields}
+	}
+
+	var data struct {
+		Records []struct {
+			RecordID string `json:"record_id"`
+		} `json:"records"`
+	}
+	// 同一批次的重试使用相同的 client_token，飞书据此去重，避免重复新增
+	token, err := newClientToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.call(ctx, "batch_create?client_token="+token, map[string]interface{}{"records": items}, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Records) != len(records) {
+		return nil, fmt.Errorf("新增 %d 条记录，飞书返回 %d 条", len(records), len(data.Records))
+	}
+
+	ids := make([]string, len(data.Records))
+	for i, r := range data.Records {
+		ids[i] = r.RecordID
+	}
+	return ids, nil
+}
+
+func (c *feishuClient) BatchUpdate(ctx context.Context, records []PlatformRecord) error {
+	items := make([]map[string]interface{}, len(records))
+	for i, r := range records {
+		items[i] = map[string]interface{}{"record_id": r.ID, "fields": r.Fields}
+	}
+	return c.call(ctx, "batch_update", map[string]interface{}{"records": items}, nil)
+}
+
+func (c *feishuClient) BatchDelete(ctx context.Context, ids []string) error {
+	return c.call(ctx, "batch_delete", map[string]interface{}{"records": ids}, nil)
+}
+
+// newClientToken 生成飞书幂等操作使用的 client_token(uuid v4 格式)
+func newClientToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成 client_token 失败: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"mysql-sync-plugin/logger"
+	"sync"
+	"time"
+)
+
+// Manager 按定时表达式调度推送任务，同一任务同时只运行一次
+type Manager struct {
+	mu      sync.Mutex
+	running map[int64]bool
+	log     *logger.Logger
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+var (
+	manager     *Manager
+	managerOnce sync.Once
+)
+
+// GetManager 获取调度器单例
+func GetManager() *Manager {
+	managerOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		manager = &Manager{
+			running: make(map[int64]bool),
+			log:     logger.New("scheduler"),
+			ctx:     ctx,
+			cancel:  cancel,
+		}
+	})
+	return manager
+}
+
+// Start 启动调度，每分钟检查一次到期的任务
+func (m *Manager) Start() error {
+	if err := GetStore().FailInterruptedRuns(); err != nil {
+		return err
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for {
+			// 对齐到下一分钟的开始
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+			timer := time.NewTimer(next.Sub(now))
+			select {
+			case <-m.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			m.tick(next)
+		}
+	}()
+	return nil
+}
+
+// StopAll 停止调度并等待运行中的任务退出
+func (m *Manager) StopAll() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// tick 启动在该分钟到期的任务
+func (m *Manager) tick(t time.Time) {
+	jobs, err := GetStore().ListJobs()
+	if err != nil {
+		m.log.Errorf("定时推送", "读取任务失败: %v", err)
+		return
+	}
+	for i := range jobs {
+		job := jobs[i]
+		if !job.Enabled {
+			continue
+		}
+		schedule, err := ParseCron(job.Cron)
+		if err != nil {
+			m.log.Errorf("定时推送", "任务 %s 的定时表达式无效: %v", job.Name, err)
+			continue
+		}
+		if !schedule.Match(t) {
+			continue
+		}
+		if err := m.launch(&job, TriggerSchedule); err != nil {
+			m.log.Warnf("定时推送", "任务 %s 未启动: %v", job.Name, err)
+		}
+	}
+}
+
+// RunNow 立即运行任务
+func (m *Manager) RunNow(id int64) error {
+	job, err := GetStore().GetJob(id)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return fmt.Errorf("任务不存在")
+	}
+	return m.launch(job, TriggerManual)
+}
+
+// Running 任务是否正在运行
+func (m *Manager) Running(id int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running[id]
+}
+
+// launch 在后台运行任务，任务正在运行时返回错误
+func (m *Manager) launch(job *Job, trigger string) error {
+	m.mu.Lock()
+	if m.running[job.ID] {
+		m.mu.Unlock()
+		return fmt.Errorf("任务正在运行")
+	}
+	if m.ctx.Err() != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("调度器已停止")
+	}
+	m.running[job.ID] = true
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer func() {
+			m.mu.Lock()
+			delete(m.running, job.ID)
+			m.mu.Unlock()
+			m.wg.Done()
+		}()
+		m.execute(job, trigger)
+	}()
+	return nil
+}
+
+// execute 运行任务并保存运行记录
+func (m *Manager) execute(job *Job, trigger string) {
+	run := &Run{JobID: job.ID, Trigger: trigger}
+	if err := GetStore().StartRun(run); err != nil {
+		m.log.Errorf("定时推送", "任务 %s 写入运行记录失败: %v", job.Name, err)
+		return
+	}
+
+	err := func() error {
+		client, err := NewClient(job.Target)
+		if err != nil {
+			return err
+		}
+		source, err := mysqlSource(job)
+		if err != nil {
+			return err
+		}
+		return runJob(m.ctx, job, source, client, run)
+	}()
+
+	switch {
+	case err != nil:
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+	case run.Failed > 0:
+		run.Status = RunStatusPartial
+	default:
+		run.Status = RunStatusSuccess
+	}
+	if err := GetStore().FinishRun(run); err != nil {
+		m.log.Errorf("定时推送", "任务 %s 保存运行记录失败: %v", job.Name, err)
+	}
+
+	summary := fmt.Sprintf("任务 %s: 读取 %d 条，新增 %d 条，更新 %d 条，删除 %d 条，未变化 %d 条，失败 %d 条",
+		job.Name, run.Read, run.Created, run.Updated, run.Deleted, run.Unchanged, run.Failed)
+	switch run.Status {
+	case RunStatusSuccess:
+		m.log.Info("定时推送", summary)
+	case RunStatusPartial:
+		m.log.Warnf("定时推送", "%s (%s)", summary, run.Error)
+	default:
+		m.log.Errorf("定时推送", "%s，运行失败: %s", summary, run.Error)
+	}
+}
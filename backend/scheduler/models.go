@@ -0,0 +1,80 @@
+package scheduler
+
+import "time"
+
+// 目标平台
+const (
+	PlatformFeishu   = "feishu"   // 飞书多维表格
+	PlatformDingTalk = "dingtalk" // 钉钉AI表格
+)
+
+// 运行触发方式
+const (
+	TriggerSchedule = "schedule" // 按定时表达式触发
+	TriggerManual   = "manual"   // 管理员手动触发
+)
+
+// 运行状态
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusPartial = "partial" // 部分记录推送失败，下次运行重试
+	RunStatusFailed  = "failed"
+)
+
+// Target 推送目标表格
+type Target struct {
+	Platform   string  `json:"platform"`             // feishu 或 dingtalk
+	BaseURL    string  `json:"baseUrl,omitempty"`    // 开放平台地址，为空时使用官方地址(联调时可指向模拟服务)
+	AppID      string  `json:"appId"`                // 飞书 app_id / 钉钉 appKey
+	AppSecret  string  `json:"appSecret,omitempty"`  // 飞书 app_secret / 钉钉 appSecret，列表接口中不返回
+	BaseID     string  `json:"baseId"`               // 飞书多维表格 app_token / 钉钉AI表格 baseId
+	TableID    string  `json:"tableId"`              // 飞书 table_id / 钉钉 sheetId
+	OperatorID string  `json:"operatorId,omitempty"` // 钉钉操作人 unionId
+	QPS        float64 `json:"qps,omitempty"`        // 请求频率上限(次/秒)，为0时使用平台默认值
+}
+
+// Job 定时推送任务：按定时表达式从源库读取数据，推送到目标表格
+type Job struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	Cron          string    `json:"cron"`   // 定时表达式(分 时 日 月 周)，按服务器时区解释
+	Source        string    `json:"source"` // 取数配置(与数据源配置相同的 MySQLConfig JSON)，列表接口中不返回密码
+	Target        Target    `json:"target"`
+	DeleteMissing bool      `json:"deleteMissing"` // 源数据中已不存在的记录从目标表格删除
+	Enabled       bool      `json:"enabled"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Run 一次运行记录
+type Run struct {
+	ID         int64      `json:"id"`
+	JobID      int64      `json:"jobId"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status"`
+	Read       int        `json:"read"`      // 读取的源记录数
+	Created    int        `json:"created"`   // 新增的记录数
+	Updated    int        `json:"updated"`   // 更新的记录数
+	Deleted    int        `json:"deleted"`   // 删除的记录数
+	Unchanged  int        `json:"unchanged"` // 内容未变化而跳过的记录数
+	Failed     int        `json:"failed"`    // 推送失败的记录数
+	Error      string     `json:"error"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+// RunQuery 运行记录查询条件
+type RunQuery struct {
+	JobID    int64  `form:"jobId"`
+	Status   string `form:"status"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"pageSize"`
+}
+
+// RecordState 已推送记录的状态：源记录ID与目标表格记录ID的对应关系及上次推送内容的摘要
+type RecordState struct {
+	RecordID   string
+	PlatformID string
+	Hash       string
+}
@@ -0,0 +1,286 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PlatformRecord 目标表格中的一条记录，字段按字段名称填写
+type PlatformRecord struct {
+	ID     string                 // 目标表格的记录ID，新增时为空
+	Fields map[string]interface{} // 字段名称 -> 值
+}
+
+// Client 目标表格的开放平台客户端
+type Client interface {
+	// BatchSize 单次批量操作的最大记录数
+	BatchSize() int
+	// BatchCreate 批量新增记录，按顺序返回目标表格的记录ID
+	BatchCreate(ctx context.Context, records []PlatformRecord) ([]string, error)
+	// BatchUpdate 批量更新记录，有记录在目标表格中不存在时返回的错误满足 isNotFound
+	BatchUpdate(ctx context.Context, records []PlatformRecord) error
+	// BatchDelete 批量删除记录
+	BatchDelete(ctx context.Context, ids []string) error
+}
+
+// ClientFactory 根据推送目标创建客户端
+type ClientFactory func(target Target) (Client, error)
+
+var (
+	platformsMu sync.RWMutex
+	platforms   = make(map[string]ClientFactory)
+)
+
+// RegisterPlatform 注册目标平台的客户端
+func RegisterPlatform(name string, factory ClientFactory) {
+	platformsMu.Lock()
+	defer platformsMu.Unlock()
+	platforms[name] = factory
+}
+
+// Platforms 返回已注册的目标平台
+func Platforms() []string {
+	platformsMu.RLock()
+	defer platformsMu.RUnlock()
+
+	names := make([]string, 0, len(platforms))
+	for name := range platforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewClient 创建推送目标的客户端
+func NewClient(target Target) (Client, error) {
+	platformsMu.RLock()
+	factory := platforms[target.Platform]
+	platformsMu.RUnlock()
+
+	if factory == nil {
+		return nil, fmt.Errorf("不支持的目标平台: %s", target.Platform)
+	}
+	return factory(target)
+}
+
+const (
+	// 请求失败的最大重试次数
+	maxRetries = 4
+	// 首次重试等待时间，之后每次翻倍
+	retryBackoff = time.Second
+	// 重试等待时间上限
+	maxRetryBackoff = 30 * time.Second
+)
+
+// apiError 开放平台返回的错误
+type apiError struct {
+	Status    int    // HTTP状态码
+	Code      string // 平台错误码
+	Msg       string
+	Retryable bool // 限流、服务端错误等可重试的错误
+	NotFound  bool // 要更新的记录在目标表格中不存在(如被手动删除)
+}
+
+func (e *apiError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("HTTP %d, 错误码 %s: %s", e.Status, e.Code, e.Msg)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.Status, e.Msg)
+}
+
+// apiClient 开放平台HTTP客户端，按频率上限限流，限流和服务端错误时按指数退避重试
+type apiClient struct {
+	baseURL    string
+	httpClient *http.Client
+	interval   time.Duration // 两次请求的最小间隔
+
+	mu   sync.Mutex
+	next time.Time // 下一次请求最早可发出的时间
+}
+
+func newAPIClient(baseURL string, qps float64) *apiClient {
+	return &apiClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		interval:   time.Duration(float64(time.Second) / qps),
+	}
+}
+
+// wait 等待到可发出下一次请求
+func (c *apiClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	at := c.next
+	if at.Before(now) {
+		at = now
+	}
+	c.next = at.Add(c.interval)
+	c.mu.Unlock()
+
+	if d := time.Until(at); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+// do 发送JSON请求，由 check 解析响应并判断平台错误；可重试的错误按指数退避重试
+// headers 在每次尝试前调用，以便访问凭证失效后使用刷新的凭证重试
+func (c *apiClient) do(ctx context.Context, method, path string, headers func() (map[string]string, error), body interface{},
+	check func(status int, respBody []byte) error) error {
+	return c.request(ctx, method, path, headers, body, check, true)
+}
+
+// doOnce 发送不可重复执行的请求(如不带幂等键的新增)，只在请求确定未被处理(限流、凭证失效)时重试
+func (c *apiClient) doOnce(ctx context.Context, method, path string, headers func() (map[string]string, error), body interface{},
+	check func(status int, respBody []byte) error) error {
+	return c.request(ctx, method, path, headers, body, check, false)
+}
+
+func (c *apiClient) request(ctx context.Context, method, path string, headers func() (map[string]string, error), body interface{},
+	check func(status int, respBody []byte) error, idempotent bool) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+
+		var h map[string]string
+		if headers != nil {
+			var err error
+			if h, err = headers(); err != nil {
+				return err
+			}
+		}
+		err := c.send(ctx, method, path, h, payload, check)
+		if err == nil {
+			return nil
+		}
+		if !retryable(err, idempotent) || attempt >= maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// isNotFound 判断错误是否为记录不存在
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.NotFound
+}
+
+// retryable 判断失败的请求能否重试，不可重复执行的请求在结果未知时不重试
+func retryable(err error, idempotent bool) bool {
+	if apiErr, ok := err.(*apiError); ok && !apiErr.Retryable {
+		return false
+	}
+	return idempotent || !resultUnknown(err)
+}
+
+// resultUnknown 网络错误、超时和服务端错误时无法确定平台是否已处理请求
+func resultUnknown(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return !ok || (apiErr.Retryable && apiErr.Status >= 500)
+}
+
+// send 发送一次请求，网络错误视为可重试
+func (c *apiClient) send(ctx context.Context, method, path string, headers map[string]string, payload []byte,
+	check func(status int, respBody []byte) error) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return &apiError{Msg: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return &apiError{Status: resp.StatusCode, Msg: truncate(string(respBody), 200), Retryable: true}
+	}
+	return check(resp.StatusCode, respBody)
+}
+
+// truncate 截断过长的错误信息
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
+
+// tokenCache 访问凭证缓存，过期前提前刷新
+type tokenCache struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// get 返回有效的访问凭证，过期时调用 fetch 获取(返回凭证及有效期秒数)
+func (t *tokenCache) get(fetch func() (string, int64, error)) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Now().Before(t.expires) {
+		return t.token, nil
+	}
+	token, expireIn, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	// 提前5分钟刷新
+	ttl := time.Duration(expireIn)*time.Second - 5*time.Minute
+	if ttl < time.Minute {
+		ttl = time.Minute
+	}
+	t.token, t.expires = token, time.Now().Add(ttl)
+	return token, nil
+}
+
+// reset 凭证被平台判定失效时清除缓存
+func (t *tokenCache) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.token = ""
+}
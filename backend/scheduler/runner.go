@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// pusher 按批次向目标表格推送新增和更新的记录
+type pusher struct {
+	ctx    context.Context
+	client Client
+	jobID  int64
+	run    *Run
+	errors []string
+
+	creates      []PlatformRecord
+	createStates []RecordState
+	updates      []PlatformRecord
+	updateStates []RecordState
+}
+
+// fail 记录推送失败的批次
+func (p *pusher) fail(count int, action string, err error) {
+	p.run.Failed += count
+	p.errors = append(p.errors, fmt.Sprintf("%s %d 条记录失败: %v", action, count, err))
+}
+
+// flushCreates 推送待新增的记录，成功后保存目标表格的记录ID
+func (p *pusher) flushCreates() error {
+	if len(p.creates) == 0 {
+		return nil
+	}
+	records, states := p.creates, p.createStates
+	p.creates, p.createStates = nil, nil
+
+	ids, err := p.client.BatchCreate(p.ctx, records)
+	if err != nil {
+		if p.ctx.Err() != nil {
+			return p.ctx.Err()
+		}
+		p.fail(len(records), "新增", err)
+		return nil
+	}
+	for i := range states {
+		states[i].PlatformID = ids[i]
+	}
+	if err := GetStore().SaveRecordStates(p.jobID, states); err != nil {
+		return fmt.Errorf("保存记录状态失败: %w", err)
+	}
+	p.run.Created += len(records)
+	return nil
+}
+
+// flushUpdates 推送待更新的记录，成功后保存内容摘要
+func (p *pusher) flushUpdates() error {
+	if len(p.updates) == 0 {
+		return nil
+	}
+	records, states := p.updates, p.updateStates
+	p.updates, p.updateStates = nil, nil
+	return p.updateBatch(records, states)
+}
+
+// updateBatch 更新一批记录；有记录在目标表格中已被删除时拆分批次找出这些记录，
+// 删除其状态并改为新增
+func (p *pusher) updateBatch(records []PlatformRecord, states []RecordState) error {
+	err := p.client.BatchUpdate(p.ctx, records)
+	if err == nil {
+		if err := GetStore().SaveRecordStates(p.jobID, states); err != nil {
+			return fmt.Errorf("保存记录状态失败: %w", err)
+		}
+		p.run.Updated += len(records)
+		return nil
+	}
+	if p.ctx.Err() != nil {
+		return p.ctx.Err()
+	}
+	if !isNotFound(err) {
+		p.fail(len(records), "更新", err)
+		return nil
+	}
+
+	if len(records) > 1 {
+		mid := len(records) / 2
+		if err := p.updateBatch(records[:mid], states[:mid]); err != nil {
+			return err
+		}
+		return p.updateBatch(records[mid:], states[mid:])
+	}
+
+	st := states[0]
+	if err := GetStore().DeleteRecordStates(p.jobID, []string{st.RecordID}); err != nil {
+		return fmt.Errorf("保存记录状态失败: %w", err)
+	}
+	p.creates = append(p.creates, PlatformRecord{Fields: records[0].Fields})
+	p.createStates = append(p.createStates, RecordState{RecordID: st.RecordID, Hash: st.Hash})
+	if len(p.creates) >= p.client.BatchSize() {
+		return p.flushCreates()
+	}
+	return nil
+}
+
+// runJob 读取源数据，与上次推送的内容比对后推送新增、更新的记录，并按配置删除源数据中已不存在的记录
+// 推送失败的记录不更新状态，下次运行时重试
+func runJob(ctx context.Context, job *Job, read RecordSource, client Client, run *Run) error {
+	states, err := GetStore().LoadRecordStates(job.ID)
+	if err != nil {
+		return fmt.Errorf("读取记录状态失败: %w", err)
+	}
+
+	p := &pusher{ctx: ctx, client: client, jobID: job.ID, run: run}
+	batchSize := client.BatchSize()
+	seen := make(map[string]bool)
+
+	token := ""
+	for {
+		page, err := read(ctx, token)
+		if err != nil {
+			return fmt.Errorf("读取源数据失败: %w", err)
+		}
+
+		names := make(map[string]string, len(page.Fields))
+		for _, f := range page.Fields {
+			names[f.ID] = f.Name
+		}
+
+		for _, rec := range page.Records {
+			run.Read++
+			if seen[rec.ID] {
+				continue
+			}
+			seen[rec.ID] = true
+
+			fields := make(map[string]interface{}, len(rec.Fields))
+			for id, v := range rec.Fields {
+				name := names[id]
+				if name == "" {
+					name = strings.TrimPrefix(id, "fid_")
+				}
+				fields[name] = v
+			}
+			hash, err := recordHash(fields)
+			if err != nil {
+				return err
+			}
+
+			st, ok := states[rec.ID]
+			switch {
+			case ok && st.Hash == hash:
+				run.Unchanged++
+			case ok:
+				p.updates = append(p.updates, PlatformRecord{ID: st.PlatformID, Fields: fields})
+				p.updateStates = append(p.updateStates, RecordState{RecordID: rec.ID, PlatformID: st.PlatformID, Hash: hash})
+			default:
+				p.creates = append(p.creates, PlatformRecord{Fields: fields})
+				p.createStates = append(p.createStates, RecordState{RecordID: rec.ID, Hash: hash})
+			}
+
+			if len(p.creates) >= batchSize {
+				if err := p.flushCreates(); err != nil {
+					return err
+				}
+			}
+			if len(p.updates) >= batchSize {
+				if err := p.flushUpdates(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !page.HasMore || page.NextToken == "" {
+			break
+		}
+		token = page.NextToken
+	}
+
+	if err := p.flushUpdates(); err != nil {
+		return err
+	}
+	// 更新时发现已被删除的记录会转为新增，因此最后推送新增
+	if err := p.flushCreates(); err != nil {
+		return err
+	}
+
+	// 源数据完整读取后才删除，避免读取中断时误删
+	if job.DeleteMissing {
+		var recordIDs, platformIDs []string
+		for id, st := range states {
+			if !seen[id] {
+				recordIDs = append(recordIDs, id)
+				platformIDs = append(platformIDs, st.PlatformID)
+			}
+		}
+		for start := 0; start < len(platformIDs); start += batchSize {
+			end := start + batchSize
+			if end > len(platformIDs) {
+				end = len(platformIDs)
+			}
+			if err := client.BatchDelete(ctx, platformIDs[start:end]); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				p.fail(end-start, "删除", err)
+				continue
+			}
+			if err := GetStore().DeleteRecordStates(job.ID, recordIDs[start:end]); err != nil {
+				return fmt.Errorf("保存记录状态失败: %w", err)
+			}
+			run.Deleted += end - start
+		}
+	}
+
+	if len(p.errors) > 0 {
+		run.Error = strings.Join(p.errors, "; ")
+		if len(p.errors) > 3 {
+			run.Error = strings.Join(p.errors[:3], "; ") + fmt.Sprintf("; 等 %d 个批次失败", len(p.errors))
+		}
+	}
+	return nil
+}
+
+// recordHash 记录内容摘要，用于跳过未变化的记录(map 序列化时按键排序，结果稳定)
+func recordHash(fields map[string]interface{}) (string, error) {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("序列化记录失败: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16]), nil
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/service"
+)
+
+// sourcePageSize 每次从源库读取的记录数
+const sourcePageSize = 500
+
+// RecordSource 分页读取源数据，pageToken 为空时读取第一页
+type RecordSource func(ctx context.Context, pageToken string) (*models.RecordsResponse, error)
+
+// mysqlSource 按任务的取数配置从源库读取记录
+// 定时推送每次全量读取并与上次推送的内容比对，因此固定使用全量同步模式
+func mysqlSource(job *Job) (RecordSource, error) {
+	params, err := PrepareSource(job.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := service.NewMySQLService()
+	targetKey := fmt.Sprintf("scheduler:%d", job.ID)
+	return func(ctx context.Context, pageToken string) (*models.RecordsResponse, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return svc.GetRecords(&models.RecordsRequest{
+			MaxResults: sourcePageSize,
+			NextToken:  pageToken,
+			Params:     params,
+			TargetKey:  targetKey,
+		})
+	}, nil
+}
+
+// PrepareSource 校验取数配置并固定为全量同步
+func PrepareSource(source string) (string, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(source), &config); err != nil {
+		return "", fmt.Errorf("取数配置格式错误: %w", err)
+	}
+	config["syncMode"] = service.SyncModeFull
+	b, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// MaskSourcePassword 去除取数配置中的密码，用于列表接口
+func MaskSourcePassword(source string) string {
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(source), &config); err != nil {
+		return source
+	}
+	if _, ok := config["password"]; !ok {
+		return source
+	}
+	delete(config, "password")
+	b, err := json.Marshal(config)
+	if err != nil {
+		return source
+	}
+	return string(b)
+}
+
+// MergeSourcePassword 新的取数配置未填写密码且连接信息未变时沿用原配置的密码
+func MergeSourcePassword(source, old string) (string, error) {
+	var config, oldConfig map[string]interface{}
+	if err := json.Unmarshal([]byte(source), &config); err != nil {
+		return "", fmt.Errorf("取数配置格式错误: %w", err)
+	}
+	if pw, _ := config["password"].(string); pw != "" {
+		return source, nil
+	}
+	if err := json.Unmarshal([]byte(old), &oldConfig); err != nil {
+		return source, nil
+	}
+	for _, key := range []string{"host", "port", "username"} {
+		if fmt.Sprint(config[key]) != fmt.Sprint(oldConfig[key]) {
+			return source, nil
+		}
+	}
+	if oldConfig["password"] == nil {
+		return source, nil
+	}
+	config["password"] = oldConfig["password"]
+	b, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,439 @@
+package scheduler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store 定时推送存储(任务、运行记录和已推送记录的状态)
+type Store struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// GetStore 获取定时推送存储单例
+func GetStore() *Store {
+	once.Do(func() {
+		instance = &Store{}
+	})
+	return instance
+}
+
+// Init 初始化数据库
+func (s *Store) Init(dbPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	// 创建任务、运行记录和记录状态表
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS scheduler_jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		cron TEXT NOT NULL,
+		source TEXT NOT NULL,
+		target TEXT NOT NULL,
+		delete_missing INTEGER NOT NULL DEFAULT 0,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS scheduler_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL,
+		trigger_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		read_count INTEGER NOT NULL DEFAULT 0,
+		created_count INTEGER NOT NULL DEFAULT 0,
+		updated_count INTEGER NOT NULL DEFAULT 0,
+		deleted_count INTEGER NOT NULL DEFAULT 0,
+		unchanged_count INTEGER NOT NULL DEFAULT 0,
+		failed_count INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME
+	);
+	CREATE INDEX IF NOT EXISTS idx_scheduler_runs_job ON scheduler_runs(job_id, id);
+	CREATE TABLE IF NOT EXISTS scheduler_records (
+		job_id INTEGER NOT NULL,
+		record_id TEXT NOT NULL,
+		platform_id TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		PRIMARY KEY (job_id, record_id)
+	);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		db.Close()
+		return fmt.Errorf("创建表失败: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+const jobColumns = "id, name, cron, source, target, delete_missing, enabled, created_at, updated_at"
+
+// scanJob 扫描任务
+func scanJob(scanner interface{ Scan(...interface{}) error }) (*Job, error) {
+	var j Job
+	var target string
+	if err := scanner.Scan(&j.ID, &j.Name, &j.Cron, &j.Source, &target, &j.DeleteMissing, &j.Enabled,
+		&j.CreatedAt, &j.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(target), &j.Target)
+	return &j, nil
+}
+
+// ListJobs 获取所有任务
+func (s *Store) ListJobs() ([]Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query("SELECT " + jobColumns + " FROM scheduler_jobs ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *j)
+	}
+	return list, rows.Err()
+}
+
+// GetJob 根据ID获取任务
+func (s *Store) GetJob(id int64) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	j, err := scanJob(s.db.QueryRow("SELECT "+jobColumns+" FROM scheduler_jobs WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return j, err
+}
+
+// SaveJob 保存任务(ID为0时新建)，目标表格变化时清空已推送记录的状态
+func (s *Store) SaveJob(j *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target, err := json.Marshal(j.Target)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if j.ID == 0 {
+		result, err := tx.Exec(
+			"INSERT INTO scheduler_jobs (name, cron, source, target, delete_missing, enabled, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			j.Name, j.Cron, j.Source, string(target), j.DeleteMissing, j.Enabled, now, now,
+		)
+		if err != nil {
+			return uniqueNameError(err, j.Name)
+		}
+		if j.ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	var oldTarget string
+	if err := tx.QueryRow("SELECT target FROM scheduler_jobs WHERE id = ?", j.ID).Scan(&oldTarget); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("任务不存在")
+		}
+		return err
+	}
+	var old Target
+	json.Unmarshal([]byte(oldTarget), &old)
+
+	if _, err := tx.Exec(
+		"UPDATE scheduler_jobs SET name = ?, cron = ?, source = ?, target = ?, delete_missing = ?, enabled = ?, updated_at = ? WHERE id = ?",
+		j.Name, j.Cron, j.Source, string(target), j.DeleteMissing, j.Enabled, now, j.ID,
+	); err != nil {
+		return uniqueNameError(err, j.Name)
+	}
+	if old.Platform != j.Target.Platform || old.BaseID != j.Target.BaseID || old.TableID != j.Target.TableID {
+		if _, err := tx.Exec("DELETE FROM scheduler_records WHERE job_id = ?", j.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteJob 删除任务及其运行记录和记录状态
+func (s *Store) DeleteJob(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM scheduler_jobs WHERE id = ?",
+		"DELETE FROM scheduler_runs WHERE job_id = ?",
+		"DELETE FROM scheduler_records WHERE job_id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// uniqueNameError 将名称唯一约束冲突转换为可读的错误
+func uniqueNameError(err error, name string) error {
+	if strings.Contains(err.Error(), "UNIQUE") {
+		return fmt.Errorf("任务名称 %s 已存在", name)
+	}
+	return err
+}
+
+// StartRun 写入一条运行中的运行记录
+func (s *Store) StartRun(r *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.Status = RunStatusRunning
+	r.StartedAt = time.Now()
+	result, err := s.db.Exec(
+		"INSERT INTO scheduler_runs (job_id, trigger_type, status, started_at) VALUES (?, ?, ?, ?)",
+		r.JobID, r.Trigger, r.Status, r.StartedAt,
+	)
+	if err != nil {
+		return err
+	}
+	r.ID, err = result.LastInsertId()
+	return err
+}
+
+// FinishRun 保存运行结果
+func (s *Store) FinishRun(r *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	r.FinishedAt = &now
+	_, err := s.db.Exec(
+		`UPDATE scheduler_runs SET status = ?, read_count = ?, created_count = ?, updated_count = ?, deleted_count = ?,
+			unchanged_count = ?, failed_count = ?, error = ?, finished_at = ? WHERE id = ?`,
+		r.Status, r.Read, r.Created, r.Updated, r.Deleted, r.Unchanged, r.Failed, r.Error, now, r.ID,
+	)
+	return err
+}
+
+// FailInterruptedRuns 将服务重启前未结束的运行标记为失败
+func (s *Store) FailInterruptedRuns() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(
+		"UPDATE scheduler_runs SET status = ?, error = ?, finished_at = ? WHERE status = ?",
+		RunStatusFailed, "服务重启，运行中断", time.Now(), RunStatusRunning,
+	)
+	return err
+}
+
+// QueryRuns 分页查询运行记录(新记录在前)
+func (s *Store) QueryRuns(q *RunQuery) ([]Run, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var conditions []string
+	var args []interface{}
+	if q.JobID > 0 {
+		conditions = append(conditions, "job_id = ?")
+		args = append(args, q.JobID)
+	}
+	if q.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, q.Status)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM scheduler_runs "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 20
+	}
+
+	rows, err := s.db.Query(
+		`SELECT id, job_id, trigger_type, status, read_count, created_count, updated_count, deleted_count,
+			unchanged_count, failed_count, error, started_at, finished_at
+		FROM scheduler_runs `+whereClause+` ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, q.PageSize, (q.Page-1)*q.PageSize)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var list []Run
+	for rows.Next() {
+		var r Run
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.JobID, &r.Trigger, &r.Status, &r.Read, &r.Created, &r.Updated, &r.Deleted,
+			&r.Unchanged, &r.Failed, &r.Error, &r.StartedAt, &finishedAt); err != nil {
+			return nil, 0, err
+		}
+		if finishedAt.Valid {
+			r.FinishedAt = &finishedAt.Time
+		}
+		list = append(list, r)
+	}
+	return list, total, rows.Err()
+}
+
+// LastRuns 获取每个任务最近一次运行记录
+func (s *Store) LastRuns() (map[int64]Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(
+		`SELECT id, job_id, trigger_type, status, started_at, finished_at FROM scheduler_runs
+		WHERE id IN (SELECT MAX(id) FROM scheduler_runs GROUP BY job_id)`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int64]Run)
+	for rows.Next() {
+		var r Run
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.JobID, &r.Trigger, &r.Status, &r.StartedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		if finishedAt.Valid {
+			r.FinishedAt = &finishedAt.Time
+		}
+		result[r.JobID] = r
+	}
+	return result, rows.Err()
+}
+
+// LoadRecordStates 读取任务已推送记录的状态(源记录ID -> 状态)
+func (s *Store) LoadRecordStates(jobID int64) (map[string]RecordState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query("SELECT record_id, platform_id, hash FROM scheduler_records WHERE job_id = ?", jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[string]RecordState)
+	for rows.Next() {
+		var st RecordState
+		if err := rows.Scan(&st.RecordID, &st.PlatformID, &st.Hash); err != nil {
+			return nil, err
+		}
+		states[st.RecordID] = st
+	}
+	return states, rows.Err()
+}
+
+// SaveRecordStates 保存推送成功的记录状态
+func (s *Store) SaveRecordStates(jobID int64, states []RecordState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO scheduler_records (job_id, record_id, platform_id, hash) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, st := range states {
+		if _, err := stmt.Exec(jobID, st.RecordID, st.PlatformID, st.Hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteRecordStates 删除已从目标表格删除的记录状态
+func (s *Store) DeleteRecordStates(jobID int64, recordIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM scheduler_records WHERE job_id = ? AND record_id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range recordIDs {
+		if _, err := stmt.Exec(jobID, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// 二进制列处理方式
+const (
+	BinaryModeSkip   = "skip"   // 不同步该列
+	BinaryModeHex    = "hex"    // 十六进制文本
+	BinaryModeBase64 = "base64" // Base64文本
+	BinaryModeSize   = "size"   // 仅输出大小
+	BinaryModeMIME   = "mime"   // 输出探测到的MIME类型和大小
+)
+
+// 单元格文本默认最大字符数
+const defaultMaxTextLength = 10000
+
+// 二进制列类型
+var binaryTypes = map[string]bool{
+	"binary":     true,
+	"varbinary":  true,
+	"tinyblob":   true,
+	"blob":       true,
+	"mediumblob": true,
+	"longblob":   true,
+}
+
+// isBinaryField 判断字段是否来自二进制列
+func isBinaryField(field models.Field) bool {
+	return binaryTypes[baseColumnType(field.SourceType)]
+}
+
+// validBinaryMode 校验二进制处理方式
+func validBinaryMode(mode string) bool {
+	switch mode {
+	case "", BinaryModeSkip, BinaryModeHex, BinaryModeBase64, BinaryModeSize, BinaryModeMIME:
+		return true
+	}
+	return false
+}
+
+// removeBinaryFields 移除二进制列(skip 模式)，记录ID键列保留
+func removeBinaryFields(fields []models.Field) []models.Field {
+	result := make([]models.Field, 0, len(fields))
+	for _, f := range fields {
+		if f.IsPrimary || !isBinaryField(f) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// convertBinary 按配置的方式将二进制值转换为文本
+func (c *valueConverter) convertBinary(data []byte) interface{} {
+	switch c.binaryMode {
+	case BinaryModeSkip, BinaryModeHex:
+		// skip 模式下只有键列会被保留，按十六进制输出以保证可读且无损
+		return c.limitText("0x" + strings.ToUpper(hex.EncodeToString(data)))
+	case BinaryModeBase64:
+		return c.limitText(base64.StdEncoding.EncodeToString(data))
+	case BinaryModeMIME:
+		return fmt.Sprintf("[%s, %s]", http.DetectContentType(data), formatByteSize(len(data)))
+	default:
+		return fmt.Sprintf("[二进制数据, %s]", formatByteSize(len(data)))
+	}
+}
+
+// limitText 确保文本为合法UTF-8，并按最大字符数截断
+func (c *valueConverter) limitText(text string) string {
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "\uFFFD")
+	}
+	if c.maxTextLength <= 0 || len(text) <= c.maxTextLength {
+		return text
+	}
+
+	length := utf8.RuneCountInString(text)
+	if length <= c.maxTextLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:c.maxTextLength]) + fmt.Sprintf("…(已截断，原长度 %d 字符)", length)
+}
+
+// formatByteSize 格式化字节大小
+func formatByteSize(size int) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
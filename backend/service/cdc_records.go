@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"mysql-sync-plugin/cdc"
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/syncstate"
+	"strconv"
+)
+
+// CDC同步在水位表中使用的列名标记，水位值为变更日志序号
+const cdcWatermarkColumn = "@binlog"
+
+// changePage 从变更日志读取的一页数据
+type changePage struct {
+	records   []models.Record
+	deleted   []models.Record // 本页中删除的记录(删除前的行)
+	consumed  int             // 本页读取的变更数(含删除)
+	remaining int             // 本页开始时范围内剩余的变更数
+	hasMore   bool            // 范围内是否还有未读取的变更
+	window    *WatermarkRange // 推进到本页末尾后的范围
+}
+
+// resolveChangeRange 确定本次CDC同步的变更序号范围
+// 没有已保存的序号时 Low 为nil，本次按全量读取表数据
+func (s *MySQLService) resolveChangeRange(source *cdc.Source, table, targetKey string) (*WatermarkRange, error) {
+	var r WatermarkRange
+
+	saved, err := syncstate.GetStore().GetWatermark(targetKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取同步水位失败: %w", err)
+	}
+	if saved != nil && saved.Column == cdcWatermarkColumn {
+		r.Low = &saved.Value
+	}
+
+	seq, err := cdc.GetStore().MaxSeq(source.ID, table)
+	if err != nil {
+		return nil, fmt.Errorf("读取变更日志失败: %w", err)
+	}
+	high := strconv.FormatInt(seq, 10)
+	r.High = &high
+
+	return &r, nil
+}
+
+// getChangeRecords 按序号读取 (Low, High] 范围内的变更并转换为记录
+// 每页读取后将 Low 推进到本页最后一条变更，避免读取期间序号变化导致分页错位
+func (s *MySQLService) getChangeRecords(source *cdc.Source, table string, fields []models.Field, conv *valueConverter, r *WatermarkRange, limit int) (*changePage, error) {
+	low, err := strconv.ParseInt(*r.Low, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的变更序号: %s", *r.Low)
+	}
+	high := low
+	if r.High != nil {
+		if high, err = strconv.ParseInt(*r.High, 10, 64); err != nil {
+			return nil, fmt.Errorf("无效的变更序号: %s", *r.High)
+		}
+	}
+
+	store := cdc.GetStore()
+	remaining, err := store.CountChanges(source.ID, table, low, high)
+	if err != nil {
+		return nil, fmt.Errorf("统计变更失败: %w", err)
+	}
+	changes, err := store.ListChanges(source.ID, table, low, high, limit)
+	if err != nil {
+		return nil, fmt.Errorf("读取变更失败: %w", err)
+	}
+
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Column
+	}
+	builder := newRecordBuilder(columns, fields, conv)
+
+	page := &changePage{
+		consumed:  len(changes),
+		remaining: remaining,
+		hasMore:   remaining > len(changes),
+		window:    r,
+	}
+	for _, c := range changes {
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			values[i] = c.Row[col].Interface()
+		}
+		if c.Op == cdc.OpDelete {
+			page.deleted = append(page.deleted, builder.build(values))
+			continue
+		}
+		page.records = append(page.records, builder.build(values))
+	}
+
+	if len(changes) > 0 {
+		last := strconv.FormatInt(changes[len(changes)-1].Seq, 10)
+		page.window = &WatermarkRange{Low: &last, High: r.High}
+	}
+
+	return page, nil
+}
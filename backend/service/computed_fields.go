@@ -0,0 +1,165 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"mysql-sync-plugin/models"
+	"sort"
+)
+
+// computedSourceType 计算字段的源类型标记
+const computedSourceType = "computed"
+
+// computedField 已编译的计算字段
+type computedField struct {
+	field models.Field
+	expr  exprNode
+}
+
+// compileComputedFields 编译计算字段表达式，fields 为同步的源字段(已应用类型覆盖)
+// 表达式可引用源列和排在前面的计算字段
+func (s *MySQLService) compileComputedFields(defs []models.ComputedField, fields []models.Field) ([]computedField, error) {
+	if len(defs) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]bool)
+	for _, f := range fields {
+		columns[f.Column] = true
+	}
+
+	result := make([]computedField, 0, len(defs))
+	for _, d := range defs {
+		if d.Name == "" {
+			return nil, fmt.Errorf("计算字段名称不能为空")
+		}
+		if columns[d.Name] {
+			return nil, fmt.Errorf("计算字段 %s 与已有字段重名", d.Name)
+		}
+		o := models.FieldOverride{
+			MysqlField:   d.Name,
+			Type:         defaultString(d.Type, "text"),
+			Formatter:    d.Formatter,
+			CurrencyCode: d.CurrencyCode,
+			DateFormat:   d.DateFormat,
+			Min:          d.Min,
+			Max:          d.Max,
+		}
+		if !overridableTypes[o.Type] {
+			return nil, fmt.Errorf("计算字段 %s 的类型 %s 不支持", d.Name, o.Type)
+		}
+		// date 为 dateTime 的别名
+		if o.Type == "date" {
+			o.Type = "dateTime"
+		}
+
+		expr, err := parseExpr(d.Expression, columns)
+		if err != nil {
+			return nil, fmt.Errorf("计算字段 %s 的表达式有误: %w", d.Name, err)
+		}
+
+		field := models.Field{
+			ID:          "fid_" + d.Name,
+			Name:        d.Name,
+			Type:        o.Type,
+			Description: "计算字段: " + d.Expression,
+			Column:      d.Name,
+			SourceType:  computedSourceType,
+			Precision:   -1,
+			Scale:       -1,
+		}
+		field.Property = s.getOverrideProperty(field, o)
+		result = append(result, computedField{field: field, expr: expr})
+		columns[d.Name] = true
+	}
+	return result, nil
+}
+
+// computedFieldList 计算字段的字段定义
+func computedFieldList(computed []computedField) []models.Field {
+	fields := make([]models.Field, len(computed))
+	for i, c := range computed {
+		fields[i] = c.field
+	}
+	return fields
+}
+
+// evalComputed 在已转换的记录上依次计算各计算字段
+func (c *valueConverter) evalComputed(record *models.Record) {
+	env := &exprEnv{fields: record.Fields, location: c.location}
+	for _, cf := range c.computed {
+		result, err := cf.expr.eval(env)
+		if err != nil {
+			c.computedErrors.add(cf.field.Name, err.Error())
+			if c.stats != nil {
+				c.stats.observeError(cf.field.Column, err)
+			}
+			record.Fields[cf.field.ID] = nil
+			continue
+		}
+
+		value := c.convertComputed(result, cf.field)
+		if c.stats != nil {
+			c.stats.observe(cf.field.Column, result, value)
+		}
+		record.Fields[cf.field.ID] = value
+	}
+}
+
+// convertComputed 将表达式结果转换为字段类型对应的值
+func (c *valueConverter) convertComputed(result interface{}, field models.Field) interface{} {
+	switch v := result.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		// 映射字面量只能作为 lookup 的参数
+		if field.Type != "hyperlink" {
+			return nil
+		}
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		// 整数值按整数转换，便于作为时间戳或精确数值处理
+		if v == math.Trunc(v) && math.Abs(v) <= maxSafeInteger {
+			result = int64(v)
+		}
+	}
+
+	if field.Type == "text" {
+		return c.limitText(exprText(result))
+	}
+	return c.convert(result, field)
+}
+
+// computedErrors 计算字段求值失败统计
+type computedErrors struct {
+	counts  map[string]int    // 字段名 -> 失败行数
+	samples map[string]string // 字段名 -> 示例错误
+}
+
+func (w *computedErrors) add(field, sample string) {
+	if w.counts == nil {
+		w.counts = make(map[string]int)
+		w.samples = make(map[string]string)
+	}
+	w.counts[field]++
+	if _, ok := w.samples[field]; !ok {
+		w.samples[field] = sample
+	}
+}
+
+// messages 生成告警文本
+func (w *computedErrors) messages() []string {
+	var names []string
+	for name := range w.counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []string
+	for _, name := range names {
+		result = append(result, fmt.Sprintf("计算字段 %s 有 %d 行计算失败，已写入空值(示例: %s)", name, w.counts[name], w.samples[name]))
+	}
+	return result
+}
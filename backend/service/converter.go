@@ -0,0 +1,325 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueConverter 值转换器，携带单次请求内与连接相关的转换配置
+type valueConverter struct {
+	location       *time.Location       // 源库时区，用于解释 DATE/DATETIME 的墙上时间
+	binaryMode     string               // 二进制列处理方式
+	maxTextLength  int                  // 单元格文本最大字符数
+	numberWarnings numberWarnings       // 超出安全精度的数值告警
+	stats          *previewStats        // 预览时的字段值统计，同步时为nil
+	computed       []computedField      // 计算字段，在源列转换后依次计算
+	computedErrors computedErrors       // 计算字段求值失败告警
+	valueMaps      map[string]*valueMap // 源列名 -> 值映射，转换前替换为显示值
+}
+
+// newValueConverter 根据配置创建值转换器
+func newValueConverter(config *models.MySQLConfig) (*valueConverter, error) {
+	loc, err := sourceLocation(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validBinaryMode(config.BinaryMode) {
+		return nil, fmt.Errorf("不支持的二进制列处理方式: %s", config.BinaryMode)
+	}
+
+	maxTextLength := config.MaxTextLength
+	if maxTextLength <= 0 {
+		maxTextLength = defaultMaxTextLength
+	}
+
+	return &valueConverter{
+		location:      loc,
+		binaryMode:    config.BinaryMode,
+		maxTextLength: maxTextLength,
+	}, nil
+}
+
+// sourceLocation 解析配置的源库时区，未配置时为服务器本地时区
+// 支持 IANA 名称(如 Asia/Shanghai)和固定偏移(如 +08:00，用于源库未加载时区表的情况)
+func sourceLocation(config *models.MySQLConfig) (*time.Location, error) {
+	if config.TimeZone == "" {
+		return time.Local, nil
+	}
+	if m := offsetZonePattern.FindStringSubmatch(config.TimeZone); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		offset := hours*3600 + minutes*60
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("无效的时区 %s", config.TimeZone)
+		}
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(config.TimeZone, offset), nil
+	}
+	if !zoneNamePattern.MatchString(config.TimeZone) {
+		return nil, fmt.Errorf("无效的时区 %s", config.TimeZone)
+	}
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区 %s: %w", config.TimeZone, err)
+	}
+	return loc, nil
+}
+
+var (
+	offsetZonePattern = regexp.MustCompile(`^([+-])(\d{2}):(\d{2})$`)
+	zoneNamePattern   = regexp.MustCompile(`^[A-Za-z0-9_+/-]+$`)
+)
+
+// convert 根据字段定义转换值
+func (c *valueConverter) convert(value interface{}, field models.Field) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch field.Type {
+	case "number", "currency", "progress", "rating":
+		return c.toNumber(value, field)
+	case "dateTime":
+		return c.toTimestamp(value)
+	case "hyperlink":
+		link := c.limitText(toText(value))
+		if link == "" {
+			return nil
+		}
+		return map[string]interface{}{
+			"text": link,
+			"link": link,
+		}
+	case "phone":
+		return c.limitText(toText(value))
+	case "checkbox":
+		return toBool(value)
+	case "multiSelect":
+		return toStringList(value)
+	case "location":
+		if b, ok := value.([]byte); ok {
+			return geometryToLocation(b)
+		}
+		return fmt.Sprintf("%v", value)
+	}
+
+	if b, ok := value.([]byte); ok {
+		// 二进制列按配置方式输出，避免非法UTF-8破坏整页JSON
+		if isBinaryField(field) {
+			return c.convertBinary(b)
+		}
+		// 空间类型以WKT文本输出
+		if spatialTypes[baseColumnType(field.SourceType)] {
+			return geometryToWKT(b)
+		}
+	}
+
+	// 整数以精确文本输出，避免超出安全精度
+	if text, ok := toExactText(value); ok {
+		return text
+	}
+
+	// 非数字类型，转换为字符串
+	switch v := value.(type) {
+	case []byte:
+		return c.limitText(string(v))
+	case string:
+		return c.limitText(v)
+	case float64:
+		return v
+	case time.Time:
+		return c.formatTime(v, baseColumnType(field.SourceType))
+	default:
+		return c.limitText(fmt.Sprintf("%v", v))
+	}
+}
+
+// 文本日期的解析格式
+var dateLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// toTimestamp 将日期值转换为毫秒时间戳，零日期返回nil
+func (c *valueConverter) toTimestamp(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return c.toInstant(v).UnixMilli()
+	case int64:
+		// 数字按Unix时间戳解释：小于1e11视为秒，否则视为毫秒
+		if v < 1e11 && v > -1e11 {
+			return v * 1000
+		}
+		return v
+	case []byte:
+		return c.parseTimestamp(string(v))
+	case string:
+		return c.parseTimestamp(v)
+	default:
+		return nil
+	}
+}
+
+// parseTimestamp 解析文本日期为毫秒时间戳
+func (c *valueConverter) parseTimestamp(str string) interface{} {
+	str = strings.TrimSpace(str)
+	if str == "" || strings.HasPrefix(str, "0000-00-00") {
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+		return t.UnixMilli()
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, str, c.location); err == nil {
+			return t.UnixMilli()
+		}
+	}
+	if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return c.toTimestamp(n)
+	}
+	return nil
+}
+
+// toInstant 将驱动返回的时间转换为真实时间点
+// 会话时区与源库时区一致(见 connectDB)：DATE/DATETIME 和 TIMESTAMP 返回的都是源库时区的墙上时间
+func (c *valueConverter) toInstant(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), c.location)
+}
+
+// formatTime 将时间格式化为源库时区的文本
+func (c *valueConverter) formatTime(t time.Time, sourceType string) string {
+	if t.IsZero() {
+		return ""
+	}
+	t = c.toInstant(t).In(c.location)
+	if sourceType == "date" {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// Warnings 返回转换过程中产生的告警
+func (c *valueConverter) Warnings() []string {
+	return append(c.numberWarnings.messages(), c.computedErrors.messages()...)
+}
+
+// scanRecords 扫描结果集并转换为记录
+func (s *MySQLService) scanRecords(rows *sql.Rows, fields []models.Field, conv *valueConverter) ([]models.Record, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newRecordBuilder(columns, fields, conv)
+
+	var records []models.Record
+	for rows.Next() {
+		// 创建扫描目标
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+
+		records = append(records, builder.build(values))
+	}
+
+	return records, rows.Err()
+}
+
+// recordBuilder 将一行原始值转换为记录
+type recordBuilder struct {
+	columns    []string
+	fieldMap   map[string]models.Field
+	keyIndexes []int // 记录ID键列在行中的位置
+	conv       *valueConverter
+}
+
+// newRecordBuilder 根据列顺序和字段定义创建记录构建器
+func newRecordBuilder(columns []string, fields []models.Field, conv *valueConverter) *recordBuilder {
+	// 构建列名到字段的映射
+	fieldMap := make(map[string]models.Field)
+	for _, f := range fields {
+		fieldMap[f.Column] = f
+	}
+
+	// 记录ID键列在结果集中的位置
+	var keyIndexes []int
+	for _, key := range keyColumns(fields) {
+		for i, col := range columns {
+			if col == key {
+				keyIndexes = append(keyIndexes, i)
+				break
+			}
+		}
+	}
+
+	return &recordBuilder{
+		columns:    columns,
+		fieldMap:   fieldMap,
+		keyIndexes: keyIndexes,
+		conv:       conv,
+	}
+}
+
+// build 构建记录
+func (b *recordBuilder) build(values []interface{}) models.Record {
+	record := models.Record{
+		Fields: make(map[string]interface{}),
+	}
+
+	// 由键列原始值编码记录ID
+	keyValues := make([]interface{}, len(b.keyIndexes))
+	for k, i := range b.keyIndexes {
+		keyValues[k] = values[i]
+	}
+	record.ID = encodeRecordID(keyValues)
+	if b.conv.stats != nil {
+		b.conv.stats.rows++
+	}
+
+	for i, col := range b.columns {
+		// 跳过未纳入字段定义的列(如 skip 模式下的二进制列)
+		field, ok := b.fieldMap[col]
+		if !ok {
+			continue
+		}
+
+		// 配置了值映射的列先将代码替换为显示值
+		raw := values[i]
+		if vm, ok := b.conv.valueMaps[col]; ok {
+			raw = vm.lookup(raw)
+		}
+
+		// 根据字段类型正确转换数据
+		value := b.conv.convert(raw, field)
+		if b.conv.stats != nil {
+			b.conv.stats.observe(col, values[i], value)
+		}
+		record.Fields[fmt.Sprintf("fid_%s", col)] = value
+	}
+
+	if len(b.conv.computed) > 0 {
+		b.conv.evalComputed(&record)
+	}
+
+	return record
+}
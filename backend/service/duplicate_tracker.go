@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"hash/fnv"
+	"mysql-sync-plugin/models"
+	"sync"
+	"time"
+)
+
+const (
+	// 同步会话闲置超过该时长后丢弃其记录ID集合
+	duplicateTrackerTTL = 6 * time.Hour
+	// 单次同步最多跟踪的记录ID数量，超出后仅做页内检测
+	duplicateTrackerMaxIDs = 2000000
+)
+
+// duplicateTracker 跨分页的重复记录ID检测
+type duplicateTracker struct {
+	mu       sync.Mutex
+	sessions map[string]*trackedSync
+}
+
+// trackedSync 单次同步已出现的记录ID及其首次出现的分页(均以64位哈希保存以节省内存)
+type trackedSync struct {
+	ids      map[uint64]uint64
+	lastUsed time.Time
+	overflow bool
+}
+
+func newDuplicateTracker() *duplicateTracker {
+	return &duplicateTracker{
+		sessions: make(map[string]*trackedSync),
+	}
+}
+
+// filter 过滤重复ID的记录，返回保留的记录和告警
+// page 标识分页(本页的分页游标)，平台超时重试同一分页时，本页首次出现的记录照常返回，
+// 只有在其他分页出现过的记录ID才视为重复
+func (t *duplicateTracker) filter(syncID, page string, records []models.Record) ([]models.Record, []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cleanup()
+
+	session := t.sessions[syncID]
+	if session == nil {
+		session = &trackedSync{ids: make(map[uint64]uint64)}
+		t.sessions[syncID] = session
+	}
+	session.lastUsed = time.Now()
+
+	pageHash := hashRecordID(page)
+	var warnings []string
+	pageIDs := make(map[string]struct{}, len(records))
+	kept := records[:0]
+	duplicates := 0
+	sample := ""
+
+	for _, r := range records {
+		h := hashRecordID(r.ID)
+		_, inPage := pageIDs[r.ID]
+		seenPage, inSync := session.ids[h]
+		if inPage || (inSync && seenPage != pageHash) {
+			duplicates++
+			if sample == "" {
+				sample = r.ID
+			}
+			continue
+		}
+
+		pageIDs[r.ID] = struct{}{}
+		if !session.overflow && !inSync {
+			if len(session.ids) >= duplicateTrackerMaxIDs {
+				session.overflow = true
+				warnings = append(warnings, fmt.Sprintf("本次同步记录数超过 %d，后续仅检测页内重复记录ID", duplicateTrackerMaxIDs))
+			} else {
+				session.ids[h] = pageHash
+			}
+		}
+		kept = append(kept, r)
+	}
+
+	if duplicates > 0 {
+		warnings = append(warnings, fmt.Sprintf("检测到 %d 条重复的记录ID(示例: %s)，已丢弃重复记录，请检查主键配置", duplicates, sample))
+	}
+
+	return kept, warnings
+}
+
+// finish 同步结束后释放会话
+func (t *duplicateTracker) finish(syncID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sessions, syncID)
+}
+
+// cleanup 清理闲置的会话(调用方需持有锁)
+func (t *duplicateTracker) cleanup() {
+	now := time.Now()
+	for id, s := range t.sessions {
+		if now.Sub(s.lastUsed) > duplicateTrackerTTL {
+			delete(t.sessions, id)
+		}
+	}
+}
+
+func hashRecordID(id string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return h.Sum64()
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"mysql-sync-plugin/models"
+)
+
+func trackerRecords(ids ...string) []models.Record {
+	records := make([]models.Record, len(ids))
+	for i, id := range ids {
+		records[i] = models.Record{ID: id}
+	}
+	return records
+}
+
+func trackerIDs(records []models.Record) []string {
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestDuplicateTrackerFilter(t *testing.T) {
+	type page struct {
+		token    string
+		ids      []string
+		want     []string
+		warnings int
+	}
+	tests := []struct {
+		name  string
+		pages []page
+	}{
+		{"页内重复", []page{
+			{"", []string{"a", "b", "a"}, []string{"a", "b"}, 1},
+		}},
+		{"跨页重复", []page{
+			{"", []string{"a", "b"}, []string{"a", "b"}, 0},
+			{"p2", []string{"b", "c"}, []string{"c"}, 1},
+		}},
+		{"重试首页", []page{
+			{"", []string{"a", "b"}, []string{"a", "b"}, 0},
+			{"", []string{"a", "b"}, []string{"a", "b"}, 0},
+			{"p2", []string{"c"}, []string{"c"}, 0},
+		}},
+		{"重试后续分页", []page{
+			{"", []string{"a", "b"}, []string{"a", "b"}, 0},
+			{"p2", []string{"c", "d"}, []string{"c", "d"}, 0},
+			{"p2", []string{"c", "d"}, []string{"c", "d"}, 0},
+			{"p3", []string{"d", "e"}, []string{"e"}, 1},
+		}},
+		{"重试分页仍丢弃其他分页的记录", []page{
+			{"", []string{"a"}, []string{"a"}, 0},
+			{"p2", []string{"a", "b"}, []string{"b"}, 1},
+			{"p2", []string{"a", "b"}, []string{"b"}, 1},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newDuplicateTracker()
+			for i, p := range tt.pages {
+				kept, warnings := tracker.filter("sync-1", p.token, trackerRecords(p.ids...))
+				if got := trackerIDs(kept); !reflect.DeepEqual(got, p.want) {
+					t.Errorf("第 %d 页(%q) 保留 %v, want %v", i+1, p.token, got, p.want)
+				}
+				if len(warnings) != p.warnings {
+					t.Errorf("第 %d 页(%q) 告警 %v, want %d 条", i+1, p.token, warnings, p.warnings)
+				}
+			}
+		})
+	}
+}
+
+func TestDuplicateTrackerSessions(t *testing.T) {
+	tracker := newDuplicateTracker()
+	tracker.filter("sync-1", "", trackerRecords("a"))
+
+	// 不同同步会话互不影响
+	if kept, _ := tracker.filter("sync-2", "p2", trackerRecords("a")); len(kept) != 1 {
+		t.Errorf("其他会话的记录被丢弃: %v", trackerIDs(kept))
+	}
+
+	// 会话结束后重新开始
+	tracker.finish("sync-1")
+	if kept, _ := tracker.filter("sync-1", "p2", trackerRecords("a")); len(kept) != 1 {
+		t.Errorf("会话结束后记录被丢弃: %v", trackerIDs(kept))
+	}
+}
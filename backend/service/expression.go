@@ -0,0 +1,901 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 计算字段表达式
+//
+// 语法:
+//   字面量    123、1.5、"文本"、'文本'、true、false、null、{"0": "待处理", "1": "已完成"}
+//   列引用    列名直接引用转换后的值，含特殊字符的列名用反引号: `order amount`
+//   运算符    + - * / %(数值)、&(文本拼接)、== != < <= > >=、&& || !、条件 ? 值1 : 值2
+//   函数      见 exprFunctions
+//
+// 表达式只能读取当前记录的值，不能访问数据库或外部资源。
+
+// exprEnv 表达式求值环境
+type exprEnv struct {
+	fields   map[string]interface{} // 记录字段(fid_列名 -> 转换后的值)
+	location *time.Location         // 日期格式化使用的时区
+}
+
+// exprNode 表达式语法树节点
+type exprNode interface {
+	eval(env *exprEnv) (interface{}, error)
+}
+
+type literalNode struct{ value interface{} }
+
+type columnNode struct{ name string }
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+type condNode struct{ cond, then, otherwise exprNode }
+
+type mapNode struct {
+	keys   []string
+	values []exprNode
+}
+
+type callNode struct {
+	name string
+	fn   *exprFunction
+	args []exprNode
+	re   *regexp.Regexp // 正则函数预编译的模式
+}
+
+// exprFunction 表达式函数定义
+type exprFunction struct {
+	minArgs, maxArgs int // maxArgs 为-1表示不限
+	call             func(env *exprEnv, n *callNode, args []interface{}) (interface{}, error)
+}
+
+// exprFunctions 支持的函数
+var exprFunctions map[string]*exprFunction
+
+func init() {
+	exprFunctions = map[string]*exprFunction{
+		// if(条件, 值1, 值2)
+		"if": {2, 3, nil},
+		// coalesce(值1, 值2, ...) 返回第一个非空值
+		"coalesce": {1, -1, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			for _, a := range args {
+				if a != nil && a != "" {
+					return a, nil
+				}
+			}
+			return nil, nil
+		}},
+		"concat": {1, -1, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			var b strings.Builder
+			for _, a := range args {
+				b.WriteString(exprText(a))
+			}
+			return b.String(), nil
+		}},
+		"upper": {1, 1, textFunc(strings.ToUpper)},
+		"lower": {1, 1, textFunc(strings.ToLower)},
+		"trim":  {1, 1, textFunc(strings.TrimSpace)},
+		"len": {1, 1, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			return float64(utf8.RuneCountInString(exprText(args[0]))), nil
+		}},
+		// substr(文本, 起始位置(从1开始), [长度])
+		"substr": {2, 3, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			runes := []rune(exprText(args[0]))
+			start, err := exprInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+			if start < 1 {
+				start = 1
+			}
+			if start > len(runes) {
+				return "", nil
+			}
+			end := len(runes)
+			if len(args) == 3 {
+				n, err := exprInt(args[2])
+				if err != nil {
+					return nil, err
+				}
+				// 先将长度限制在剩余字符数内再相加，避免溢出
+				if n < 0 {
+					n = 0
+				}
+				if n < end-(start-1) {
+					end = start - 1 + n
+				}
+			}
+			return string(runes[start-1 : end]), nil
+		}},
+		"replace": {3, 3, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			return strings.ReplaceAll(exprText(args[0]), exprText(args[1]), exprText(args[2])), nil
+		}},
+		// round(数值, [小数位数])
+		"round": {1, 2, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			if args[0] == nil {
+				return nil, nil
+			}
+			x, err := exprNumber(args[0])
+			if err != nil {
+				return nil, err
+			}
+			digits := 0
+			if len(args) == 2 {
+				if digits, err = exprInt(args[1]); err != nil {
+					return nil, err
+				}
+			}
+			// float64 约有15位有效数字，更多小数位没有意义且会使 10^digits 溢出
+			if digits > 15 {
+				digits = 15
+			} else if digits < -15 {
+				digits = -15
+			}
+			p := math.Pow(10, float64(digits))
+			return math.Round(x*p) / p, nil
+		}},
+		"abs":   {1, 1, numberFunc(math.Abs)},
+		"floor": {1, 1, numberFunc(math.Floor)},
+		"ceil":  {1, 1, numberFunc(math.Ceil)},
+		"number": {1, 1, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			if args[0] == nil || args[0] == "" {
+				return nil, nil
+			}
+			return exprNumber(args[0])
+		}},
+		"text": {1, 1, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			return exprText(args[0]), nil
+		}},
+		// format_date(日期, "YYYY-MM-DD HH:mm:ss")，日期为毫秒时间戳或日期文本
+		"format_date": {2, 2, func(env *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			t, ok, err := exprTime(args[0], env.location)
+			if err != nil || !ok {
+				return nil, err
+			}
+			return t.In(env.location).Format(dateLayout(exprText(args[1]))), nil
+		}},
+		// lookup(映射, 键, [默认值])，映射为 {"键": 值} 字面量
+		"lookup": {2, 3, func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+			m, ok := args[0].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("lookup 的第一个参数必须是映射")
+			}
+			if v, ok := m[exprText(args[1])]; ok {
+				return v, nil
+			}
+			if len(args) == 3 {
+				return args[2], nil
+			}
+			return nil, nil
+		}},
+		// regex_extract(文本, "模式", [分组])，未匹配时返回空
+		"regex_extract": {2, 3, func(_ *exprEnv, n *callNode, args []interface{}) (interface{}, error) {
+			group := 0
+			if len(args) == 3 {
+				var err error
+				if group, err = exprInt(args[2]); err != nil {
+					return nil, err
+				}
+			}
+			match := n.re.FindStringSubmatch(exprText(args[0]))
+			if match == nil || group < 0 || group >= len(match) {
+				return nil, nil
+			}
+			return match[group], nil
+		}},
+		// regex_match(文本, "模式")
+		"regex_match": {2, 2, func(_ *exprEnv, n *callNode, args []interface{}) (interface{}, error) {
+			return n.re.MatchString(exprText(args[0])), nil
+		}},
+	}
+}
+
+func textFunc(f func(string) string) func(*exprEnv, *callNode, []interface{}) (interface{}, error) {
+	return func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return f(exprText(args[0])), nil
+	}
+}
+
+func numberFunc(f func(float64) float64) func(*exprEnv, *callNode, []interface{}) (interface{}, error) {
+	return func(_ *exprEnv, _ *callNode, args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		x, err := exprNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(x), nil
+	}
+}
+
+// ---- 词法分析 ----
+
+type exprToken struct {
+	kind string // num/str/ident/op/eof
+	text string
+	pos  int
+}
+
+func tokenizeExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{"num", src[start:i], start})
+		case r == '"' || r == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(src) {
+				c := src[i]
+				if c == '\\' && i+1 < len(src) {
+					switch src[i+1] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(src[i+1])
+					}
+					i += 2
+					continue
+				}
+				if c == byte(r) {
+					i++
+					closed = true
+					break
+				}
+				b.WriteByte(c)
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("位置 %d 的字符串未结束", start+1)
+			}
+			tokens = append(tokens, exprToken{"str", b.String(), start})
+		case r == '`':
+			start := i
+			end := strings.IndexByte(src[i+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("位置 %d 的列名未结束", start+1)
+			}
+			tokens = append(tokens, exprToken{"column", src[i+1 : i+1+end], start})
+			i += end + 2
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, exprToken{"ident", src[start:i], start})
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "&", "<", ">", "!", "(", ")", ",", "{", "}", ":", "?"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("位置 %d 有无法识别的字符 %q", i+1, r)
+			}
+			tokens = append(tokens, exprToken{"op", op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{"eof", "", len(src)}), nil
+}
+
+// ---- 语法分析 ----
+
+// exprParser 递归下降解析器，columns 为可引用的列名
+type exprParser struct {
+	tokens  []exprToken
+	pos     int
+	columns map[string]bool
+}
+
+// parseExpr 解析表达式，引用的列必须在 columns 中
+func parseExpr(src string, columns map[string]bool) (exprNode, error) {
+	tokens, err := tokenizeExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, columns: columns}
+	node, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != "eof" {
+		return nil, fmt.Errorf("位置 %d 有多余的内容 %q", tok.pos+1, tok.text)
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() exprToken { return p.tokens[p.pos] }
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != "eof" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) isOp(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != "op" {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.isOp(op) {
+		tok := p.peek()
+		if tok.kind == "eof" {
+			return fmt.Errorf("表达式不完整，缺少 %s", op)
+		}
+		return fmt.Errorf("位置 %d 应为 %s", tok.pos+1, op)
+	}
+	p.next()
+	return nil
+}
+
+func (p *exprParser) parseConditional() (exprNode, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("?") {
+		return cond, nil
+	}
+	p.next()
+	then, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	return &condNode{cond, then, otherwise}, nil
+}
+
+// 二元运算符优先级，从低到高
+var exprPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"&"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(exprPrecedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(exprPrecedence[level]...) {
+		op := p.next().text
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op, left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOp("-", "!") {
+		op := p.next().text
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op, operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case "num":
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("位置 %d 的数字 %s 无效", tok.pos+1, tok.text)
+		}
+		return &literalNode{v}, nil
+	case "str":
+		return &literalNode{tok.text}, nil
+	case "column":
+		return p.column(tok)
+	case "ident":
+		switch tok.text {
+		case "true":
+			return &literalNode{true}, nil
+		case "false":
+			return &literalNode{false}, nil
+		case "null":
+			return &literalNode{nil}, nil
+		}
+		if p.isOp("(") {
+			return p.parseCall(tok)
+		}
+		return p.column(tok)
+	case "op":
+		switch tok.text {
+		case "(":
+			node, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "{":
+			return p.parseMap()
+		}
+	case "eof":
+		return nil, fmt.Errorf("表达式不完整")
+	}
+	return nil, fmt.Errorf("位置 %d 有意外的 %q", tok.pos+1, tok.text)
+}
+
+func (p *exprParser) column(tok exprToken) (exprNode, error) {
+	if !p.columns[tok.text] {
+		return nil, fmt.Errorf("列 %s 不存在", tok.text)
+	}
+	return &columnNode{tok.text}, nil
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	fn, ok := exprFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("不支持的函数 %s", name.text)
+	}
+	p.next() // (
+
+	n := &callNode{name: name.text, fn: fn}
+	if !p.isOp(")") {
+		for {
+			arg, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			n.args = append(n.args, arg)
+			if !p.isOp(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	if len(n.args) < fn.minArgs || (fn.maxArgs >= 0 && len(n.args) > fn.maxArgs) {
+		return nil, fmt.Errorf("函数 %s 的参数个数不正确", name.text)
+	}
+
+	// 正则模式必须是字符串字面量，解析时编译
+	if strings.HasPrefix(name.text, "regex_") {
+		lit, ok := n.args[1].(*literalNode)
+		pattern, isText := lit.valueText()
+		if !ok || !isText {
+			return nil, fmt.Errorf("函数 %s 的模式必须是字符串", name.text)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("函数 %s 的模式无效: %w", name.text, err)
+		}
+		n.re = re
+	}
+	return n, nil
+}
+
+func (p *exprParser) parseMap() (exprNode, error) {
+	n := &mapNode{}
+	for !p.isOp("}") {
+		tok := p.next()
+		switch tok.kind {
+		case "str", "num", "ident":
+			n.keys = append(n.keys, tok.text)
+		default:
+			return nil, fmt.Errorf("位置 %d 应为映射的键", tok.pos+1)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, value)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	return n, p.expect("}")
+}
+
+func (n *literalNode) valueText() (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	s, ok := n.value.(string)
+	return s, ok
+}
+
+// ---- 求值 ----
+
+func (n *literalNode) eval(*exprEnv) (interface{}, error) { return n.value, nil }
+
+func (n *columnNode) eval(env *exprEnv) (interface{}, error) {
+	return env.fields["fid_"+n.name], nil
+}
+
+func (n *unaryNode) eval(env *exprEnv) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !exprTruthy(v), nil
+	}
+	if v == nil {
+		return nil, nil
+	}
+	x, err := exprNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	return -x, nil
+}
+
+func (n *binaryNode) eval(env *exprEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// 逻辑运算短路求值
+	switch n.op {
+	case "&&":
+		if !exprTruthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(env)
+		return exprTruthy(right), err
+	case "||":
+		if exprTruthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(env)
+		return exprTruthy(right), err
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&":
+		return exprText(left) + exprText(right), nil
+	case "==":
+		return exprEqual(left, right), nil
+	case "!=":
+		return !exprEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		if left == nil || right == nil {
+			return false, nil
+		}
+		c := exprCompare(left, right)
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+
+	// 算术运算：任一侧为空时结果为空
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	a, err := exprNumber(left)
+	if err != nil {
+		return nil, err
+	}
+	b, err := exprNumber(right)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, nil
+		}
+		return a / b, nil
+	default:
+		if b == 0 {
+			return nil, nil
+		}
+		return math.Mod(a, b), nil
+	}
+}
+
+func (n *condNode) eval(env *exprEnv) (interface{}, error) {
+	cond, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if exprTruthy(cond) {
+		return n.then.eval(env)
+	}
+	return n.otherwise.eval(env)
+}
+
+func (n *mapNode) eval(env *exprEnv) (interface{}, error) {
+	m := make(map[string]interface{}, len(n.keys))
+	for i, key := range n.keys {
+		v, err := n.values[i].eval(env)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+func (n *callNode) eval(env *exprEnv) (interface{}, error) {
+	// if 只对选中的分支求值
+	if n.name == "if" {
+		cond, err := n.args[0].eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if exprTruthy(cond) {
+			return n.args[1].eval(env)
+		}
+		if len(n.args) == 3 {
+			return n.args[2].eval(env)
+		}
+		return nil, nil
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return n.fn.call(env, n, args)
+}
+
+// ---- 值处理 ----
+
+// exprText 将值转换为文本
+func exprText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case json.Number:
+		return val.String()
+	case bool:
+		if val {
+			return "true"
+		}
+		return "false"
+	case []string:
+		return strings.Join(val, ",")
+	case map[string]interface{}:
+		// 超链接字段取链接文本
+		if text, ok := val["text"].(string); ok {
+			return text
+		}
+		b, _ := json.Marshal(val)
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// exprNumber 将值转换为数值
+func exprNumber(v interface{}) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case int64:
+		return float64(val), nil
+	case json.Number:
+		return val.Float64()
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+	case nil:
+		return 0, nil
+	}
+	text := strings.TrimSpace(exprText(v))
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("值 %q 不是数字", text)
+	}
+	return f, nil
+}
+
+// exprInt 将值转换为整数参数，超出 int32 范围的值按边界截断，避免后续运算溢出
+func exprInt(v interface{}) (int, error) {
+	f, err := exprNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("值 %v 不是有效的整数", f)
+	}
+	return int(math.Max(math.MinInt32, math.Min(math.MaxInt32, f))), nil
+}
+
+// exprTruthy 判断值是否为真：空、false、0 和空文本为假
+func exprTruthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	case float64, int64, json.Number:
+		f, _ := exprNumber(val)
+		return f != 0
+	}
+	return true
+}
+
+// exprIsNumber 判断值是否为数值类型
+func exprIsNumber(v interface{}) bool {
+	switch v.(type) {
+	case float64, int64, json.Number:
+		return true
+	}
+	return false
+}
+
+// exprCompare 比较两个值：一侧为数值且另一侧可解析为数值时按数值比较，否则按文本比较
+func exprCompare(a, b interface{}) int {
+	if exprIsNumber(a) || exprIsNumber(b) {
+		x, errA := exprNumber(a)
+		y, errB := exprNumber(b)
+		if errA == nil && errB == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(exprText(a), exprText(b))
+}
+
+func exprEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if x, ok := a.(bool); ok {
+		return x == exprTruthy(b)
+	}
+	if y, ok := b.(bool); ok {
+		return y == exprTruthy(a)
+	}
+	return exprCompare(a, b) == 0
+}
+
+// exprTime 将毫秒时间戳或日期文本转换为时间，空值返回 ok=false
+func exprTime(v interface{}, location *time.Location) (time.Time, bool, error) {
+	switch val := v.(type) {
+	case nil:
+		return time.Time{}, false, nil
+	case float64, int64, json.Number:
+		ms, err := exprNumber(val)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return time.UnixMilli(int64(ms)), true, nil
+	}
+
+	text := strings.TrimSpace(exprText(v))
+	if text == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+		return t, true, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, text, location); err == nil {
+			return t, true, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("值 %q 不是日期", text)
+}
+
+// dateLayout 将 YYYY-MM-DD HH:mm:ss 形式的格式转换为 Go 的时间格式
+func dateLayout(format string) string {
+	return strings.NewReplacer(
+		"YYYY", "2006",
+		"MM", "01",
+		"DD", "02",
+		"HH", "15",
+		"mm", "04",
+		"ss", "05",
+	).Replace(format)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// evalTestExpr 解析并求值表达式，fields 的键为列名
+func evalTestExpr(src string, fields map[string]interface{}) (interface{}, error) {
+	columns := make(map[string]bool, len(fields))
+	values := make(map[string]interface{}, len(fields))
+	for name, v := range fields {
+		columns[name] = true
+		values["fid_"+name] = v
+	}
+	node, err := parseExpr(src, columns)
+	if err != nil {
+		return nil, err
+	}
+	return node.eval(&exprEnv{fields: values, location: time.UTC})
+}
+
+func TestExprEval(t *testing.T) {
+	fields := map[string]interface{}{
+		"price":      float64(12.5),
+		"qty":        int64(4),
+		"name":       "Widget",
+		"empty":      "",
+		"missing":    nil,
+		"flag":       true,
+		"order code": "A-1024",
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		// 运算符优先级
+		{"乘法优先于加法", "1 + 2 * 3", float64(7)},
+		{"括号改变优先级", "(1 + 2) * 3", float64(9)},
+		{"减法左结合", "10 - 4 - 3", float64(3)},
+		{"除法左结合", "24 / 4 / 3", float64(2)},
+		{"取余", "17 % 5", float64(2)},
+		{"一元负号", "-2 * 3", float64(-6)},
+		{"算术优先于拼接", `"n=" & 1 + 2`, "n=3"},
+		{"拼接优先于比较", `"a" & "b" == "ab"`, true},
+		{"比较优先于相等", "1 < 2 == true", true},
+		{"与优先于或", "true || false && false", true},
+		{"取反优先于与", "!false && true", true},
+		{"条件运算最低", "1 + 1 == 2 ? \"yes\" : \"no\"", "yes"},
+		{"条件运算右结合", `false ? 1 : true ? 2 : 3`, float64(2)},
+
+		// 列引用
+		{"列参与运算", "price * qty", float64(50)},
+		{"反引号列名", "`order code`", "A-1024"},
+		{"文本数字比较", `"10" > 9`, true},
+
+		// 空值传播
+		{"空值加法为空", "missing + 1", nil},
+		{"空值取负为空", "-missing", nil},
+		{"除以零为空", "1 / 0", nil},
+		{"对零取余为空", "1 % 0", nil},
+		{"空值比较为假", "missing < 1", false},
+		{"空值相等", "missing == null", true},
+		{"空值与文本不等", `missing == ""`, false},
+		{"空值拼接为空文本", `missing & "x"`, "x"},
+		{"空值取反为真", "!missing", true},
+		{"文本函数空值为空", "upper(missing)", nil},
+		{"coalesce跳过空值和空文本", `coalesce(missing, empty, name)`, "Widget"},
+
+		// 短路求值：右侧求值会出错，短路时不求值
+		{"与短路", `false && number("x") > 0`, false},
+		{"或短路", `true || number("x") > 0`, true},
+		{"条件只求选中分支", `flag ? 1 : number("x")`, float64(1)},
+		{"if只求选中分支", `if(flag, "ok", number("x"))`, "ok"},
+		{"if缺省分支为空", `if(false, 1)`, nil},
+
+		// 函数
+		{"round", "round(2.345, 2)", 2.35},
+		{"round超大位数", "round(2.5, 1000000000)", 2.5},
+		{"lookup", `lookup({"1": "待处理", "2": "已完成"}, 2)`, "已完成"},
+		{"lookup默认值", `lookup({"1": "待处理"}, 3, "未知")`, "未知"},
+		{"regex_extract分组", `regex_extract(name, "W(id)", 1)`, "id"},
+		{"regex_extract未匹配", `regex_extract(name, "^x")`, nil},
+		{"regex_match", `regex_match(` + "`order code`" + `, "^[A-Z]-\\d+$")`, true},
+		{"format_date", `format_date(0, "YYYY-MM-DD HH:mm")`, "1970-01-01 00:00"},
+
+		// substr 边界
+		{"substr基本", `substr("abcdef", 2, 3)`, "bcd"},
+		{"substr无长度", `substr("abcdef", 4)`, "def"},
+		{"substr起始小于1", `substr("abcdef", -5, 2)`, "ab"},
+		{"substr起始超出", `substr("abcdef", 7)`, ""},
+		{"substr长度为负", `substr("abcdef", 2, -1)`, ""},
+		{"substr长度超出", `substr("abcdef", 5, 100)`, "ef"},
+		{"substr超大起始和长度", `substr("abcdef", 2000, 9200000000000000000)`, ""},
+		{"substr超大长度", `substr("abcdef", 2, 9200000000000000000)`, "bcdef"},
+		{"substr超大负长度", `substr("abcdef", 2, -9200000000000000000)`, ""},
+		{"substr多字节", `substr("数据同步", 2, 2)`, "据同"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalTestExpr(tt.src, fields)
+			if err != nil {
+				t.Fatalf("%s: 求值失败: %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	fields := map[string]interface{}{"name": "Widget"}
+
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		// 解析错误
+		{"未知列", "price + 1", "列 price 不存在"},
+		{"未知函数", "eval(name)", "不支持的函数"},
+		{"参数个数", "upper(name, name)", "参数个数不正确"},
+		{"多余内容", "1 2", "多余的内容"},
+		{"正则模式为列", "regex_match(name, name)", "模式必须是字符串"},
+		{"正则模式为表达式", `regex_match(name, "a" & "b")`, "模式必须是字符串"},
+		{"正则模式为数字", "regex_extract(name, 1)", "模式必须是字符串"},
+		{"正则模式无效", `regex_match(name, "(")`, "模式无效"},
+
+		// 求值错误
+		{"非数字运算", `name * 2`, "不是数字"},
+		{"substr长度为无穷", `substr(name, 1, "Inf")`, "不是有效的整数"},
+		{"substr起始为NaN", `substr(name, "NaN")`, "不是有效的整数"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := evalTestExpr(tt.src, fields)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want containing %q", tt.src, err, tt.wantErr)
+			}
+		})
+	}
+}
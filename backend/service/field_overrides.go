@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"mysql-sync-plugin/models"
+)
+
+// 支持覆盖的目标字段类型
+var overridableTypes = map[string]bool{
+	"text":      true,
+	"number":    true,
+	"date":      true,
+	"dateTime":  true,
+	"checkbox":  true,
+	"currency":  true,
+	"hyperlink": true,
+	"phone":     true,
+	"progress":  true,
+	"rating":    true,
+}
+
+// prepareFields 在源列推断的字段定义上应用配置：先确定记录ID键列，再处理二进制列和数值精度，最后应用用户的类型覆盖
+func (s *MySQLService) prepareFields(fields []models.Field, config *models.MySQLConfig) ([]models.Field, error) {
+	fields, err := resolveKeyFields(fields, config.PrimaryKeys)
+	if err != nil {
+		return nil, err
+	}
+	if config.BinaryMode == BinaryModeSkip {
+		fields = removeBinaryFields(fields)
+	}
+	if config.UnsafeNumberAsString {
+		fields = s.applyUnsafeNumberAsText(fields)
+	}
+	return s.applyFieldOverrides(fields, config.FieldOverrides)
+}
+
+// applyUnsafeNumberAsText 将可能超出安全精度的数值列改为文本字段，以字符串原样输出
+func (s *MySQLService) applyUnsafeNumberAsText(fields []models.Field) []models.Field {
+	for i := range fields {
+		if fields[i].Type == "number" && mayExceedSafeNumber(fields[i]) {
+			fields[i].Type = "text"
+			fields[i].Property = nil
+		}
+	}
+	return fields
+}
+
+// applyFieldOverrides 应用字段类型覆盖配置
+func (s *MySQLService) applyFieldOverrides(fields []models.Field, overrides []models.FieldOverride) ([]models.Field, error) {
+	if len(overrides) == 0 {
+		return fields, nil
+	}
+
+	overrideMap := make(map[string]models.FieldOverride)
+	for _, o := range overrides {
+		if o.Type == "" {
+			continue
+		}
+		if !overridableTypes[o.Type] {
+			return nil, fmt.Errorf("字段 %s 的目标类型 %s 不支持", o.MysqlField, o.Type)
+		}
+		if o.Divisor < 0 {
+			return nil, fmt.Errorf("字段 %s 的除数不能为负数", o.MysqlField)
+		}
+		// date 为 dateTime 的别名
+		if o.Type == "date" {
+			o.Type = "dateTime"
+		}
+		overrideMap[o.MysqlField] = o
+	}
+
+	for i := range fields {
+		o, ok := overrideMap[fields[i].Column]
+		if !ok {
+			continue
+		}
+		fields[i].Type = o.Type
+		fields[i].Divisor = o.Divisor
+		fields[i].Property = s.getOverrideProperty(fields[i], o)
+	}
+
+	return fields, nil
+}
+
+// getOverrideProperty 根据覆盖配置生成字段属性
+func (s *MySQLService) getOverrideProperty(field models.Field, o models.FieldOverride) map[string]interface{} {
+	switch o.Type {
+	case "number":
+		if o.Formatter != "" {
+			return map[string]interface{}{"formatter": o.Formatter}
+		}
+		// 未指定格式时沿用源列推断的格式
+		return s.getNumberProperty(baseColumnType(field.SourceType), field.Scale)
+	case "currency":
+		return map[string]interface{}{
+			"currencyCode": defaultString(o.CurrencyCode, "CNY"),
+			"formatter":    defaultString(o.Formatter, "FLOAT_2"),
+		}
+	case "dateTime":
+		if o.DateFormat != "" {
+			return map[string]interface{}{"formatter": o.DateFormat}
+		}
+		return getDateProperty(baseColumnType(field.SourceType))
+	case "progress":
+		return map[string]interface{}{
+			"formatter": defaultString(o.Formatter, "PERCENT"),
+			"min":       defaultFloat(o.Min, 0),
+			"max":       defaultFloat(o.Max, 1),
+		}
+	case "rating":
+		return map[string]interface{}{
+			"min": defaultFloat(o.Min, 0),
+			"max": defaultFloat(o.Max, 5),
+		}
+	}
+	return nil
+}
+
+func defaultString(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func defaultFloat(v *float64, def float64) float64 {
+	if v == nil {
+		return def
+	}
+	return *v
+}
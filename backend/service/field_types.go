@@ -0,0 +1,280 @@
+package service
+
+import (
+	"fmt"
+	"mysql-sync-plugin/models"
+	"strconv"
+	"strings"
+)
+
+// 空间数据类型
+var spatialTypes = map[string]bool{
+	"geometry":           true,
+	"point":              true,
+	"linestring":         true,
+	"polygon":            true,
+	"multipoint":         true,
+	"multilinestring":    true,
+	"multipolygon":       true,
+	"geometrycollection": true,
+	"geomcollection":     true,
+}
+
+// baseColumnType 提取列类型的基础类型名，如 "tinyint(1) unsigned" -> "tinyint"
+func baseColumnType(columnType string) string {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+	if idx := strings.IndexAny(columnType, "( "); idx >= 0 {
+		return columnType[:idx]
+	}
+	return columnType
+}
+
+// buildField 根据MySQL列信息构建字段定义
+// dataType 为 DATA_TYPE(如 tinyint)，columnType 为 COLUMN_TYPE(如 tinyint(1))
+// precision/scale 为数值精度和小数位数，未知时传 -1
+func (s *MySQLService) buildField(columnName, dataType, columnType string, precision, scale int) models.Field {
+	if columnType == "" {
+		columnType = dataType
+	}
+
+	field := models.Field{
+		ID:         fmt.Sprintf("fid_%s", columnName),
+		Name:       columnName,
+		Type:       s.mapMySQLTypeToAITable(dataType, columnType),
+		Column:     columnName,
+		SourceType: strings.ToLower(columnType),
+		Precision:  precision,
+		Scale:      scale,
+	}
+	field.Property = s.getFieldProperty(field)
+
+	return field
+}
+
+// mapMySQLTypeToAITable 映射MySQL类型到AI表格类型
+func (s *MySQLService) mapMySQLTypeToAITable(dataType, columnType string) string {
+	dataType = strings.ToLower(dataType)
+	columnType = strings.ToLower(columnType)
+
+	switch dataType {
+	case "enum":
+		return "singleSelect"
+	case "set":
+		return "multiSelect"
+	case "json":
+		return "text"
+	case "point":
+		return "location"
+	case "tinyint", "bit":
+		if isBooleanColumnType(columnType) {
+			return "checkbox"
+		}
+	}
+
+	// 其他空间类型以WKT文本展示(需在数字类型判断之前，避免 point 命中 "int")
+	if spatialTypes[dataType] {
+		return "text"
+	}
+
+	// 数字类型
+	if strings.Contains(dataType, "int") ||
+		strings.Contains(dataType, "decimal") ||
+		strings.Contains(dataType, "float") ||
+		strings.Contains(dataType, "double") ||
+		strings.Contains(dataType, "numeric") ||
+		strings.Contains(dataType, "real") ||
+		strings.Contains(dataType, "money") ||
+		dataType == "bit" {
+		return "number"
+	}
+
+	// 日期类型(TIME 为时长而非时间点，按文本输出；YEAR 按整数输出)
+	switch dataType {
+	case "date", "datetime", "timestamp":
+		return "dateTime"
+	case "time":
+		return "text"
+	case "year":
+		return "number"
+	}
+
+	// 默认为文本
+	return "text"
+}
+
+// isBooleanColumnType 判断列类型是否为布尔语义(tinyint(1) / bit(1))
+func isBooleanColumnType(columnType string) bool {
+	columnType = strings.ToLower(columnType)
+	return strings.HasPrefix(columnType, "tinyint(1)") || strings.HasPrefix(columnType, "bit(1)")
+}
+
+// getFieldProperty 获取字段属性
+func (s *MySQLService) getFieldProperty(field models.Field) map[string]interface{} {
+	switch field.Type {
+	case "number":
+		return s.getNumberProperty(baseColumnType(field.SourceType), field.Scale)
+	case "dateTime":
+		return getDateProperty(baseColumnType(field.SourceType))
+	case "singleSelect", "multiSelect":
+		options := parseEnumOptions(field.SourceType)
+		if len(options) == 0 {
+			return nil
+		}
+		choices := make([]map[string]interface{}, 0, len(options))
+		for _, opt := range options {
+			choices = append(choices, map[string]interface{}{"name": opt})
+		}
+		return map[string]interface{}{
+			"choices": choices,
+		}
+	}
+	return nil
+}
+
+// parseEnumOptions 从 COLUMN_TYPE 解析 ENUM/SET 的选项
+// 例如: enum('a','b') -> ["a", "b"]，选项中连续两个单引号表示一个单引号
+func parseEnumOptions(columnType string) []string {
+	start := strings.Index(columnType, "(")
+	end := strings.LastIndex(columnType, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+	body := columnType[start+1 : end]
+
+	var options []string
+	var current strings.Builder
+	inQuote := false
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if !inQuote {
+			if c == '\'' {
+				inQuote = true
+				current.Reset()
+			}
+			continue
+		}
+		switch {
+		case c == '\'' && i+1 < len(body) && body[i+1] == '\'':
+			// '' 转义为单引号
+			current.WriteByte('\'')
+			i++
+		case c == '\\' && i+1 < len(body):
+			current.WriteByte(body[i+1])
+			i++
+		case c == '\'':
+			inQuote = false
+			options = append(options, current.String())
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	return options
+}
+
+// getNumberProperty 获取数字类型属性
+// scale 为小数位数，未知时传 -1
+func (s *MySQLService) getNumberProperty(mysqlType string, scale int) map[string]interface{} {
+	mysqlType = strings.ToLower(mysqlType)
+
+	if strings.Contains(mysqlType, "int") || mysqlType == "year" {
+		return map[string]interface{}{
+			"formatter": "INT",
+		}
+	}
+
+	if strings.Contains(mysqlType, "decimal") || strings.Contains(mysqlType, "numeric") ||
+		strings.Contains(mysqlType, "float") || strings.Contains(mysqlType, "double") {
+		switch {
+		case scale == 0:
+			return map[string]interface{}{"formatter": "INT"}
+		case scale > 4:
+			// 平台最多支持4位小数
+			return map[string]interface{}{"formatter": "FLOAT_4"}
+		case scale > 0:
+			return map[string]interface{}{"formatter": fmt.Sprintf("FLOAT_%d", scale)}
+		}
+		return map[string]interface{}{
+			"formatter": "FLOAT_2",
+		}
+	}
+
+	return nil
+}
+
+// getDateProperty 获取日期类型属性
+func getDateProperty(mysqlType string) map[string]interface{} {
+	if strings.ToLower(mysqlType) == "date" {
+		return map[string]interface{}{
+			"formatter": "YYYY-MM-DD",
+		}
+	}
+	return map[string]interface{}{
+		"formatter": "YYYY-MM-DD HH:mm",
+	}
+}
+
+// applyDivisor 数值除以除数(如分转元)
+func applyDivisor(value interface{}, divisor float64) interface{} {
+	if divisor == 0 || divisor == 1 {
+		return value
+	}
+	switch v := value.(type) {
+	case int64:
+		return float64(v) / divisor
+	case float64:
+		return v / divisor
+	}
+	return value
+}
+
+// toText 将值转换为字符串
+func toText(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// toBool 将 tinyint(1)/bit(1) 的值转换为布尔
+func toBool(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case []byte:
+		// bit(1) 以原始字节返回(0x00/0x01)，tinyint 以文本返回("0"/"1")
+		if len(v) == 1 && v[0] <= 1 {
+			return v[0] == 1
+		}
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return nil
+		}
+		return n != 0
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil
+		}
+		return n != 0
+	default:
+		return nil
+	}
+}
+
+// toStringList 将 SET 值("a,b,c")转换为字符串数组
+func toStringList(value interface{}) interface{} {
+	result := []string{}
+	for _, item := range strings.Split(toText(value), ",") {
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
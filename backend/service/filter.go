@@ -0,0 +1,237 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"mysql-sync-plugin/models"
+	"strings"
+	"time"
+)
+
+const (
+	// 条件树最大嵌套层数
+	maxFilterDepth = 8
+	// in/notIn 最多的值个数
+	maxFilterValues = 1000
+)
+
+// 比较运算符对应的SQL
+var comparisonOperators = map[string]string{
+	"eq":      "=",
+	"ne":      "<>",
+	"gt":      ">",
+	"gte":     ">=",
+	"lt":      "<",
+	"lte":     "<=",
+	"like":    "LIKE",
+	"notLike": "NOT LIKE",
+}
+
+// filterCompiler 将筛选条件树编译为参数化的SQL条件
+// 列名只能引用字段定义中的列，值全部作为参数传入
+type filterCompiler struct {
+	fields   []models.Field
+	location *time.Location // 源库时区，用于计算相对日期
+	now      time.Time      // 相对日期的基准时间(同一次同步内保持不变)
+}
+
+// applyConfigFilter 将配置的筛选条件和排序追加到查询条件
+func applyConfigFilter(f *queryFilter, config *models.MySQLConfig, fields []models.Field, location *time.Location, now time.Time) error {
+	c := &filterCompiler{fields: fields, location: location, now: now}
+
+	if config.Filter != nil {
+		cond, args, err := c.compile(config.Filter, 0)
+		if err != nil {
+			return err
+		}
+		if cond != "" {
+			f.where(cond, args...)
+		}
+	}
+
+	for _, s := range config.Sort {
+		field, err := c.field(s.Column)
+		if err != nil {
+			return err
+		}
+		order := quoteIdentifier(field.Column)
+		if s.Desc {
+			order += " DESC"
+		}
+		f.orderBy = append(f.orderBy, order)
+	}
+	return nil
+}
+
+// compile 编译条件节点，空条件组返回空串
+func (c *filterCompiler) compile(node *models.FilterNode, depth int) (string, []interface{}, error) {
+	if depth > maxFilterDepth {
+		return "", nil, fmt.Errorf("筛选条件嵌套超过 %d 层", maxFilterDepth)
+	}
+	if node.Logic != "" {
+		return c.compileGroup(node, depth)
+	}
+	return c.compileCondition(node)
+}
+
+// compileGroup 编译条件组
+func (c *filterCompiler) compileGroup(node *models.FilterNode, depth int) (string, []interface{}, error) {
+	var joiner string
+	switch strings.ToLower(node.Logic) {
+	case "and":
+		joiner = " AND "
+	case "or":
+		joiner = " OR "
+	default:
+		return "", nil, fmt.Errorf("不支持的条件组逻辑: %s", node.Logic)
+	}
+
+	var parts []string
+	var args []interface{}
+	for i := range node.Conditions {
+		cond, condArgs, err := c.compile(&node.Conditions[i], depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		if cond == "" {
+			continue
+		}
+		parts = append(parts, cond)
+		args = append(args, condArgs...)
+	}
+
+	switch len(parts) {
+	case 0:
+		return "", nil, nil
+	case 1:
+		return parts[0], args, nil
+	}
+	return "(" + strings.Join(parts, joiner) + ")", args, nil
+}
+
+// compileCondition 编译单个条件
+func (c *filterCompiler) compileCondition(node *models.FilterNode) (string, []interface{}, error) {
+	field, err := c.field(node.Column)
+	if err != nil {
+		return "", nil, err
+	}
+	column := quoteIdentifier(field.Column)
+
+	if op, ok := comparisonOperators[node.Operator]; ok {
+		value, err := filterScalar(node.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("列 %s 的条件值无效: %w", field.Column, err)
+		}
+		return fmt.Sprintf("%s %s ?", column, op), []interface{}{value}, nil
+	}
+
+	switch node.Operator {
+	case "isNull":
+		return column + " IS NULL", nil, nil
+	case "isNotNull":
+		return column + " IS NOT NULL", nil, nil
+	case "in", "notIn":
+		values, err := filterList(node.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("列 %s 的条件值无效: %w", field.Column, err)
+		}
+		if len(values) == 0 || len(values) > maxFilterValues {
+			return "", nil, fmt.Errorf("列 %s 的 %s 条件需要 1~%d 个值", field.Column, node.Operator, maxFilterValues)
+		}
+		op := "IN"
+		if node.Operator == "notIn" {
+			op = "NOT IN"
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		return fmt.Sprintf("%s %s (%s)", column, op, placeholders), values, nil
+	case "between":
+		values, err := filterList(node.Value)
+		if err != nil || len(values) != 2 {
+			return "", nil, fmt.Errorf("列 %s 的 between 条件需要 2 个值", field.Column)
+		}
+		return column + " BETWEEN ? AND ?", values, nil
+	case "lastDays", "lastHours":
+		n, ok := node.Value.(float64)
+		if !ok || n <= 0 || n != math.Trunc(n) {
+			return "", nil, fmt.Errorf("列 %s 的 %s 条件需要正整数", field.Column, node.Operator)
+		}
+		unit := 24 * time.Hour
+		if node.Operator == "lastHours" {
+			unit = time.Hour
+		}
+		start := c.now.Add(-time.Duration(n) * unit)
+		return column + " >= ?", []interface{}{c.timeArg(start, field)}, nil
+	case "today", "thisMonth":
+		local := c.now.In(c.location)
+		start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.location)
+		end := start.AddDate(0, 0, 1)
+		if node.Operator == "thisMonth" {
+			start = time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, c.location)
+			end = start.AddDate(0, 1, 0)
+		}
+		return fmt.Sprintf("(%s >= ? AND %s < ?)", column, column), []interface{}{c.timeArg(start, field), c.timeArg(end, field)}, nil
+	}
+
+	return "", nil, fmt.Errorf("不支持的筛选运算符: %s", node.Operator)
+}
+
+// field 按列名查找字段，列名不存在时报错(防止引用任意表达式)
+func (c *filterCompiler) field(column string) (models.Field, error) {
+	field, ok := findFieldByColumn(c.fields, column)
+	if !ok {
+		return models.Field{}, fmt.Errorf("筛选或排序列 %s 不存在", column)
+	}
+	return field, nil
+}
+
+// timeArg 将时间点转换为与列类型匹配的查询参数
+// 会话时区与源库时区一致，日期列按源库时区的墙上时间比较，整数列视为Unix秒
+func (c *filterCompiler) timeArg(t time.Time, field models.Field) interface{} {
+	switch sourceType := baseColumnType(field.SourceType); {
+	case integerColumnTypes[sourceType]:
+		return t.Unix()
+	case sourceType == "date":
+		return t.In(c.location).Format("2006-01-02")
+	default:
+		return t.In(c.location).Format("2006-01-02 15:04:05")
+	}
+}
+
+// filterScalar 校验并转换单个条件值
+func filterScalar(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case float64:
+		// JSON数字解码为 float64，整数值按整数传参
+		if val == math.Trunc(val) && math.Abs(val) < 1<<53 {
+			return int64(val), nil
+		}
+		return val, nil
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+	case nil:
+		return nil, fmt.Errorf("缺少条件值，判断空值请使用 isNull")
+	}
+	return nil, fmt.Errorf("不支持的值类型 %T", v)
+}
+
+// filterList 校验并转换数组条件值
+func filterList(v interface{}) ([]interface{}, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("条件值应为数组")
+	}
+	values := make([]interface{}, len(list))
+	for i, item := range list {
+		value, err := filterScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mysql-sync-plugin/models"
+)
+
+var filterTestFields = []models.Field{
+	{Column: "id", SourceType: "bigint"},
+	{Column: "name", SourceType: "varchar(50)"},
+	{Column: "created_at", SourceType: "datetime"},
+	{Column: "day", SourceType: "date"},
+	{Column: "ts", SourceType: "int(11)"},
+	{Column: "a`b", SourceType: "varchar(50)"},
+}
+
+// compileTestFilter 编译筛选条件和排序，now 为 2026-03-15 10:30 (UTC+8)
+func compileTestFilter(filter *models.FilterNode, sort []models.SortField) (*queryFilter, error) {
+	location := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2026, 3, 15, 10, 30, 0, 0, location)
+	var f queryFilter
+	config := &models.MySQLConfig{Filter: filter, Sort: sort}
+	if err := applyConfigFilter(&f, config, filterTestFields, location, now); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func TestFilterCompile(t *testing.T) {
+	cond := func(column, operator string, value interface{}) models.FilterNode {
+		return models.FilterNode{Column: column, Operator: operator, Value: value}
+	}
+	now := time.Date(2026, 3, 15, 2, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter models.FilterNode
+		where  string
+		args   []interface{}
+	}{
+		{"等于", cond("name", "eq", "paid"), "`name` = ?", []interface{}{"paid"}},
+		{"不等于", cond("id", "ne", float64(3)), "`id` <> ?", []interface{}{int64(3)}},
+		{"大于", cond("id", "gt", float64(1.5)), "`id` > ?", []interface{}{1.5}},
+		{"大于等于", cond("id", "gte", float64(2)), "`id` >= ?", []interface{}{int64(2)}},
+		{"小于", cond("id", "lt", float64(2)), "`id` < ?", []interface{}{int64(2)}},
+		{"小于等于", cond("id", "lte", true), "`id` <= ?", []interface{}{1}},
+		{"like", cond("name", "like", "%a%"), "`name` LIKE ?", []interface{}{"%a%"}},
+		{"notLike", cond("name", "notLike", "a_"), "`name` NOT LIKE ?", []interface{}{"a_"}},
+		{"in", cond("id", "in", []interface{}{float64(1), "2"}), "`id` IN (?, ?)", []interface{}{int64(1), "2"}},
+		{"notIn", cond("id", "notIn", []interface{}{float64(1)}), "`id` NOT IN (?)", []interface{}{int64(1)}},
+		{"between", cond("id", "between", []interface{}{float64(1), float64(9)}), "`id` BETWEEN ? AND ?", []interface{}{int64(1), int64(9)}},
+		{"isNull", cond("name", "isNull", nil), "`name` IS NULL", nil},
+		{"isNotNull", cond("name", "isNotNull", nil), "`name` IS NOT NULL", nil},
+
+		// 相对日期按源库时区计算，参数类型与列类型匹配
+		{"lastDays日期时间列", cond("created_at", "lastDays", float64(2)), "`created_at` >= ?", []interface{}{"2026-03-13 10:30:00"}},
+		{"lastHours整数列", cond("ts", "lastHours", float64(3)), "`ts` >= ?", []interface{}{now.Add(-3 * time.Hour).Unix()}},
+		{"today日期列", cond("day", "today", nil), "(`day` >= ? AND `day` < ?)", []interface{}{"2026-03-15", "2026-03-16"}},
+		{"thisMonth", cond("created_at", "thisMonth", nil), "(`created_at` >= ? AND `created_at` < ?)",
+			[]interface{}{"2026-03-01 00:00:00", "2026-04-01 00:00:00"}},
+
+		// 条件组
+		{"and组", models.FilterNode{Logic: "and", Conditions: []models.FilterNode{
+			cond("id", "gt", float64(1)), cond("name", "eq", "x"),
+		}}, "(`id` > ? AND `name` = ?)", []interface{}{int64(1), "x"}},
+		{"嵌套or组", models.FilterNode{Logic: "AND", Conditions: []models.FilterNode{
+			cond("id", "gt", float64(1)),
+			{Logic: "or", Conditions: []models.FilterNode{cond("name", "isNull", nil), cond("name", "eq", "")}},
+		}}, "(`id` > ? AND (`name` IS NULL OR `name` = ?))", []interface{}{int64(1), ""}},
+		{"单个条件的组不加括号", models.FilterNode{Logic: "or", Conditions: []models.FilterNode{
+			cond("id", "eq", float64(1)), {Logic: "and"},
+		}}, "`id` = ?", []interface{}{int64(1)}},
+
+		// 注入：列名转义，值只作为参数
+		{"列名中的反引号", cond("a`b", "eq", "x"), "`a``b` = ?", []interface{}{"x"}},
+		{"值中的SQL", cond("name", "eq", "x' OR '1'='1"), "`name` = ?", []interface{}{"x' OR '1'='1"}},
+		{"in值中的SQL", cond("name", "in", []interface{}{"a); DROP TABLE t; --"}), "`name` IN (?)", []interface{}{"a); DROP TABLE t; --"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := compileTestFilter(&tt.filter, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(f.conditions, " AND "); got != tt.where {
+				t.Errorf("where = %s, want %s", got, tt.where)
+			}
+			if !reflect.DeepEqual(f.args, tt.args) {
+				t.Errorf("args = %#v, want %#v", f.args, tt.args)
+			}
+		})
+	}
+}
+
+func TestFilterCompileEmptyGroup(t *testing.T) {
+	f, err := compileTestFilter(&models.FilterNode{Logic: "and", Conditions: []models.FilterNode{{Logic: "or"}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clause := f.whereClause(); clause != "" {
+		t.Errorf("whereClause = %q, want empty", clause)
+	}
+}
+
+func TestFilterSort(t *testing.T) {
+	f, err := compileTestFilter(nil, []models.SortField{{Column: "created_at", Desc: true}, {Column: "a`b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.orderClause("`id`"), " ORDER BY `created_at` DESC, `a``b`, `id`"; got != want {
+		t.Errorf("orderClause = %q, want %q", got, want)
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	deep := models.FilterNode{Column: "id", Operator: "eq", Value: float64(1)}
+	for i := 0; i <= maxFilterDepth; i++ {
+		deep = models.FilterNode{Logic: "and", Conditions: []models.FilterNode{deep}}
+	}
+	tooMany := make([]interface{}, maxFilterValues+1)
+	for i := range tooMany {
+		tooMany[i] = float64(i)
+	}
+
+	tests := []struct {
+		name    string
+		filter  *models.FilterNode
+		sort    []models.SortField
+		wantErr string
+	}{
+		{"未知列", &models.FilterNode{Column: "password", Operator: "eq", Value: "x"}, nil, "列 password 不存在"},
+		{"列名为表达式", &models.FilterNode{Column: "id) OR (1=1", Operator: "eq", Value: "x"}, nil, "不存在"},
+		{"排序列为表达式", nil, []models.SortField{{Column: "SLEEP(1)"}}, "不存在"},
+		{"未知运算符", &models.FilterNode{Column: "id", Operator: "regexp", Value: "x"}, nil, "不支持的筛选运算符"},
+		{"运算符大小写", &models.FilterNode{Column: "id", Operator: "EQ", Value: "x"}, nil, "不支持的筛选运算符"},
+		{"未知组逻辑", &models.FilterNode{Logic: "xor"}, nil, "不支持的条件组逻辑"},
+		{"嵌套过深", &deep, nil, "嵌套超过"},
+		{"比较值为空", &models.FilterNode{Column: "id", Operator: "eq"}, nil, "isNull"},
+		{"比较值为对象", &models.FilterNode{Column: "id", Operator: "eq", Value: map[string]interface{}{}}, nil, "不支持的值类型"},
+		{"in值不是数组", &models.FilterNode{Column: "id", Operator: "in", Value: "1,2"}, nil, "应为数组"},
+		{"in值为空", &models.FilterNode{Column: "id", Operator: "in", Value: []interface{}{}}, nil, "需要 1~"},
+		{"in值过多", &models.FilterNode{Column: "id", Operator: "in", Value: tooMany}, nil, "需要 1~"},
+		{"between值个数", &models.FilterNode{Column: "id", Operator: "between", Value: []interface{}{float64(1)}}, nil, "需要 2 个值"},
+		{"lastDays非整数", &models.FilterNode{Column: "created_at", Operator: "lastDays", Value: float64(1.5)}, nil, "需要正整数"},
+		{"lastHours为负", &models.FilterNode{Column: "created_at", Operator: "lastHours", Value: float64(-1)}, nil, "需要正整数"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileTestFilter(tt.filter, tt.sort)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
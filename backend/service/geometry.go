@@ -0,0 +1,209 @@
+package service
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MySQL 空间数据的内部存储格式为: 4字节SRID(小端) + 标准WKB
+// WKB 几何类型编码
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+)
+
+var errInvalidWKB = errors.New("无效的WKB数据")
+
+// wkbReader WKB解析器
+type wkbReader struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func (r *wkbReader) readByte() (byte, error) {
+	if r.pos+1 > len(r.data) {
+		return 0, errInvalidWKB
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *wkbReader) readUint32() (uint32, error) {
+	if r.pos+4 > len(r.data) {
+		return 0, errInvalidWKB
+	}
+	v := r.order.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *wkbReader) readFloat64() (float64, error) {
+	if r.pos+8 > len(r.data) {
+		return 0, errInvalidWKB
+	}
+	v := math.Float64frombits(r.order.Uint64(r.data[r.pos:]))
+	r.pos += 8
+	return v, nil
+}
+
+// readHeader 读取字节序与几何类型
+func (r *wkbReader) readHeader() (uint32, error) {
+	b, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		r.order = binary.BigEndian
+	} else {
+		r.order = binary.LittleEndian
+	}
+	return r.readUint32()
+}
+
+// readPoint 读取坐标点，返回 "x y"
+func (r *wkbReader) readPoint() (string, error) {
+	x, err := r.readFloat64()
+	if err != nil {
+		return "", err
+	}
+	y, err := r.readFloat64()
+	if err != nil {
+		return "", err
+	}
+	return formatCoord(x) + " " + formatCoord(y), nil
+}
+
+// readPoints 读取点序列，返回 "x1 y1, x2 y2"
+func (r *wkbReader) readPoints() (string, error) {
+	n, err := r.readUint32()
+	if err != nil {
+		return "", err
+	}
+	points := make([]string, 0, n)
+	for i := uint32(0); i < n; i++ {
+		p, err := r.readPoint()
+		if err != nil {
+			return "", err
+		}
+		points = append(points, p)
+	}
+	return strings.Join(points, ", "), nil
+}
+
+// readRings 读取多边形的环
+func (r *wkbReader) readRings() (string, error) {
+	n, err := r.readUint32()
+	if err != nil {
+		return "", err
+	}
+	rings := make([]string, 0, n)
+	for i := uint32(0); i < n; i++ {
+		ring, err := r.readPoints()
+		if err != nil {
+			return "", err
+		}
+		rings = append(rings, "("+ring+")")
+	}
+	return strings.Join(rings, ", "), nil
+}
+
+// readGeometry 递归读取几何对象并输出WKT
+func (r *wkbReader) readGeometry() (string, error) {
+	geomType, err := r.readHeader()
+	if err != nil {
+		return "", err
+	}
+
+	switch geomType {
+	case wkbPoint:
+		p, err := r.readPoint()
+		return "POINT(" + p + ")", err
+	case wkbLineString:
+		p, err := r.readPoints()
+		return "LINESTRING(" + p + ")", err
+	case wkbPolygon:
+		p, err := r.readRings()
+		return "POLYGON(" + p + ")", err
+	case wkbMultiPoint, wkbMultiLineString, wkbMultiPolygon, wkbGeometryCollection:
+		n, err := r.readUint32()
+		if err != nil {
+			return "", err
+		}
+		parts := make([]string, 0, n)
+		for i := uint32(0); i < n; i++ {
+			part, err := r.readGeometry()
+			if err != nil {
+				return "", err
+			}
+			// MULTI* 类型的子元素不需要类型前缀
+			if geomType != wkbGeometryCollection {
+				part = part[strings.Index(part, "("):]
+			}
+			parts = append(parts, part)
+		}
+		name := map[uint32]string{
+			wkbMultiPoint:         "MULTIPOINT",
+			wkbMultiLineString:    "MULTILINESTRING",
+			wkbMultiPolygon:       "MULTIPOLYGON",
+			wkbGeometryCollection: "GEOMETRYCOLLECTION",
+		}[geomType]
+		return name + "(" + strings.Join(parts, ", ") + ")", nil
+	default:
+		return "", errInvalidWKB
+	}
+}
+
+// newGeometryReader 跳过MySQL内部格式的SRID前缀
+func newGeometryReader(data []byte) (*wkbReader, bool) {
+	if len(data) < 4+1+4 {
+		return nil, false
+	}
+	return &wkbReader{data: data[4:]}, true
+}
+
+// geometryToWKT 将MySQL空间数据转换为WKT文本，解析失败时输出十六进制
+func geometryToWKT(data []byte) string {
+	r, ok := newGeometryReader(data)
+	if ok {
+		if wkt, err := r.readGeometry(); err == nil {
+			return wkt
+		}
+	}
+	return "0x" + strings.ToUpper(hex.EncodeToString(data))
+}
+
+// geometryToLocation 将 POINT 转换为地理位置值 "经度,纬度"
+func geometryToLocation(data []byte) interface{} {
+	r, ok := newGeometryReader(data)
+	if !ok {
+		return nil
+	}
+	geomType, err := r.readHeader()
+	if err != nil || geomType != wkbPoint {
+		return nil
+	}
+	x, err := r.readFloat64()
+	if err != nil {
+		return nil
+	}
+	y, err := r.readFloat64()
+	if err != nil {
+		return nil
+	}
+	return formatCoord(x) + "," + formatCoord(y)
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"fmt"
+	"mysql-sync-plugin/models"
+	"strings"
+)
+
+// QueryModeJoin 取数模式：多表关联
+const QueryModeJoin = "join"
+
+// 支持的关联方式
+var joinKeywords = map[string]string{
+	"":      "LEFT JOIN", // 默认保留主表的全部行
+	"left":  "LEFT JOIN",
+	"inner": "INNER JOIN",
+}
+
+// resolveJoinQuery 多表关联模式下由关联配置生成SQL，替换为自定义SQL取数
+func resolveJoinQuery(config *models.MySQLConfig) error {
+	if config.QueryMode != QueryModeJoin {
+		config.Join = nil
+		return nil
+	}
+	if config.Join == nil {
+		return fmt.Errorf("请配置关联的表")
+	}
+
+	sqlText, err := buildJoinSQL(config.Join)
+	if err != nil {
+		return err
+	}
+	config.Table = ""
+	config.QueryMode = "sql"
+	config.CustomSQL = sqlText
+	config.SQLParams = nil
+	return nil
+}
+
+// buildJoinSQL 生成关联查询SQL
+// 表名、列名只作为转义后的标识符出现，用户配置不会作为SQL文本拼入
+func buildJoinSQL(j *models.JoinConfig) (string, error) {
+	if j.Base == "" {
+		return "", fmt.Errorf("请选择主表")
+	}
+
+	// 已出现的表(别名)，关联键和选取的列只能引用这些表
+	known := map[string]bool{j.Base: true}
+	from := quoteIdentifier(j.Base)
+	for i, t := range j.Joins {
+		if t.Table == "" {
+			return "", fmt.Errorf("第 %d 个关联未选择表", i+1)
+		}
+		alias := joinAlias(t)
+		if known[alias] {
+			return "", fmt.Errorf("表 %s 重复出现，请设置别名", alias)
+		}
+		keyword, ok := joinKeywords[t.Type]
+		if !ok {
+			return "", fmt.Errorf("不支持的关联方式: %s", t.Type)
+		}
+		if len(t.On) == 0 {
+			return "", fmt.Errorf("关联表 %s 未配置关联键", alias)
+		}
+
+		var conds []string
+		for _, k := range t.On {
+			if k.LeftColumn == "" || k.RightColumn == "" {
+				return "", fmt.Errorf("关联表 %s 的关联键不完整", alias)
+			}
+			if !known[k.LeftTable] {
+				return "", fmt.Errorf("关联表 %s 的关联键引用了未关联的表 %s", alias, k.LeftTable)
+			}
+			conds = append(conds, fmt.Sprintf("%s.%s = %s.%s",
+				quoteIdentifier(k.LeftTable), quoteIdentifier(k.LeftColumn),
+				quoteIdentifier(alias), quoteIdentifier(k.RightColumn)))
+		}
+
+		from += fmt.Sprintf(" %s %s", keyword, quoteIdentifier(t.Table))
+		if alias != t.Table {
+			from += " AS " + quoteIdentifier(alias)
+		}
+		from += " ON " + strings.Join(conds, " AND ")
+		known[alias] = true
+	}
+
+	if len(j.Columns) == 0 {
+		return "", fmt.Errorf("请选择要同步的列")
+	}
+
+	// 未设置别名的列与其他列重名时以 表名_列名 作为结果列名
+	counts := make(map[string]int)
+	for _, c := range j.Columns {
+		if c.Alias == "" {
+			counts[c.Column]++
+		}
+	}
+	names := make(map[string]bool)
+	var items []string
+	for _, c := range j.Columns {
+		if !known[c.Table] {
+			return "", fmt.Errorf("列 %s 所在的表 %s 未关联", c.Column, c.Table)
+		}
+		if c.Column == "" {
+			return "", fmt.Errorf("表 %s 有未选择的列", c.Table)
+		}
+		name := c.Alias
+		if name == "" {
+			name = c.Column
+			if counts[c.Column] > 1 {
+				name = c.Table + "_" + c.Column
+			}
+		}
+		if names[name] {
+			return "", fmt.Errorf("结果列名 %s 重复，请设置列别名", name)
+		}
+		names[name] = true
+		items = append(items, fmt.Sprintf("%s.%s AS %s", quoteIdentifier(c.Table), quoteIdentifier(c.Column), quoteIdentifier(name)))
+	}
+
+	return "SELECT " + strings.Join(items, ", ") + " FROM " + from, nil
+}
+
+// joinAlias 关联表在SQL中的名称
+func joinAlias(t models.JoinTable) string {
+	if t.Alias != "" {
+		return t.Alias
+	}
+	return t.Table
+}
+
+// GetJoinSchema 获取关联配置涉及的表的列和外键，外键用于推荐关联键
+func (s *MySQLService) GetJoinSchema(config *models.MySQLConfig) (*models.JoinSchema, error) {
+	var tables []string
+	if config.Join != nil {
+		if config.Join.Base != "" {
+			tables = append(tables, config.Join.Base)
+		}
+		for _, t := range config.Join.Joins {
+			if t.Table != "" && !containsString(tables, t.Table) {
+				tables = append(tables, t.Table)
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("请选择主表")
+	}
+
+	db, err := s.connectDB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tables)), ", ")
+	args := []interface{}{config.Database}
+	for _, t := range tables {
+		args = append(args, t)
+	}
+
+	schema := &models.JoinSchema{Columns: make(map[string][]string)}
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT TABLE_NAME, COLUMN_NAME
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME IN (%s)
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, placeholders), args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询表结构失败: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("查询表结构失败: %w", err)
+		}
+		schema.Columns[table] = append(schema.Columns[table], column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询表结构失败: %w", err)
+	}
+
+	// 引用方或被引用方在这些表中的外键，多列外键按列顺序合并
+	fkRows, err := db.Query(fmt.Sprintf(`
+		SELECT CONSTRAINT_NAME, TABLE_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND REFERENCED_TABLE_SCHEMA = TABLE_SCHEMA AND REFERENCED_TABLE_NAME IS NOT NULL
+			AND (TABLE_NAME IN (%s) OR REFERENCED_TABLE_NAME IN (%s))
+		ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION
+	`, placeholders, placeholders), append(args, args[1:]...)...)
+	if err != nil {
+		return nil, fmt.Errorf("查询外键失败: %w", err)
+	}
+	defer fkRows.Close()
+	for fkRows.Next() {
+		var name, table, column, refTable, refColumn string
+		if err := fkRows.Scan(&name, &table, &column, &refTable, &refColumn); err != nil {
+			return nil, fmt.Errorf("查询外键失败: %w", err)
+		}
+		n := len(schema.ForeignKeys)
+		if n > 0 && schema.ForeignKeys[n-1].Name == name && schema.ForeignKeys[n-1].Table == table {
+			fk := &schema.ForeignKeys[n-1]
+			fk.Columns = append(fk.Columns, column)
+			fk.RefColumns = append(fk.RefColumns, refColumn)
+			continue
+		}
+		schema.ForeignKeys = append(schema.ForeignKeys, models.ForeignKey{
+			Name:       name,
+			Table:      table,
+			Columns:    []string{column},
+			RefTable:   refTable,
+			RefColumns: []string{refColumn},
+		})
+	}
+	if err := fkRows.Err(); err != nil {
+		return nil, fmt.Errorf("查询外键失败: %w", err)
+	}
+
+	if schema.ForeignKeys == nil {
+		schema.ForeignKeys = []models.ForeignKey{}
+	}
+	return schema, nil
+}
@@ -3,17 +3,27 @@ package service
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mysql-sync-plugin/cdc"
 	"mysql-sync-plugin/models"
-	"strconv"
+	"mysql-sync-plugin/spool"
+	"net/url"
 	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 // MySQLService MySQL数据源服务
 type MySQLService struct{}
 
+// recordTracker 跨分页检测重复记录ID
+var recordTracker = newDuplicateTracker()
+
+// snapshots 快照模式下跨分页持有的一致性快照
+var snapshots = newSnapshotManager()
+
 // NewMySQLService 创建MySQL服务实例
 func NewMySQLService() *MySQLService {
 	return &MySQLService{}
@@ -86,15 +96,19 @@ func (s *MySQLService) GetTables(config *models.MySQLConfig) ([]string, error) {
 	return tables, nil
 }
 
-// GetFields 获取表字段信息
-func (s *MySQLService) GetFields(config *models.MySQLConfig) ([]models.Field, error) {
+// GetFields 获取表字段信息及数据预览
+func (s *MySQLService) GetFields(config *models.MySQLConfig) (*models.PreviewResponse, error) {
 	db, err := s.connectDB(config)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
-	return s.getTableSchema(db, config.Database, config.Table)
+	fields, err := s.getTableSchema(db, config.Database, config.Table)
+	if err != nil {
+		return nil, err
+	}
+	return s.previewData(db, config, nil, fields, time.Now())
 }
 
 // GetSheetMeta 获取表结构
@@ -104,6 +118,9 @@ func (s *MySQLService) GetSheetMeta(req *models.SheetMetaRequest) (*models.Sheet
 	if err := json.Unmarshal([]byte(req.Params), &config); err != nil {
 		return nil, fmt.Errorf("解析配置失败: %w", err)
 	}
+	if err := resolveQueryMode(&config); err != nil {
+		return nil, err
+	}
 
 	// 连接数据库
 	db, err := s.connectDB(&config)
@@ -117,8 +134,15 @@ func (s *MySQLService) GetSheetMeta(req *models.SheetMetaRequest) (*models.Sheet
 
 	// 根据取数模式获取字段
 	if config.QueryMode == "sql" && config.CustomSQL != "" {
-		fields, err = s.getSQLSchema(db, config.CustomSQL)
+		var cq *customQuery
+		if cq, err = s.resolveCustomQuery(&config, req.Context, time.Now()); err != nil {
+			return nil, err
+		}
+		fields, err = s.getSQLSchema(db, cq)
 		sheetName = "自定义查询"
+		if config.Join != nil {
+			sheetName = config.Join.Base
+		}
 	} else {
 		fields, err = s.getTableSchema(db, config.Database, config.Table)
 		sheetName = config.Table
@@ -127,6 +151,26 @@ func (s *MySQLService) GetSheetMeta(req *models.SheetMetaRequest) (*models.Sheet
 		return nil, err
 	}
 
+	// 应用数值精度与字段类型覆盖
+	fields, err = s.prepareFields(fields, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 配置了值映射的字段改为单选字段
+	if _, err = s.applyValueMappings(db, &config, fields); err != nil {
+		return nil, err
+	}
+
+	// 追加计算字段和删除标记字段
+	computed, err := s.compileComputedFields(config.ComputedFields, fields)
+	if err != nil {
+		return nil, err
+	}
+	if fields, err = outputFields(fields, computed, config.DeleteMode); err != nil {
+		return nil, err
+	}
+
 	// 应用字段映射
 	fields = s.applyFieldMappings(fields, config.FieldMappings)
 
@@ -143,6 +187,27 @@ func (s *MySQLService) GetRecords(req *models.RecordsRequest) (*models.RecordsRe
 	if err := json.Unmarshal([]byte(req.Params), &config); err != nil {
 		return nil, fmt.Errorf("解析配置失败: %w", err)
 	}
+	if err := resolveQueryMode(&config); err != nil {
+		return nil, err
+	}
+
+	conv, err := newValueConverter(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validSyncMode(config.SyncMode) {
+		return nil, fmt.Errorf("不支持的同步模式: %s", config.SyncMode)
+	}
+	if config.SyncMode == SyncModeIncremental && config.WatermarkColumn == "" {
+		return nil, fmt.Errorf("增量同步需要配置水位列")
+	}
+	if !validCountMode(config.CountMode) {
+		return nil, fmt.Errorf("不支持的总数统计方式: %s", config.CountMode)
+	}
+	if !validDeleteMode(config.DeleteMode) {
+		return nil, fmt.Errorf("不支持的删除行处理方式: %s", config.DeleteMode)
+	}
 
 	// 连接数据库
 	db, err := s.connectDB(&config)
@@ -152,13 +217,16 @@ func (s *MySQLService) GetRecords(req *models.RecordsRequest) (*models.RecordsRe
 	defer db.Close()
 
 	// 解析分页参数
-	offset := 0
-	if req.NextToken != "" {
-		// nextToken格式: "offset:300"
-		parts := strings.Split(req.NextToken, ":")
-		if len(parts) == 2 {
-			offset, _ = strconv.Atoi(parts[1])
-		}
+	token := ParsePageToken(req.NextToken)
+	offset := token.Offset
+
+	// 同步会话ID：优先使用平台提供的ID，其次沿用游标中的ID，首页时生成
+	syncID := req.SyncID
+	if syncID == "" {
+		syncID = token.SyncID
+	}
+	if syncID == "" {
+		syncID = newSyncID()
 	}
 
 	maxResults := req.MaxResults
@@ -166,64 +234,289 @@ func (s *MySQLService) GetRecords(req *models.RecordsRequest) (*models.RecordsRe
 		maxResults = 300
 	}
 
-	var total int
+	isSQLMode := config.QueryMode == "sql" && config.CustomSQL != ""
+	if config.Spool && !isSQLMode {
+		return nil, fmt.Errorf("查询结果缓存仅支持自定义SQL取数")
+	}
+	if config.Spool && config.SyncMode != "" && config.SyncMode != SyncModeFull {
+		return nil, fmt.Errorf("查询结果缓存仅支持全量同步")
+	}
+	hasConfigFilter := config.Filter != nil || len(config.Sort) > 0
+	if hasConfigFilter && (config.Spool || config.SyncMode == SyncModeCDC) {
+		return nil, fmt.Errorf("查询结果缓存和CDC同步不支持筛选条件")
+	}
+
+	// 相对日期(筛选条件和SQL模板变量)以首页的时间为基准，保证各分页条件一致
+	now := time.Now()
+	if token.FilterTime != 0 {
+		now = time.Unix(token.FilterTime, 0)
+	}
+	var cq *customQuery
+	if isSQLMode {
+		if cq, err = s.resolveCustomQuery(&config, req.Context, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// 快照模式：同一次同步的所有分页在同一一致性快照内读取，Total 和 HasMore 不受同步期间的写入影响
+	var q queryer = db
+	snapshotDone := false
+	if config.Snapshot {
+		session, err := snapshots.acquire(syncID, req.NextToken != "", func() (*sql.DB, error) {
+			return s.connectDB(&config)
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer func() { snapshots.release(session, snapshotDone) }()
+		q = session
+	}
+
+	// 获取字段定义(查询结果缓存模式使用缓存中保存的列信息，避免为获取结构再执行一次查询)
 	var fields []models.Field
+	var sp *spool.Spool
+	var costWarnings []string
+	if config.Spool {
+		// 首页构建缓存前检查查询代价
+		if req.NextToken == "" {
+			if costWarnings, err = s.checkQueryCost(q, &config, cq, &queryFilter{}); err != nil {
+				return nil, err
+			}
+		}
+		if sp, err = s.acquireSpool(q, &config, cq, token.SpoolID); err != nil {
+			return nil, err
+		}
+		fields = s.buildSQLFields(db, spoolResultColumns(sp))
+	} else if isSQLMode {
+		fields, err = s.getSQLSchema(db, cq)
+	} else {
+		fields, err = s.getTableSchema(db, config.Database, config.Table)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fields, err = s.prepareFields(fields, &config); err != nil {
+		return nil, err
+	}
+	if conv.computed, err = s.compileComputedFields(config.ComputedFields, fields); err != nil {
+		return nil, err
+	}
+
+	// 增量同步：首页确定水位范围，后续分页沿用游标中的范围
+	var filter queryFilter
+	var window *WatermarkRange
+	var changeSource *cdc.Source
+	var watermarkField models.Field
+	targetKey := syncTargetKey(req, &config)
+	if targetKey == "" && requiresSyncState(&config) {
+		return nil, fmt.Errorf("请求中没有目标表格标识，无法保存同步状态，不支持增量同步、删除行检测和只同步变化的记录")
+	}
+	switch config.SyncMode {
+	case SyncModeIncremental:
+		var ok bool
+		if watermarkField, ok = findFieldByColumn(fields, config.WatermarkColumn); !ok {
+			return nil, fmt.Errorf("水位列 %s 不存在", config.WatermarkColumn)
+		}
+		window = token.Watermark
+		if window == nil {
+			if window, err = s.resolveWatermarkRange(q, &config, cq, targetKey); err != nil {
+				return nil, err
+			}
+		}
+		filter = watermarkFilter(window, watermarkField)
+	case SyncModeCDC:
+		if isSQLMode {
+			return nil, fmt.Errorf("CDC同步仅支持按表取数")
+		}
+		if changeSource, err = cdc.GetStore().FindSource(config.Host, config.Port, config.Database, config.Table); err != nil {
+			return nil, fmt.Errorf("查询CDC数据源失败: %w", err)
+		}
+		if changeSource == nil {
+			return nil, fmt.Errorf("未找到订阅表 %s 的CDC数据源，请先在管理后台添加", config.Table)
+		}
+		window = token.Watermark
+		if window == nil {
+			if window, err = s.resolveChangeRange(changeSource, config.Table, targetKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 筛选条件与排序
+	var filterTime int64
+	if hasConfigFilter {
+		if err := applyConfigFilter(&filter, &config, fields, conv.location, now); err != nil {
+			return nil, err
+		}
+	}
+	if hasConfigFilter || (cq != nil && len(cq.args) > 0) {
+		filterTime = now.Unix()
+	}
+
+	// 自定义SQL或有筛选条件时，首页执行前检查查询代价
+	if req.NextToken == "" && !config.Spool && (isSQLMode || hasConfigFilter) {
+		if costWarnings, err = s.checkQueryCost(q, &config, cq, &filter); err != nil {
+			return nil, err
+		}
+	}
+
+	// 值映射在筛选条件构建之后应用，筛选仍按源值进行
+	if conv.valueMaps, err = s.applyValueMappings(q, &config, fields); err != nil {
+		return nil, err
+	}
+
+	var total int
 	var records []models.Record
+	var hasMore bool
+	var warnings []string
+	var deleted []models.Record
+	next := PageToken{SyncID: syncID, Watermark: window, Phase: token.Phase, FilterTime: filterTime, Vanished: token.Vanished}
 
 	// 根据取数模式获取数据
-	if config.QueryMode == "sql" && config.CustomSQL != "" {
-		total, err = s.getSQLRecordCount(db, config.CustomSQL)
+	switch {
+	case token.Phase == pagePhaseDeleted:
+		// 数据已读取完毕，本页只输出墓碑记录
+		total = offset
+		next.Offset = offset
+	case changeSource != nil && window.Low != nil:
+		// CDC增量：从变更日志读取上次同步之后的变更
+		page, err := s.getChangeRecords(changeSource, config.Table, fields, conv, window, maxResults)
 		if err != nil {
 			return nil, err
 		}
-		fields, err = s.getSQLSchema(db, config.CustomSQL)
+		records = page.records
+		total = offset + page.remaining
+		hasMore = page.hasMore
+		next.Offset = offset + page.consumed
+		next.Watermark = page.window
+		deleted = page.deleted
+		if len(deleted) > 0 && config.DeleteMode == "" {
+			warnings = append(warnings, fmt.Sprintf("变更日志中有 %d 条删除的记录未同步", len(deleted)))
+		}
+	case sp != nil:
+		// 查询结果缓存：每次同步只执行一次查询，分页从缓存读取
+		records, err = s.getSpoolRecords(sp, fields, conv, offset, maxResults)
 		if err != nil {
 			return nil, err
 		}
-		records, err = s.getSQLRecords(db, config.CustomSQL, fields, offset, maxResults)
-	} else {
-		total, err = s.getRecordCount(db, config.Table)
-		if err != nil {
-			return nil, err
+		total = sp.RowCount
+		next.SpoolID = sp.ID
+		next.Offset = offset + maxResults
+		hasMore = next.Offset < total
+	default:
+		// 每次同步只在首页统计总数，后续分页沿用游标中的值
+		total = token.Total
+		if offset == 0 {
+			if total, err = s.countRecords(q, &config, cq, &filter); err != nil {
+				return nil, err
+			}
+		}
+
+		// 多取一条判断是否还有下一页，不依赖总数
+		if isSQLMode {
+			records, err = s.getSQLRecords(q, cq, fields, conv, &filter, offset, maxResults+1)
+		} else {
+			records, err = s.getTableRecords(q, config.Table, fields, conv, &filter, offset, maxResults+1)
 		}
-		fields, err = s.getTableSchema(db, config.Database, config.Table)
 		if err != nil {
 			return nil, err
 		}
-		records, err = s.getTableRecords(db, config.Table, fields, offset, maxResults)
+		if len(records) > maxResults {
+			hasMore = true
+			records = records[:maxResults]
+		}
+		next.Offset = offset + len(records)
+		next.Total = total
+
+		// 估算的总数与实际读取的记录数不一致时修正
+		if config.CountMode != CountModeNone && (!hasMore || total < next.Offset) {
+			total = next.Offset
+		}
 	}
-	if err != nil {
+
+	// 计算字段不参与查询，在读取数据后追加到字段列表
+	if fields, err = outputFields(fields, conv.computed, config.DeleteMode); err != nil {
 		return nil, err
 	}
 
-	// 应用字段映射到记录
-	records = s.applyRecordFieldMappings(records, config.FieldMappings)
+	// 增量同步：跳过上次同步已输出的边界记录，并暂存本次同步的边界记录
+	if config.SyncMode == SyncModeIncremental && token.Phase != pagePhaseDeleted {
+		if records, err = applyWatermarkBoundary(targetKey, syncID, window, watermarkField, records); err != nil {
+			return nil, err
+		}
+	}
+
+	// 检测本次同步内的重复记录ID
+	records, duplicateWarnings := recordTracker.filter(syncID, req.NextToken, records)
+
+	// 记录集合跟踪：保存本次出现的记录及内容摘要，全量读取完毕后找出已消失的记录
+	if config.DeleteMode != "" || config.ChangedOnly {
+		tracker := &syncTracker{
+			deleteMode:  config.DeleteMode,
+			changedOnly: config.ChangedOnly,
+			targetKey:   targetKey,
+			syncID:      syncID,
+			source:      syncSourceName(&config),
+			syncMode:    config.SyncMode,
+			fullScan:    window == nil || window.Low == nil,
+		}
+		if records, hasMore, err = tracker.trackPage(records, deleted, &next, hasMore, maxResults); err != nil {
+			return nil, fmt.Errorf("记录同步状态失败: %w", err)
+		}
+	}
+
+	if !hasMore {
+		recordTracker.finish(syncID)
+		snapshotDone = true
+	}
 
 	// 计算下一页token
-	nextOffset := offset + maxResults
-	hasMore := nextOffset < total
 	nextToken := ""
 	if hasMore {
-		nextToken = fmt.Sprintf("offset:%d", nextOffset)
+		nextToken = next.Encode()
 	}
 
+	// 增量同步完成后保存水位，下次同步从该水位之后开始
+	if !hasMore && window != nil {
+		column := config.WatermarkColumn
+		if changeSource != nil {
+			column = cdcWatermarkColumn
+		}
+		if err := s.saveWatermark(targetKey, syncID, &config, column, window); err != nil {
+			return nil, fmt.Errorf("保存同步水位失败: %w", err)
+		}
+	}
+
+	// 应用字段映射到记录
+	records = s.applyRecordFieldMappings(records, config.FieldMappings)
+
 	return &models.RecordsResponse{
 		NextToken: nextToken,
 		HasMore:   hasMore,
 		Records:   records,
 		Total:     total,
+		Fields:    fields,
+		Warnings:  append(append(append(costWarnings, conv.Warnings()...), warnings...), duplicateWarnings...),
 	}, nil
 }
 
 // connectDB 连接MySQL数据库
+// 配置了源库时区时会话时区设为该时区，未配置时沿用源库默认时区(视为与服务器本地时区一致)，
+// 因此 TIMESTAMP 与 DATETIME 一样按源库时区的墙上时间返回，NOW() 等函数也按源库时区计算
 func (s *MySQLService) connectDB(config *models.MySQLConfig) (*sql.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
 		config.Username,
 		config.Password,
 		config.Host,
 		config.Port,
 		config.Database,
 	)
+	if config.TimeZone != "" {
+		if _, err := sourceLocation(config); err != nil {
+			return nil, err
+		}
+		dsn += "&time_zone=" + url.QueryEscape("'"+config.TimeZone+"'")
+	}
 
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
@@ -233,20 +526,30 @@ func (s *MySQLService) connectDB(config *models.MySQLConfig) (*sql.DB, error) {
 	// 测试连接
 	if err := db.Ping(); err != nil {
 		db.Close()
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrUnknownTimeZone {
+			return nil, fmt.Errorf("源库未加载时区 %s，请使用 +08:00 形式的时区: %w", config.TimeZone, err)
+		}
 		return nil, fmt.Errorf("数据库连接测试失败: %w", err)
 	}
 
 	return db, nil
 }
 
+// mysqlErrUnknownTimeZone 源库未加载时区表时设置命名时区返回的错误码
+const mysqlErrUnknownTimeZone = 1298
+
 // getTableSchema 获取表结构
 func (s *MySQLService) getTableSchema(db *sql.DB, database, table string) ([]models.Field, error) {
 	query := `
 		SELECT
 			COLUMN_NAME,
 			DATA_TYPE,
+			COLUMN_TYPE,
 			COLUMN_KEY,
-			COLUMN_COMMENT
+			COLUMN_COMMENT,
+			NUMERIC_PRECISION,
+			NUMERIC_SCALE
 		FROM INFORMATION_SCHEMA.COLUMNS
 		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
 		ORDER BY ORDINAL_POSITION
@@ -260,23 +563,15 @@ func (s *MySQLService) getTableSchema(db *sql.DB, database, table string) ([]mod
 
 	var fields []models.Field
 	for rows.Next() {
-		var columnName, dataType, columnKey, columnComment string
-		if err := rows.Scan(&columnName, &dataType, &columnKey, &columnComment); err != nil {
+		var columnName, dataType, columnType, columnKey, columnComment string
+		var precision, scale sql.NullInt64
+		if err := rows.Scan(&columnName, &dataType, &columnType, &columnKey, &columnComment, &precision, &scale); err != nil {
 			return nil, err
 		}
 
-		field := models.Field{
-			ID:          fmt.Sprintf("fid_%s", columnName),
-			Name:        columnName,
-			Type:        s.mapMySQLTypeToAITable(dataType),
-			IsPrimary:   columnKey == "PRI",
-			Description: columnComment,
-		}
-
-		// 设置字段属性
-		if field.Type == "number" {
-			field.Property = s.getNumberProperty(dataType)
-		}
+		field := s.buildField(columnName, dataType, columnType, nullIntOr(precision, -1), nullIntOr(scale, -1))
+		field.IsPrimary = columnKey == "PRI"
+		field.Description = columnComment
 
 		fields = append(fields, field)
 	}
@@ -285,201 +580,78 @@ func (s *MySQLService) getTableSchema(db *sql.DB, database, table string) ([]mod
 }
 
 // getRecordCount 获取记录总数
-func (s *MySQLService) getRecordCount(db *sql.DB, table string) (int, error) {
+func (s *MySQLService) getRecordCount(db queryer, table string, filter *queryFilter) (int, error) {
 	var count int
-	query := fmt.Sprintf("SELECT COUNT(*) FROM `%s`", table)
-	err := db.QueryRow(query).Scan(&count)
+	query := fmt.Sprintf("SELECT COUNT(*) FROM `%s`%s", table, filter.whereClause())
+	err := db.QueryRow(query, filter.args...).Scan(&count)
 	return count, err
 }
 
 // getTableRecords 获取表记录
-func (s *MySQLService) getTableRecords(db *sql.DB, table string, fields []models.Field, offset, limit int) ([]models.Record, error) {
+func (s *MySQLService) getTableRecords(db queryer, table string, fields []models.Field, conv *valueConverter, filter *queryFilter, offset, limit int) ([]models.Record, error) {
 	// 构建字段列表
 	var columnNames []string
 	for _, field := range fields {
-		// 从字段ID中提取列名 (fid_xxx -> xxx)
-		columnName := strings.TrimPrefix(field.ID, "fid_")
-		columnNames = append(columnNames, fmt.Sprintf("`%s`", columnName))
+		columnNames = append(columnNames, fmt.Sprintf("`%s`", field.Column))
 	}
 
-	query := fmt.Sprintf("SELECT %s FROM `%s` LIMIT ? OFFSET ?",
+	// 按键列排序，保证分页稳定
+	var keyOrder []string
+	for _, col := range keyColumns(fields) {
+		keyOrder = append(keyOrder, quoteIdentifier(col))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM `%s`%s%s LIMIT ? OFFSET ?",
 		strings.Join(columnNames, ", "),
 		table,
+		filter.whereClause(),
+		filter.orderClause(keyOrder...),
 	)
 
-	rows, err := db.Query(query, limit, offset)
+	args := append(append([]interface{}{}, filter.args...), limit, offset)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("查询记录失败: %w", err)
 	}
 	defer rows.Close()
 
-	// 获取列信息
-	columns, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
-
-	// 构建字段类型映射
-	fieldTypeMap := make(map[string]string)
-	for _, f := range fields {
-		columnName := strings.TrimPrefix(f.ID, "fid_")
-		fieldTypeMap[columnName] = f.Type
-	}
-
-	var records []models.Record
-	for rows.Next() {
-		// 创建扫描目标
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, err
-		}
-
-		// 构建记录
-		record := models.Record{
-			Fields: make(map[string]interface{}),
-		}
-
-		for i, col := range columns {
-			fieldID := fmt.Sprintf("fid_%s", col)
-			value := values[i]
-			fieldType := fieldTypeMap[col]
-
-			// 根据字段类型正确转换数据
-			record.Fields[fieldID] = s.convertValue(value, fieldType)
-
-			// 第一个字段作为记录ID(通常是主键)
-			if i == 0 && value != nil {
-				record.ID = fmt.Sprintf("%v", value)
-			}
-		}
-
-		records = append(records, record)
-	}
-
-	return records, rows.Err()
+	return s.scanRecords(rows, fields, conv)
 }
 
-// mapMySQLTypeToAITable 映射MySQL类型到AI表格类型
-func (s *MySQLService) mapMySQLTypeToAITable(mysqlType string) string {
-	mysqlType = strings.ToLower(mysqlType)
-
-	// 数字类型
-	if strings.Contains(mysqlType, "int") ||
-		strings.Contains(mysqlType, "decimal") ||
-		strings.Contains(mysqlType, "float") ||
-		strings.Contains(mysqlType, "double") ||
-		strings.Contains(mysqlType, "numeric") ||
-		strings.Contains(mysqlType, "real") ||
-		strings.Contains(mysqlType, "money") ||
-		mysqlType == "bit" {
-		return "number"
-	}
-
-	// 日期类型
-	if strings.Contains(mysqlType, "date") ||
-		strings.Contains(mysqlType, "time") {
-		return "date"
+// recordSource 取数来源(表名或自定义SQL子查询)及其参数
+func (s *MySQLService) recordSource(config *models.MySQLConfig, cq *customQuery) (string, []interface{}) {
+	if cq != nil {
+		return cq.subquery(), cq.args
 	}
-
-	// 默认为文本
-	return "text"
+	return quoteIdentifier(config.Table), nil
 }
 
-// convertValue 根据字段类型转换值
-func (s *MySQLService) convertValue(value interface{}, fieldType string) interface{} {
-	if value == nil {
-		return nil
-	}
-
-	// 数字类型处理
-	if fieldType == "number" {
-		return s.toNumber(value)
-	}
-
-	// 非数字类型，转换为字符串
-	switch v := value.(type) {
-	case []byte:
-		return string(v)
-	case string:
-		return v
-	case int64:
-		return v
-	case float64:
-		return v
-	default:
-		return fmt.Sprintf("%v", v)
+// resolveQueryMode 将已发布查询和多表关联模式解析为自定义SQL取数
+func resolveQueryMode(config *models.MySQLConfig) error {
+	if err := resolveSavedQuery(config); err != nil {
+		return err
 	}
+	return resolveJoinQuery(config)
 }
 
-// toNumber 将值转换为数字类型
-func (s *MySQLService) toNumber(value interface{}) interface{} {
-	if value == nil {
-		return nil
-	}
-
-	switch v := value.(type) {
-	case int64:
-		return v
-	case int32:
-		return int64(v)
-	case int:
-		return int64(v)
-	case float64:
-		return v
-	case float32:
-		return float64(v)
-	case []byte:
-		strVal := string(v)
-		if strVal == "" {
-			return nil
-		}
-		if f, err := strconv.ParseFloat(strVal, 64); err == nil {
-			return f
-		}
-		return nil
-	case string:
-		if v == "" {
-			return nil
-		}
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			return f
-		}
-		return nil
-	default:
-		// 尝试转换任何其他类型
-		strVal := fmt.Sprintf("%v", v)
-		if strVal == "" {
-			return nil
-		}
-		if f, err := strconv.ParseFloat(strVal, 64); err == nil {
-			return f
-		}
-		return nil
+// resolveCustomQuery 解析自定义SQL的模板变量，now 为时间函数的基准时间
+// 时间变量按源库时区格式化，connectDB 保证会话时区与之一致
+func (s *MySQLService) resolveCustomQuery(config *models.MySQLConfig, ctx models.Context, now time.Time) (*customQuery, error) {
+	location, err := sourceLocation(config)
+	if err != nil {
+		return nil, err
 	}
+	return resolveCustomSQL(config, ctx, location, now)
 }
 
-// getNumberProperty 获取数字类型属性
-func (s *MySQLService) getNumberProperty(mysqlType string) map[string]interface{} {
-	mysqlType = strings.ToLower(mysqlType)
-
-	if strings.Contains(mysqlType, "int") {
-		return map[string]interface{}{
-			"formatter": "INT",
-		}
-	}
-
-	if strings.Contains(mysqlType, "decimal") || strings.Contains(mysqlType, "float") {
-		return map[string]interface{}{
-			"formatter": "FLOAT_2",
+// findFieldByColumn 按源列名查找字段
+func findFieldByColumn(fields []models.Field, column string) (models.Field, bool) {
+	for _, f := range fields {
+		if f.Column == column {
+			return f, true
 		}
 	}
-
-	return nil
+	return models.Field{}, false
 }
 
 // applyFieldMappings 应用字段映射到字段列表
@@ -540,18 +712,35 @@ func (s *MySQLService) applyRecordFieldMappings(records []models.Record, mapping
 }
 
 // getSQLSchema 通过执行SQL获取结果集的字段结构
-func (s *MySQLService) getSQLSchema(db *sql.DB, customSQL string) ([]models.Field, error) {
+func (s *MySQLService) getSQLSchema(db *sql.DB, cq *customQuery) ([]models.Field, error) {
 	// 添加 LIMIT 1 来只获取一行用于分析结构
-	query := fmt.Sprintf("SELECT * FROM (%s) AS t LIMIT 1", strings.TrimSuffix(strings.TrimSpace(customSQL), ";"))
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT 1", cq.subquery())
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, cq.args...)
 	if err != nil {
 		return nil, fmt.Errorf("执行SQL失败: %w", err)
 	}
 	defer rows.Close()
 
-	// 获取列信息
-	columns, err := rows.Columns()
+	columns, err := readResultColumns(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildSQLFields(db, columns), nil
+}
+
+// resultColumn 结果集列信息
+type resultColumn struct {
+	Name      string
+	DBType    string // 结果集报告的类型(小写，如 varchar、decimal)
+	Precision int    // 数值精度，-1表示未知
+	Scale     int    // 数值小数位数，-1表示未知
+}
+
+// readResultColumns 读取结果集的列信息
+func readResultColumns(rows *sql.Rows) ([]resultColumn, error) {
+	names, err := rows.Columns()
 	if err != nil {
 		return nil, fmt.Errorf("获取列信息失败: %w", err)
 	}
@@ -561,70 +750,122 @@ func (s *MySQLService) getSQLSchema(db *sql.DB, customSQL string) ([]models.Fiel
 		return nil, fmt.Errorf("获取列类型失败: %w", err)
 	}
 
-	// 获取当前数据库所有字段的备注映射
-	commentMap := s.getAllColumnComments(db)
-
-	var fields []models.Field
-	for i, col := range columns {
-		dbType := ""
+	columns := make([]resultColumn, len(names))
+	for i, name := range names {
+		columns[i] = resultColumn{Name: name, Precision: -1, Scale: -1}
 		if i < len(columnTypes) {
-			dbType = columnTypes[i].DatabaseTypeName()
+			columns[i].DBType = strings.ToLower(columnTypes[i].DatabaseTypeName())
+			if p, sc, ok := columnTypes[i].DecimalSize(); ok {
+				columns[i].Precision, columns[i].Scale = int(p), int(sc)
+			}
 		}
+	}
+	return columns, nil
+}
 
-		field := models.Field{
-			ID:          fmt.Sprintf("fid_%s", col),
-			Name:        col,
-			Type:        s.mapMySQLTypeToAITable(dbType),
-			IsPrimary:   i == 0,
-			Description: commentMap[col],
-		}
+// buildSQLFields 根据结果集列信息生成字段定义
+func (s *MySQLService) buildSQLFields(db *sql.DB, columns []resultColumn) []models.Field {
+	// 获取当前数据库所有字段的备注和列类型
+	columnInfoMap := s.getColumnInfoMap(db)
 
-		if field.Type == "number" {
-			field.Property = s.getNumberProperty(dbType)
+	var fields []models.Field
+	for _, c := range columns {
+		col, dbType := c.Name, c.DBType
+
+		// 结果集只提供粗粒度类型(如 GEOMETRY、TINYINT)，尽量用同名源列的 COLUMN_TYPE 细化
+		dataType, columnType := dbType, dbType
+		precision, scale := c.Precision, c.Scale
+		info, ok := columnInfoMap[col]
+		if ok && isCompatibleColumnType(dbType, baseColumnType(info.ColumnType)) {
+			dataType = baseColumnType(info.ColumnType)
+			columnType = info.ColumnType
+			if info.Precision >= 0 {
+				precision, scale = info.Precision, info.Scale
+			}
 		}
 
+		field := s.buildField(col, dataType, columnType, precision, scale)
+		field.Description = info.Comment
+
 		fields = append(fields, field)
 	}
 
-	return fields, nil
+	return fields
+}
+
+// columnInfo 源列信息
+type columnInfo struct {
+	ColumnType string
+	Comment    string
+	Precision  int // 数值精度，-1表示未知
+	Scale      int // 数值小数位数，-1表示未知
 }
 
-// getAllColumnComments 获取当前数据库所有表的字段备注
-func (s *MySQLService) getAllColumnComments(db *sql.DB) map[string]string {
-	commentMap := make(map[string]string)
+// isCompatibleColumnType 判断结果集列类型与源列类型是否一致
+func isCompatibleColumnType(dbType, sourceType string) bool {
+	if dbType == sourceType {
+		return true
+	}
+	switch dbType {
+	case "geometry":
+		return spatialTypes[sourceType]
+	case "char":
+		// 部分驱动版本将 ENUM/SET 报告为 CHAR
+		return sourceType == "enum" || sourceType == "set"
+	}
+	return false
+}
+
+// nullIntOr 读取可空整数，为NULL时返回默认值
+func nullIntOr(v sql.NullInt64, def int) int {
+	if !v.Valid {
+		return def
+	}
+	return int(v.Int64)
+}
+
+// getColumnInfoMap 获取当前数据库所有表的字段备注和列类型
+func (s *MySQLService) getColumnInfoMap(db *sql.DB) map[string]columnInfo {
+	infoMap := make(map[string]columnInfo)
 
 	query := `
-		SELECT COLUMN_NAME, COLUMN_COMMENT
+		SELECT COLUMN_NAME, COLUMN_TYPE, COLUMN_COMMENT, NUMERIC_PRECISION, NUMERIC_SCALE
 		FROM INFORMATION_SCHEMA.COLUMNS
-		WHERE TABLE_SCHEMA = DATABASE() AND COLUMN_COMMENT != ''
+		WHERE TABLE_SCHEMA = DATABASE()
 	`
 
 	rows, err := db.Query(query)
 	if err != nil {
-		return commentMap
+		return infoMap
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var columnName, columnComment string
-		if err := rows.Scan(&columnName, &columnComment); err != nil {
+		var columnName, columnType, columnComment string
+		var precision, scale sql.NullInt64
+		if err := rows.Scan(&columnName, &columnType, &columnComment, &precision, &scale); err != nil {
 			continue
 		}
-		// 如果同名字段有多个备注，保留第一个
-		if _, exists := commentMap[columnName]; !exists {
-			commentMap[columnName] = columnComment
+		// 如果同名字段有多个，优先保留带备注的第一个
+		if existing, exists := infoMap[columnName]; !exists || (existing.Comment == "" && columnComment != "") {
+			infoMap[columnName] = columnInfo{
+				ColumnType: columnType,
+				Comment:    columnComment,
+				Precision:  nullIntOr(precision, -1),
+				Scale:      nullIntOr(scale, -1),
+			}
 		}
 	}
 
-	return commentMap
+	return infoMap
 }
 
 // getSQLRecordCount 获取自定义SQL的记录总数
-func (s *MySQLService) getSQLRecordCount(db *sql.DB, customSQL string) (int, error) {
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS t", strings.TrimSuffix(strings.TrimSpace(customSQL), ";"))
+func (s *MySQLService) getSQLRecordCount(db queryer, cq *customQuery, filter *queryFilter) (int, error) {
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", cq.subquery(), filter.whereClause())
 
 	var count int
-	err := db.QueryRow(countQuery).Scan(&count)
+	err := db.QueryRow(countQuery, cq.bind(filter.args...)...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("获取记录数失败: %w", err)
 	}
@@ -633,73 +874,81 @@ func (s *MySQLService) getSQLRecordCount(db *sql.DB, customSQL string) (int, err
 }
 
 // getSQLRecords 获取自定义SQL的记录数据(分页)
-func (s *MySQLService) getSQLRecords(db *sql.DB, customSQL string, fields []models.Field, offset, limit int) ([]models.Record, error) {
-	query := fmt.Sprintf("SELECT * FROM (%s) AS t LIMIT %d OFFSET %d",
-		strings.TrimSuffix(strings.TrimSpace(customSQL), ";"),
+func (s *MySQLService) getSQLRecords(db queryer, cq *customQuery, fields []models.Field, conv *valueConverter, filter *queryFilter, offset, limit int) ([]models.Record, error) {
+	// 自定义SQL默认保持原有顺序；有排序条件时追加键列保证分页稳定
+	orderClause := ""
+	if len(filter.orderBy) > 0 {
+		var keyOrder []string
+		for _, col := range keyColumns(fields) {
+			keyOrder = append(keyOrder, quoteIdentifier(col))
+		}
+		orderClause = filter.orderClause(keyOrder...)
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s%s%s LIMIT %d OFFSET %d",
+		cq.subquery(),
+		filter.whereClause(),
+		orderClause,
 		limit,
 		offset,
 	)
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, cq.bind(filter.args...)...)
 	if err != nil {
 		return nil, fmt.Errorf("执行SQL失败: %w", err)
 	}
 	defer rows.Close()
 
-	columns, err := rows.Columns()
-	if err != nil {
+	return s.scanRecords(rows, fields, conv)
+}
+
+// PreviewSQL 预览SQL执行结果（获取字段列表、数据预览及模板变量替换后的SQL）
+func (s *MySQLService) PreviewSQL(config *models.MySQLConfig, ctx models.Context) (*models.PreviewResponse, error) {
+	if err := resolveQueryMode(config); err != nil {
 		return nil, err
 	}
 
-	// 构建字段类型映射
-	fieldTypeMap := make(map[string]string)
-	for _, f := range fields {
-		columnName := strings.TrimPrefix(f.ID, "fid_")
-		fieldTypeMap[columnName] = f.Type
+	now := time.Now()
+	cq, err := s.resolveCustomQuery(config, ctx, now)
+	if err != nil {
+		return nil, err
 	}
 
-	var records []models.Record
-	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, err
-		}
-
-		record := models.Record{
-			Fields: make(map[string]interface{}),
-		}
-
-		for i, col := range columns {
-			fieldID := fmt.Sprintf("fid_%s", col)
-			value := values[i]
-			fieldType := fieldTypeMap[col]
-
-			// 根据字段类型正确转换数据
-			record.Fields[fieldID] = s.convertValue(value, fieldType)
-
-			if i == 0 && value != nil {
-				record.ID = fmt.Sprintf("%v", value)
-			}
-		}
-
-		records = append(records, record)
+	db, err := s.connectDB(config)
+	if err != nil {
+		return nil, err
 	}
+	defer db.Close()
 
-	return records, rows.Err()
-}
+	fields, err := s.getSQLSchema(db, cq)
+	if err != nil {
+		return nil, err
+	}
+	if fields, err = resolveKeyFields(fields, config.PrimaryKeys); err != nil {
+		return nil, err
+	}
 
-// PreviewSQL 预览SQL执行结果（获取字段列表）
-func (s *MySQLService) PreviewSQL(config *models.MySQLConfig) ([]models.Field, error) {
-	db, err := s.connectDB(config)
+	preview, err := s.previewData(db, config, cq, fields, now)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
+	preview.ResolvedSQL = cq.text
+	preview.Params = cq.params
+	return preview, nil
+}
 
-	return s.getSQLSchema(db, config.CustomSQL)
+// outputFields 在源字段后追加计算字段和删除标记字段，得到输出的字段列表
+// 表结构和记录都由此得到字段列表，保证两者的飞书 fieldID 分配一致
+func outputFields(fields []models.Field, computed []computedField, deleteMode string) ([]models.Field, error) {
+	fields = append(fields, computedFieldList(computed)...)
+	return appendDeleteMarkerField(fields, deleteMode)
+}
+
+// assignFeishuFieldIDs 填充字段对应的飞书 fieldID，供前端展示字段与ID的对应关系
+// output 为同步时输出的完整字段列表，fieldID 按该列表分配，与表结构中的一致
+func assignFeishuFieldIDs(fields, output []models.Field) {
+	ids := models.FeishuFieldIDs(output)
+	for i := range fields {
+		fields[i].FeishuFieldID = ids[fields[i].ID]
+	}
 }
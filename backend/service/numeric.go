@@ -0,0 +1,232 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"mysql-sync-plugin/models"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 平台前端以双精度浮点解析数字，超出以下范围的数值会丢失精度
+const (
+	maxSafeInteger     = 1<<53 - 1 // 2^53-1
+	maxSafeSignificant = 15        // float64 可无损往返的十进制有效位数
+)
+
+// 十进制数字文本
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// mayExceedSafeNumber 判断数值列的取值是否可能超出安全精度
+func mayExceedSafeNumber(field models.Field) bool {
+	switch baseColumnType(field.SourceType) {
+	case "bigint":
+		return true
+	case "decimal", "numeric":
+		// 精度未知时按可能超出处理
+		return field.Precision < 0 || field.Precision > maxSafeSignificant
+	}
+	return false
+}
+
+// numberWarnings 数值精度告警统计
+type numberWarnings struct {
+	counts  map[string]int    // 字段名 -> 超出安全精度的值个数
+	samples map[string]string // 字段名 -> 示例值
+}
+
+func (w *numberWarnings) add(field, sample string) {
+	if w.counts == nil {
+		w.counts = make(map[string]int)
+		w.samples = make(map[string]string)
+	}
+	w.counts[field]++
+	if _, ok := w.samples[field]; !ok {
+		w.samples[field] = sample
+	}
+}
+
+// messages 生成告警文本
+func (w *numberWarnings) messages() []string {
+	var names []string
+	for name := range w.counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []string
+	for _, name := range names {
+		result = append(result, fmt.Sprintf("字段 %s 有 %d 个值超出安全精度范围(示例: %s)，平台端可能丢失精度，可开启 unsafeNumberAsString 按文本输出",
+			name, w.counts[name], w.samples[name]))
+	}
+	return result
+}
+
+// toNumber 将值无损转换为数字
+// 安全范围内的值输出 int64/float64；超出安全精度的值以 json.Number 原样输出并记录告警
+func (c *valueConverter) toNumber(value interface{}, field models.Field) interface{} {
+	var text string
+	switch v := value.(type) {
+	case int64:
+		if field.Divisor == 0 && v <= maxSafeInteger && v >= -maxSafeInteger {
+			return v
+		}
+		text = strconv.FormatInt(v, 10)
+	case uint64:
+		text = strconv.FormatUint(v, 10)
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case float32:
+		return applyDivisor(float64(v), field.Divisor)
+	case float64:
+		// 浮点列本身不具备更高精度，直接输出
+		return applyDivisor(v, field.Divisor)
+	case []byte:
+		if baseColumnType(field.SourceType) == "bit" {
+			// BIT(n) 以大端原始字节返回
+			var n uint64
+			for _, b := range v {
+				n = n<<8 | uint64(b)
+			}
+			text = strconv.FormatUint(n, 10)
+		} else {
+			text = strings.TrimSpace(string(v))
+		}
+	case string:
+		text = strings.TrimSpace(v)
+	default:
+		text = strings.TrimSpace(fmt.Sprintf("%v", v))
+	}
+
+	if text == "" || !decimalPattern.MatchString(text) {
+		return nil
+	}
+
+	if field.Divisor != 0 && field.Divisor != 1 {
+		text = divideDecimal(text, field.Divisor)
+	}
+
+	if isSafeDecimal(text) {
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return i
+		}
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	}
+
+	c.numberWarnings.add(field.Name, text)
+	return json.Number(normalizeDecimal(text))
+}
+
+// toExactText 将数值以精确的十进制文本输出
+func toExactText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	}
+	return "", false
+}
+
+// isSafeDecimal 判断十进制文本能否被双精度浮点无损表示
+func isSafeDecimal(text string) bool {
+	mantissa := strings.TrimLeft(text, "+-")
+	exponent := 0
+	if idx := strings.IndexAny(mantissa, "eE"); idx >= 0 {
+		exp, err := strconv.Atoi(mantissa[idx+1:])
+		if err != nil {
+			return false
+		}
+		exponent = exp
+		mantissa = mantissa[:idx]
+	}
+
+	intPart, fracPart := mantissa, ""
+	if idx := strings.Index(mantissa, "."); idx >= 0 {
+		intPart, fracPart = mantissa[:idx], mantissa[idx+1:]
+	}
+	fracPart = strings.TrimRight(fracPart, "0")
+
+	// 整数：直接比较安全整数范围
+	if fracPart == "" && exponent == 0 {
+		intPart = strings.TrimLeft(intPart, "0")
+		if intPart == "" {
+			return true
+		}
+		n, err := strconv.ParseUint(intPart, 10, 64)
+		return err == nil && n <= maxSafeInteger
+	}
+
+	digits := strings.TrimLeft(intPart+fracPart, "0")
+	if len(digits) > maxSafeSignificant {
+		return false
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	return err == nil && !math.IsInf(f, 0)
+}
+
+// divideDecimal 精确计算 text / divisor
+func divideDecimal(text string, divisor float64) string {
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return text
+	}
+	d := new(big.Rat).SetFloat64(divisor)
+	if d == nil || d.Sign() == 0 {
+		return text
+	}
+	r.Quo(r, d)
+
+	// 保留的小数位：原小数位 + 除数的数量级，最多 10 位
+	scale := 0
+	if idx := strings.Index(text, "."); idx >= 0 {
+		scale = len(text) - idx - 1
+	}
+	scale += int(math.Ceil(math.Log10(math.Abs(divisor))))
+	if scale < 0 {
+		scale = 0
+	}
+	if scale > 10 {
+		scale = 10
+	}
+	return normalizeDecimal(r.FloatString(scale))
+}
+
+// normalizeDecimal 规范化十进制文本为合法的JSON数字(去除前导0、小数末尾0和多余符号)
+func normalizeDecimal(text string) string {
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign = "-"
+		text = text[1:]
+	} else {
+		text = strings.TrimPrefix(text, "+")
+	}
+
+	exponent := ""
+	if idx := strings.IndexAny(text, "eE"); idx >= 0 {
+		text, exponent = text[:idx], text[idx:]
+	}
+
+	intPart, fracPart := text, ""
+	if idx := strings.Index(text, "."); idx >= 0 {
+		intPart, fracPart = text[:idx], text[idx+1:]
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	fracPart = strings.TrimRight(fracPart, "0")
+
+	if fracPart != "" {
+		return sign + intPart + "." + fracPart + exponent
+	}
+	return sign + intPart + exponent
+}
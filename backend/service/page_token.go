@@ -0,0 +1,63 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// 分页游标格式:
+//   - 旧格式 "offset:300"，仅包含偏移量
+//   - 新格式 "v2." + base64url(JSON)，携带同步会话状态
+const pageTokenPrefix = "v2."
+
+// PageToken 分页游标
+type PageToken struct {
+	Offset     int             `json:"o"`           // 下一页偏移量
+	SyncID     string          `json:"s,omitempty"` // 同步会话ID，同一次同步的所有分页共享
+	Watermark  *WatermarkRange `json:"w,omitempty"` // 增量同步的水位范围
+	Phase      string          `json:"p,omitempty"` // 分页阶段，为空时读取数据
+	SpoolID    int64           `json:"c,omitempty"` // 查询结果缓存ID，同一次同步沿用同一份缓存
+	Total      int             `json:"n,omitempty"` // 首页统计的记录总数，后续分页沿用
+	FilterTime int64           `json:"f,omitempty"` // 首页时间(Unix秒)，筛选条件中的相对日期以此为基准
+	Vanished   string          `json:"v,omitempty"` // 墓碑阶段上一页最后一条记录ID，请求本页时上一页已送达
+}
+
+// ParsePageToken 解析分页游标，无法解析时返回零值(从头开始)
+func ParsePageToken(token string) PageToken {
+	var t PageToken
+	if token == "" {
+		return t
+	}
+
+	if strings.HasPrefix(token, pageTokenPrefix) {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, pageTokenPrefix))
+		if err == nil {
+			json.Unmarshal(data, &t)
+		}
+		return t
+	}
+
+	// 兼容旧格式 "offset:300"
+	parts := strings.Split(token, ":")
+	if len(parts) == 2 {
+		t.Offset, _ = strconv.Atoi(parts[1])
+	}
+	return t
+}
+
+// Encode 编码分页游标
+func (t PageToken) Encode() string {
+	data, _ := json.Marshal(t)
+	return pageTokenPrefix + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// newSyncID 生成同步会话ID
+func newSyncID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
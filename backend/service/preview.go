@@ -0,0 +1,201 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"strings"
+	"time"
+)
+
+// previewRowLimit 预览返回的数据行数
+const previewRowLimit = 20
+
+// previewNullRatio 预览数据中空值比例达到该值时提示
+const previewNullRatio = 0.5
+
+// previewStats 预览数据的字段值统计
+type previewStats struct {
+	rows     int
+	nulls    map[string]int    // 源列名 -> 空值个数
+	failures map[string]int    // 源列名 -> 转换失败个数
+	samples  map[string]string // 源列名 -> 转换失败的示例值
+	unsafe   map[string]int    // 源列名 -> 超出安全精度的值个数
+	errors   map[string]int    // 计算字段名 -> 计算失败个数
+}
+
+func newPreviewStats() *previewStats {
+	return &previewStats{
+		nulls:    make(map[string]int),
+		failures: make(map[string]int),
+		samples:  make(map[string]string),
+		unsafe:   make(map[string]int),
+		errors:   make(map[string]int),
+	}
+}
+
+// observe 记录一个值的转换结果
+func (p *previewStats) observe(column string, raw, value interface{}) {
+	switch {
+	case isBlankValue(raw):
+		p.nulls[column]++
+	case value == nil:
+		// 非空的源值转换后为空，同步时该单元格会被清空
+		p.failures[column]++
+		if _, ok := p.samples[column]; !ok {
+			p.samples[column] = limitRunes(toText(raw), 50)
+		}
+	default:
+		if _, ok := value.(json.Number); ok {
+			p.unsafe[column]++
+		}
+	}
+}
+
+// observeError 记录一个计算字段的求值失败
+func (p *previewStats) observeError(column string, err error) {
+	p.errors[column]++
+	if _, ok := p.samples[column]; !ok {
+		p.samples[column] = limitRunes(err.Error(), 50)
+	}
+}
+
+// warnings 生成字段的预览告警
+func (p *previewStats) warnings(field models.Field) []string {
+	var result []string
+	if n := p.errors[field.Column]; n > 0 {
+		result = append(result, fmt.Sprintf("预览的 %d 行中有 %d 行计算失败，同步时将写入空值(示例: %s)",
+			p.rows, n, p.samples[field.Column]))
+	}
+	if n := p.failures[field.Column]; n > 0 {
+		result = append(result, fmt.Sprintf("预览的 %d 行中有 %d 个值无法转换为 %s 类型，同步时将写入空值(示例: %s)",
+			p.rows, n, field.Type, p.samples[field.Column]))
+	}
+	if n := p.unsafe[field.Column]; n > 0 {
+		result = append(result, fmt.Sprintf("预览的 %d 行中有 %d 个值超出安全精度范围，平台端可能丢失精度", p.rows, n))
+	}
+	if n := p.nulls[field.Column]; p.rows > 0 && n == p.rows {
+		result = append(result, fmt.Sprintf("预览的 %d 行中该字段全部为空", p.rows))
+	} else if p.rows > 0 && float64(n)/float64(p.rows) >= previewNullRatio {
+		result = append(result, fmt.Sprintf("预览的 %d 行中 %d%% 为空", p.rows, n*100/p.rows))
+	}
+	return result
+}
+
+// isBlankValue 判断源值是否为空(NULL、空文本或零日期)
+func isBlankValue(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case time.Time:
+		return val.IsZero()
+	case []byte:
+		return isBlankText(string(val))
+	case string:
+		return isBlankText(val)
+	}
+	return false
+}
+
+func isBlankText(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "0000-00-00")
+}
+
+// limitRunes 截断过长的示例文本
+func limitRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
+
+// previewData 按同步时的方式读取前若干行：检查查询代价，应用筛选排序、字段类型覆盖、值转换和字段映射
+// fields 为源字段定义，返回时附加各字段的告警
+func (s *MySQLService) previewData(db *sql.DB, config *models.MySQLConfig, cq *customQuery, fields []models.Field, now time.Time) (*models.PreviewResponse, error) {
+	conv, err := newValueConverter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepareFields 会修改字段定义，在副本上处理以保留返回给前端的源字段
+	syncFields, err := s.prepareFields(append([]models.Field(nil), fields...), config)
+	if err != nil {
+		return nil, err
+	}
+	if conv.computed, err = s.compileComputedFields(config.ComputedFields, syncFields); err != nil {
+		return nil, err
+	}
+
+	var filter queryFilter
+	if config.Filter != nil || len(config.Sort) > 0 {
+		if err := applyConfigFilter(&filter, config, syncFields, conv.location, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// 自定义SQL或有筛选条件时，执行前检查查询代价并展示执行计划摘要
+	var explain *models.ExplainSummary
+	if cq != nil || len(filter.conditions) > 0 {
+		if explain, err = s.guardQueryCost(db, config, cq, &filter, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if conv.valueMaps, err = s.applyValueMappings(db, config, syncFields); err != nil {
+		return nil, err
+	}
+
+	conv.stats = newPreviewStats()
+	start := time.Now()
+	var records []models.Record
+	if cq != nil {
+		records, err = s.getSQLRecords(db, cq, syncFields, conv, &filter, 0, previewRowLimit)
+	} else {
+		records, err = s.getTableRecords(db, config.Table, syncFields, conv, &filter, 0, previewRowLimit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+
+	estimated, err := s.estimateRecords(db, config, cq, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// 告警按同步时的字段类型生成，附加到源字段上
+	for _, f := range syncFields {
+		for i := range fields {
+			if fields[i].Column == f.Column {
+				fields[i].Warnings = conv.stats.warnings(f)
+			}
+		}
+	}
+
+	// 计算字段附加在源字段之后
+	for _, f := range computedFieldList(conv.computed) {
+		f.Warnings = conv.stats.warnings(f)
+		fields = append(fields, f)
+	}
+
+	// 飞书 fieldID 在追加计算字段后按同步时输出的字段列表分配
+	output, err := outputFields(syncFields, conv.computed, config.DeleteMode)
+	if err != nil {
+		return nil, err
+	}
+	assignFeishuFieldIDs(fields, output)
+
+	if records == nil {
+		records = []models.Record{}
+	}
+	return &models.PreviewResponse{
+		Fields:         fields,
+		Records:        s.applyRecordFieldMappings(records, config.FieldMappings),
+		EstimatedTotal: estimated,
+		ElapsedMs:      elapsed.Milliseconds(),
+		Explain:        explain,
+	}, nil
+}
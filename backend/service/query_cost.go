@@ -0,0 +1,221 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mysql-sync-plugin/costguard"
+	"mysql-sync-plugin/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// guardQueryCost 执行前以 EXPLAIN 检查查询代价，超出管理员设置的阈值时按设置拒绝或告警
+// preview 为 true 时即使未开启检查也返回执行计划摘要；未检查时返回nil
+func (s *MySQLService) guardQueryCost(db queryer, config *models.MySQLConfig, cq *customQuery, filter *queryFilter, preview bool) (*models.ExplainSummary, error) {
+	settings, err := costguard.GetStore().Get()
+	if err != nil {
+		return nil, fmt.Errorf("读取查询代价限制失败: %w", err)
+	}
+	if settings.Action == costguard.ActionOff && !preview {
+		return nil, nil
+	}
+
+	source, args := s.recordSource(config, cq)
+	query := fmt.Sprintf("SELECT * FROM %s%s", source, filter.whereClause())
+	summary, err := s.explainQuery(db, query, append(append([]interface{}{}, args...), filter.args...))
+	if err != nil {
+		switch settings.Action {
+		case costguard.ActionReject:
+			return nil, fmt.Errorf("无法检查查询代价: %w", err)
+		case costguard.ActionWarn:
+			return &models.ExplainSummary{Warnings: []string{"无法检查查询代价: " + err.Error()}}, nil
+		}
+		return nil, nil
+	}
+
+	if settings.Action == costguard.ActionOff {
+		return summary, nil
+	}
+	summary.Warnings = evaluateQueryCost(summary, settings)
+	if len(summary.Warnings) > 0 && settings.Action == costguard.ActionReject {
+		return nil, fmt.Errorf("查询代价超出限制，已拒绝执行: %s", strings.Join(summary.Warnings, "; "))
+	}
+	return summary, nil
+}
+
+// checkQueryCost 同步前检查查询代价，返回需要写入同步日志的告警
+func (s *MySQLService) checkQueryCost(db queryer, config *models.MySQLConfig, cq *customQuery, filter *queryFilter) ([]string, error) {
+	summary, err := s.guardQueryCost(db, config, cq, filter, false)
+	if err != nil || summary == nil {
+		return nil, err
+	}
+	return summary.Warnings, nil
+}
+
+// evaluateQueryCost 对照阈值检查执行计划，返回超出的项
+func evaluateQueryCost(summary *models.ExplainSummary, settings *costguard.Settings) []string {
+	var warnings []string
+	if settings.MaxCost > 0 && summary.QueryCost > settings.MaxCost {
+		warnings = append(warnings, fmt.Sprintf("估算查询代价 %.0f 超过上限 %.0f", summary.QueryCost, settings.MaxCost))
+	}
+	if settings.MaxRows > 0 && summary.EstimatedRows > settings.MaxRows {
+		warnings = append(warnings, fmt.Sprintf("估算检查 %d 行，超过上限 %d 行", summary.EstimatedRows, settings.MaxRows))
+	}
+	if settings.FullScanRows > 0 {
+		for _, t := range summary.Tables {
+			// 派生表、临时表(<derived2> 等)不是源表，不按全表扫描处理
+			if t.AccessType == "ALL" && !strings.HasPrefix(t.Table, "<") && t.Rows > settings.FullScanRows {
+				warnings = append(warnings, fmt.Sprintf("表 %s 全表扫描约 %d 行，超过上限 %d 行", t.Table, t.Rows, settings.FullScanRows))
+			}
+		}
+	}
+	return warnings
+}
+
+// explainQuery 以 EXPLAIN FORMAT=JSON 获取查询的执行计划摘要
+func (s *MySQLService) explainQuery(db queryer, query string, args []interface{}) (*models.ExplainSummary, error) {
+	var plan string
+	if err := db.QueryRow("EXPLAIN FORMAT=JSON "+query, args...).Scan(&plan); err != nil {
+		return nil, fmt.Errorf("获取执行计划失败: %w", err)
+	}
+	return parseExplainJSON(plan)
+}
+
+// parseExplainJSON 解析 JSON 格式的执行计划
+func parseExplainJSON(plan string) (*models.ExplainSummary, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal([]byte(plan), &root); err != nil {
+		return nil, fmt.Errorf("解析执行计划失败: %w", err)
+	}
+
+	w := &explainWalker{summary: &models.ExplainSummary{}}
+	w.walk(root)
+
+	// 顶层查询块的代价已包含子查询；UNION 等顶层没有代价时取各查询块之和
+	w.summary.QueryCost = w.cost
+	if block, ok := root["query_block"].(map[string]interface{}); ok {
+		if info, ok := block["cost_info"].(map[string]interface{}); ok {
+			if cost, ok := explainNumber(info["query_cost"]); ok {
+				w.summary.QueryCost = cost
+			}
+		}
+	}
+	if w.examined > math.MaxInt64/2 {
+		w.summary.EstimatedRows = math.MaxInt64 / 2
+	} else {
+		w.summary.EstimatedRows = int64(math.Ceil(w.examined))
+	}
+	return w.summary, nil
+}
+
+// explainWalker 遍历执行计划，收集各表的访问方式和检查行数
+type explainWalker struct {
+	summary  *models.ExplainSummary
+	examined float64 // 估算检查的总行数
+	cost     float64 // 各查询块的代价之和
+}
+
+func (w *explainWalker) walk(node interface{}) {
+	switch v := node.(type) {
+	case []interface{}:
+		for _, item := range v {
+			w.walk(item)
+		}
+	case map[string]interface{}:
+		if info, ok := v["cost_info"].(map[string]interface{}); ok {
+			if cost, ok := explainNumber(info["query_cost"]); ok {
+				w.cost += cost
+			}
+		}
+
+		// 按键名排序遍历，保证表的顺序稳定
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			switch key {
+			case "nested_loop":
+				w.walkNestedLoop(v[key])
+			case "table":
+				if t, ok := v[key].(map[string]interface{}); ok {
+					w.addTable(t, 1)
+					w.walk(t)
+				}
+			default:
+				w.walk(v[key])
+			}
+		}
+	}
+}
+
+// walkNestedLoop 嵌套循环连接：每个表的扫描次数为前序表输出的行数
+func (w *explainWalker) walkNestedLoop(node interface{}) {
+	items, ok := node.([]interface{})
+	if !ok {
+		w.walk(node)
+		return
+	}
+
+	prefix := 1.0
+	for _, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		t, ok := entry["table"].(map[string]interface{})
+		if !ok {
+			w.walk(entry)
+			continue
+		}
+
+		rows := w.addTable(t, prefix)
+		if produced, ok := explainNumber(t["rows_produced_per_join"]); ok {
+			prefix = produced
+		} else {
+			filtered, ok := explainNumber(t["filtered"])
+			if !ok {
+				filtered = 100
+			}
+			prefix = prefix * rows * filtered / 100
+		}
+		w.walk(t)
+	}
+}
+
+// addTable 记录一个表的访问方式，返回每次扫描检查的行数
+func (w *explainWalker) addTable(t map[string]interface{}, prefix float64) float64 {
+	rows, ok := explainNumber(t["rows_examined_per_scan"])
+	if !ok {
+		// MariaDB 使用 rows
+		rows, _ = explainNumber(t["rows"])
+	}
+	w.examined += prefix * rows
+
+	name, _ := t["table_name"].(string)
+	access, _ := t["access_type"].(string)
+	key, _ := t["key"].(string)
+	w.summary.Tables = append(w.summary.Tables, models.ExplainTable{
+		Table:      name,
+		AccessType: access,
+		Key:        key,
+		Rows:       int64(rows),
+	})
+	return rows
+}
+
+// explainNumber 读取执行计划中的数值(MySQL 的代价和比例以字符串输出)
+func explainNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
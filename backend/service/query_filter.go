@@ -0,0 +1,53 @@
+package service
+
+import "strings"
+
+// queryFilter 取数查询的附加条件和排序
+type queryFilter struct {
+	conditions []string      // WHERE 条件(参数化)
+	args       []interface{} // 条件参数
+	orderBy    []string      // 排序列(已转义)
+}
+
+// where 追加一个 WHERE 条件
+func (f *queryFilter) where(condition string, args ...interface{}) {
+	f.conditions = append(f.conditions, condition)
+	f.args = append(f.args, args...)
+}
+
+// whereClause 生成 WHERE 子句，无条件时返回空串
+func (f *queryFilter) whereClause() string {
+	if len(f.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.conditions, " AND ")
+}
+
+// orderClause 生成 ORDER BY 子句，extra 为追加在其后的排序列(用于保证分页稳定)
+func (f *queryFilter) orderClause(extra ...string) string {
+	columns := append([]string{}, f.orderBy...)
+	for _, col := range extra {
+		if !containsString(columns, col) {
+			columns = append(columns, col)
+		}
+	}
+	if len(columns) == 0 {
+		return ""
+	}
+	return " ORDER BY " + strings.Join(columns, ", ")
+}
+
+// quoteIdentifier 转义MySQL标识符
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// containsString 判断字符串切片是否包含指定值
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"mysql-sync-plugin/models"
+	"strconv"
+	"strings"
+)
+
+// 总数统计方式
+const (
+	CountModeExact    = "exact"    // 精确 COUNT(*)(默认)
+	CountModeEstimate = "estimate" // 估算：整表使用 information_schema.TABLES.TABLE_ROWS，有过滤条件或自定义SQL使用 EXPLAIN 行数
+	CountModeNone     = "none"     // 不统计总数
+)
+
+// validCountMode 校验总数统计方式
+func validCountMode(mode string) bool {
+	switch mode {
+	case "", CountModeExact, CountModeEstimate, CountModeNone:
+		return true
+	}
+	return false
+}
+
+// countRecords 按配置的方式统计记录总数，每次同步只在首页统计一次
+func (s *MySQLService) countRecords(db queryer, config *models.MySQLConfig, cq *customQuery, filter *queryFilter) (int, error) {
+	switch config.CountMode {
+	case CountModeNone:
+		return 0, nil
+	case CountModeEstimate:
+		return s.estimateRecords(db, config, cq, filter)
+	}
+
+	if cq != nil {
+		return s.getSQLRecordCount(db, cq, filter)
+	}
+	return s.getRecordCount(db, config.Table, filter)
+}
+
+// estimateRecords 估算记录总数：整表使用 TABLE_ROWS，有过滤条件或自定义SQL使用 EXPLAIN 行数
+func (s *MySQLService) estimateRecords(db queryer, config *models.MySQLConfig, cq *customQuery, filter *queryFilter) (int, error) {
+	if cq == nil && len(filter.conditions) == 0 {
+		return s.getTableRowsEstimate(db, config.Table)
+	}
+	source, args := s.recordSource(config, cq)
+	query := fmt.Sprintf("SELECT * FROM %s%s", source, filter.whereClause())
+	return s.getExplainRowsEstimate(db, query, append(append([]interface{}{}, args...), filter.args...))
+}
+
+// getTableRowsEstimate 从 information_schema 读取表行数估算值(InnoDB 为统计信息中的近似值)
+func (s *MySQLService) getTableRowsEstimate(db queryer, table string) (int, error) {
+	var rows sql.NullInt64
+	err := db.QueryRow(
+		"SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+		table,
+	).Scan(&rows)
+	if err != nil {
+		return 0, fmt.Errorf("获取估算行数失败: %w", err)
+	}
+	return int(rows.Int64), nil
+}
+
+// getExplainRowsEstimate 以 EXPLAIN 的行数估算查询结果数
+// 取第一个 SELECT 的各表 rows × filtered% 之积，与优化器的连接基数估算一致
+func (s *MySQLService) getExplainRowsEstimate(db queryer, query string, args []interface{}) (int, error) {
+	rows, err := db.Query("EXPLAIN "+query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("获取估算行数失败: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("获取估算行数失败: %w", err)
+	}
+	index := make(map[string]int)
+	for i, col := range columns {
+		index[strings.ToLower(col)] = i
+	}
+
+	estimate := 1.0
+	found := false
+	firstID := ""
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return 0, fmt.Errorf("获取估算行数失败: %w", err)
+		}
+
+		id := explainValue(values, index, "id")
+		if firstID == "" {
+			firstID = id
+		}
+		if id != firstID {
+			continue
+		}
+
+		n, err := strconv.ParseFloat(explainValue(values, index, "rows"), 64)
+		if err != nil {
+			continue
+		}
+		if filtered, err := strconv.ParseFloat(explainValue(values, index, "filtered"), 64); err == nil {
+			n = n * filtered / 100
+		}
+		estimate *= n
+		found = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("获取估算行数失败: %w", err)
+	}
+
+	if !found {
+		return 0, nil
+	}
+	if estimate > math.MaxInt32 {
+		return math.MaxInt32, nil
+	}
+	return int(math.Ceil(estimate)), nil
+}
+
+// explainValue 按列名读取 EXPLAIN 结果中的值
+func explainValue(values []sql.NullString, index map[string]int, column string) string {
+	i, ok := index[column]
+	if !ok || !values[i].Valid {
+		return ""
+	}
+	return values[i].String
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 记录ID编码规则(保证不同键值得到不同ID，且只包含英文、数字、下划线)：
+//   - 英文字母和数字原样保留
+//   - 下划线编码为 "__"
+//   - 其他字节编码为 "_XX"(两位大写十六进制)
+//   - 复合键各列之间以 "_S" 分隔，NULL 编码为 "_N"
+//   - 编码结果超过 maxRecordIDLength 时，截取前缀并追加 "_H" + SHA-256 摘要
+const (
+	recordIDSeparator = "_S"
+	recordIDNull      = "_N"
+	recordIDHash      = "_H"
+	maxRecordIDLength = 128
+)
+
+// resolveKeyFields 确定用于生成记录ID的键列，并将其标记为主键
+// 优先使用配置的 primaryKeys；否则使用表主键；都没有时退回第一列
+func resolveKeyFields(fields []models.Field, keys []string) ([]models.Field, error) {
+	if len(fields) == 0 {
+		return fields, nil
+	}
+
+	if len(keys) > 0 {
+		keySet := make(map[string]bool)
+		for _, k := range keys {
+			keySet[k] = true
+		}
+		found := 0
+		for i := range fields {
+			fields[i].IsPrimary = keySet[fields[i].Column]
+			if fields[i].IsPrimary {
+				found++
+			}
+		}
+		if found != len(keySet) {
+			for _, k := range keys {
+				if !hasColumn(fields, k) {
+					return nil, fmt.Errorf("主键列 %s 不存在", k)
+				}
+			}
+		}
+		return fields, nil
+	}
+
+	for _, f := range fields {
+		if f.IsPrimary {
+			return fields, nil
+		}
+	}
+
+	// 没有主键时使用第一列
+	fields[0].IsPrimary = true
+	return fields, nil
+}
+
+// hasColumn 判断字段列表中是否包含指定列
+func hasColumn(fields []models.Field, column string) bool {
+	for _, f := range fields {
+		if f.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// keyColumns 返回键列名(按字段顺序)
+func keyColumns(fields []models.Field) []string {
+	var columns []string
+	for _, f := range fields {
+		if f.IsPrimary {
+			columns = append(columns, f.Column)
+		}
+	}
+	return columns
+}
+
+// encodeRecordID 将键列的原始值编码为记录ID
+func encodeRecordID(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if v == nil {
+			parts[i] = recordIDNull
+			continue
+		}
+		parts[i] = escapeRecordIDPart(keyValueText(v))
+	}
+
+	id := strings.Join(parts, recordIDSeparator)
+	if len(id) <= maxRecordIDLength {
+		return id
+	}
+
+	// 按转义序列边界截取前缀，避免截断在序列中间
+	cut := 0
+	for cut < len(id) {
+		size := 1
+		if id[cut] == '_' {
+			size = 3
+			if cut+1 < len(id) && (id[cut+1] == '_' || id[cut+1] == 'S' || id[cut+1] == 'N') {
+				size = 2
+			}
+		}
+		if cut+size > maxRecordIDLength/2 {
+			break
+		}
+		cut += size
+	}
+
+	sum := sha256.Sum256([]byte(id))
+	return id[:cut] + recordIDHash + hex.EncodeToString(sum[:16])
+}
+
+// decodeRecordID 解码记录ID为各键列的文本值(NULL 返回 nil)
+// 使用摘要缩短过的ID无法解码
+func decodeRecordID(id string) ([]*string, error) {
+	var parts []*string
+	var current strings.Builder
+	isNull := false
+
+	flush := func() {
+		if isNull {
+			parts = append(parts, nil)
+		} else {
+			s := current.String()
+			parts = append(parts, &s)
+		}
+		current.Reset()
+		isNull = false
+	}
+
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if c != '_' {
+			current.WriteByte(c)
+			continue
+		}
+		if i+1 >= len(id) {
+			return nil, fmt.Errorf("无效的记录ID: %s", id)
+		}
+		switch next := id[i+1]; next {
+		case '_':
+			current.WriteByte('_')
+			i++
+		case 'S':
+			flush()
+			i++
+		case 'N':
+			isNull = true
+			i++
+		case 'H':
+			return nil, fmt.Errorf("记录ID %s 经过摘要缩短，无法解码", id)
+		default:
+			if i+2 >= len(id) {
+				return nil, fmt.Errorf("无效的记录ID: %s", id)
+			}
+			b, err := strconv.ParseUint(id[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("无效的记录ID: %s", id)
+			}
+			current.WriteByte(byte(b))
+			i += 2
+		}
+	}
+	flush()
+
+	return parts, nil
+}
+
+// escapeRecordIDPart 转义单个键值
+func escapeRecordIDPart(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'):
+			b.WriteByte(c)
+		case c == '_':
+			b.WriteString("__")
+		default:
+			fmt.Fprintf(&b, "_%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// keyValueText 将键列原始值转换为规范文本
+func keyValueText(v interface{}) string {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05.999999")
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeRecordID(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   string
+	}{
+		{"英文数字原样保留", []interface{}{"Order42"}, "Order42"},
+		{"下划线", []interface{}{"a_b"}, "a__b"},
+		{"其他字节", []interface{}{"a-b c"}, "a_2Db_20c"},
+		{"多字节字符", []interface{}{"订"}, "_E8_AE_A2"},
+		{"空文本", []interface{}{""}, ""},
+		{"NULL", []interface{}{nil}, "_N"},
+		{"复合键", []interface{}{int64(7), "x", nil}, "7_Sx_S_N"},
+		{"形似分隔符的值", []interface{}{"_S", "_N"}, "__S_S__N"},
+		{"无符号整数", []interface{}{uint64(18446744073709551615)}, "18446744073709551615"},
+		{"浮点数", []interface{}{1.5}, "1_2E5"},
+		{"字节串", []interface{}{[]byte("ab")}, "ab"},
+		{"时间", []interface{}{time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}, "2026_2D01_2D02_2003_3A04_3A05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeRecordID(tt.values); got != tt.want {
+				t.Errorf("encodeRecordID(%v) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRecordID(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   []*string
+	}{
+		{"单列", []interface{}{"abc"}, []*string{str("abc")}},
+		{"空文本", []interface{}{""}, []*string{str("")}},
+		{"NULL", []interface{}{nil}, []*string{nil}},
+		{"转义字符", []interface{}{"a_b-c d/é"}, []*string{str("a_b-c d/é")}},
+		{"形似编码的值", []interface{}{"_S_N_H__", "_2D"}, []*string{str("_S_N_H__"), str("_2D")}},
+		{"复合键含NULL和空文本", []interface{}{nil, "", int64(-1)}, []*string{nil, str(""), str("-1")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := encodeRecordID(tt.values)
+			got, err := decodeRecordID(id)
+			if err != nil {
+				t.Fatalf("decodeRecordID(%q): %v", id, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeRecordID(%q) = %v, want %v", id, recordIDParts(got), recordIDParts(tt.want))
+			}
+		})
+	}
+}
+
+func TestDecodeRecordIDErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr string
+	}{
+		{"末尾下划线", "abc_", "无效的记录ID"},
+		{"不完整的十六进制", "a_2", "无效的记录ID"},
+		{"非十六进制", "a_ZZ", "无效的记录ID"},
+		{"摘要缩短", encodeRecordID([]interface{}{strings.Repeat("a", 200)}), "无法解码"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeRecordID(tt.id)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("decodeRecordID(%q) err = %v, want containing %q", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncodeRecordIDTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string // 前缀相同的两个超长键值
+	}{
+		{"英文", strings.Repeat("a", 200) + "1", strings.Repeat("a", 200) + "2"},
+		{"转义序列", strings.Repeat("-", 100) + "1", strings.Repeat("-", 100) + "2"},
+		{"下划线", strings.Repeat("a_", 100) + "1", strings.Repeat("a_", 100) + "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := encodeRecordID([]interface{}{tt.a})
+			b := encodeRecordID([]interface{}{tt.b})
+			if a == b {
+				t.Errorf("不同键值得到相同的记录ID %q", a)
+			}
+			for _, id := range []string{a, b} {
+				if len(id) > maxRecordIDLength {
+					t.Errorf("记录ID长度 %d 超过 %d", len(id), maxRecordIDLength)
+				}
+				prefix, _, ok := strings.Cut(id, recordIDHash)
+				if !ok {
+					t.Fatalf("记录ID %q 没有摘要后缀", id)
+				}
+				// 截取的前缀仍能完整解码，说明没有截断在转义序列中间
+				if _, err := decodeRecordID(prefix); err != nil {
+					t.Errorf("前缀 %q 截断在转义序列中间: %v", prefix, err)
+				}
+			}
+			if encodeRecordID([]interface{}{tt.a}) != a {
+				t.Error("相同键值的记录ID不稳定")
+			}
+		})
+	}
+}
+
+// recordIDParts 将解码结果转为可读的形式
+func recordIDParts(parts []*string) []string {
+	out := make([]string, len(parts))
+	for i, p := range parts {
+		if p == nil {
+			out[i] = "NULL"
+		} else {
+			out[i] = *p
+		}
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/querylib"
+	"time"
+)
+
+// QueryModeSaved 取数模式：已发布查询
+const QueryModeSaved = "saved"
+
+// resolveSavedQuery 已发布查询模式下按名称读取查询和连接配置，替换为自定义SQL取数
+// 用户配置中的连接信息和SQL不生效，只能为查询声明的参数赋值
+func resolveSavedQuery(config *models.MySQLConfig) error {
+	if config.QueryMode != QueryModeSaved {
+		config.SavedQuery = ""
+		return nil
+	}
+	if config.SavedQuery == "" {
+		return fmt.Errorf("请选择已发布查询")
+	}
+
+	store := querylib.GetStore()
+	q, err := store.FindQuery(config.SavedQuery)
+	if err != nil {
+		return fmt.Errorf("读取已发布查询失败: %w", err)
+	}
+	if q == nil {
+		return fmt.Errorf("已发布查询 %s 不存在", config.SavedQuery)
+	}
+
+	sqlText, params := q.SQL, q.Params
+	if config.SavedQueryVersion > 0 && config.SavedQueryVersion != q.Version {
+		v, err := store.GetVersion(q.ID, config.SavedQueryVersion)
+		if err != nil {
+			return fmt.Errorf("读取已发布查询失败: %w", err)
+		}
+		if v == nil {
+			return fmt.Errorf("已发布查询 %s 没有版本 %d", q.Name, config.SavedQueryVersion)
+		}
+		sqlText, params = v.SQL, v.Params
+	}
+
+	profile, err := store.GetProfile(q.ProfileID)
+	if err != nil {
+		return fmt.Errorf("读取连接配置失败: %w", err)
+	}
+	if profile == nil {
+		return fmt.Errorf("已发布查询 %s 的连接配置不存在", q.Name)
+	}
+
+	// 查找表映射会以连接配置的账号读取任意表，已发布查询模式下只允许内联配置的值映射
+	for _, m := range config.FieldMappings {
+		if m.ValueMap != nil && m.ValueMap.Table != "" {
+			return fmt.Errorf("已发布查询不支持查找表值映射(字段 %s)，请使用内联配置的映射值", m.MysqlField)
+		}
+	}
+
+	// 参数值覆盖声明的默认值，不允许引入未声明的参数
+	resolved := make([]models.SQLParam, len(params))
+	copy(resolved, params)
+	for name, value := range config.ParamValues {
+		found := false
+		for i := range resolved {
+			if resolved[i].Name == name {
+				resolved[i].Default = value
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("已发布查询 %s 没有声明参数 %s", q.Name, name)
+		}
+	}
+
+	config.Host = profile.Host
+	config.Port = profile.Port
+	config.Username = profile.Username
+	config.Password = profile.Password
+	config.Database = profile.Database
+	config.TimeZone = profile.TimeZone
+	config.Table = ""
+	config.QueryMode = "sql"
+	config.CustomSQL = sqlText
+	config.SQLParams = resolved
+	return nil
+}
+
+// ListSavedQueries 获取配置页面可选的已发布查询
+func (s *MySQLService) ListSavedQueries() ([]models.SavedQueryInfo, error) {
+	queries, err := querylib.GetStore().ListQueries()
+	if err != nil {
+		return nil, fmt.Errorf("读取已发布查询失败: %w", err)
+	}
+
+	list := make([]models.SavedQueryInfo, len(queries))
+	for i, q := range queries {
+		list[i] = models.SavedQueryInfo{
+			Name:        q.Name,
+			Description: q.Description,
+			Owner:       q.Owner,
+			Version:     q.Version,
+			Params:      q.Params,
+		}
+	}
+	return list, nil
+}
+
+// ValidateSavedQuery 校验已发布查询的SQL模板和参数声明
+func ValidateSavedQuery(q *querylib.Query) error {
+	config := &models.MySQLConfig{CustomSQL: q.SQL, SQLParams: q.Params}
+	_, err := resolveCustomSQL(config, models.Context{}, time.Local, time.Now())
+	return err
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// 快照会话闲置超过该时长后回滚事务并释放连接
+	snapshotIdleTimeout = 10 * time.Minute
+	// 同时持有的快照会话上限，避免占满源库连接
+	snapshotMaxSessions = 20
+)
+
+// queryer 执行查询的对象：数据库连接池或快照会话
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// snapshotManager 一致性快照会话管理，同一次同步的所有分页在同一快照内读取
+type snapshotManager struct {
+	mu       sync.Mutex
+	sessions map[string]*snapshotSession
+}
+
+// snapshotSession 持有已开启一致性快照事务的专用连接
+type snapshotSession struct {
+	syncID string
+	db     *sql.DB
+	conn   *sql.Conn
+	mu     sync.Mutex // 同一连接上的查询串行执行
+	users  int        // 正在使用会话的请求数(由 snapshotManager.mu 保护)
+	timer  *time.Timer
+	closed bool
+}
+
+func newSnapshotManager() *snapshotManager {
+	return &snapshotManager{
+		sessions: make(map[string]*snapshotSession),
+	}
+}
+
+// acquire 获取同步会话的快照，不存在时通过 open 建立连接并开启快照
+// resume 表示同步已在进行中(非首页)，此时快照不存在说明已超时释放，不能再开启新快照
+func (m *snapshotManager) acquire(syncID string, resume bool, open func() (*sql.DB, error)) (*snapshotSession, error) {
+	m.mu.Lock()
+	if session := m.sessions[syncID]; session != nil {
+		session.users++
+		if session.timer != nil {
+			session.timer.Stop()
+		}
+		m.mu.Unlock()
+
+		session.mu.Lock()
+		if session.closed {
+			session.mu.Unlock()
+			return nil, fmt.Errorf("开启一致性快照失败，请重新同步")
+		}
+		return session, nil
+	}
+
+	if resume {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("同步快照已超时释放，请重新同步")
+	}
+	if len(m.sessions) >= snapshotMaxSessions {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("进行中的快照同步已达上限(%d)，请稍后重试", snapshotMaxSessions)
+	}
+
+	// 先占位再建立连接，同一同步的并发请求等待连接建立完成
+	session := &snapshotSession{syncID: syncID, users: 1}
+	session.mu.Lock()
+	m.sessions[syncID] = session
+	m.mu.Unlock()
+
+	if err := session.open(open); err != nil {
+		m.mu.Lock()
+		delete(m.sessions, syncID)
+		m.mu.Unlock()
+		session.closed = true
+		session.mu.Unlock()
+		return nil, err
+	}
+	return session, nil
+}
+
+// release 归还会话；done 表示同步已完成，没有其他请求使用时立即释放快照
+func (m *snapshotManager) release(session *snapshotSession, done bool) {
+	session.mu.Unlock()
+
+	m.mu.Lock()
+	session.users--
+	if session.users > 0 || m.sessions[session.syncID] != session {
+		m.mu.Unlock()
+		return
+	}
+	if done {
+		delete(m.sessions, session.syncID)
+		m.mu.Unlock()
+		session.close()
+		return
+	}
+	if session.timer == nil {
+		session.timer = time.AfterFunc(snapshotIdleTimeout, func() { m.expire(session) })
+	} else {
+		session.timer.Reset(snapshotIdleTimeout)
+	}
+	m.mu.Unlock()
+}
+
+// expire 释放闲置超时的会话
+func (m *snapshotManager) expire(session *snapshotSession) {
+	m.mu.Lock()
+	if session.users > 0 || m.sessions[session.syncID] != session {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.sessions, session.syncID)
+	m.mu.Unlock()
+
+	session.close()
+}
+
+// open 建立专用连接并开启只读的一致性快照事务
+func (s *snapshotSession) open(open func() (*sql.DB, error)) error {
+	db, err := open()
+	if err != nil {
+		return err
+	}
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("获取快照连接失败: %w", err)
+	}
+
+	// 一致性快照只在可重复读隔离级别下生效
+	for _, stmt := range []string{
+		"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+		"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			conn.Close()
+			db.Close()
+			return fmt.Errorf("开启一致性快照失败: %w", err)
+		}
+	}
+
+	s.db = db
+	s.conn = conn
+	return nil
+}
+
+// close 回滚快照事务并关闭连接
+func (s *snapshotSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.conn.ExecContext(context.Background(), "ROLLBACK")
+	s.conn.Close()
+	s.db.Close()
+}
+
+// Query 在快照内执行查询
+func (s *snapshotSession) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.conn.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRow 在快照内执行单行查询
+func (s *snapshotSession) QueryRow(query string, args ...interface{}) *sql.Row {
+	return s.conn.QueryRowContext(context.Background(), query, args...)
+}
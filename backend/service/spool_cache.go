@@ -0,0 +1,285 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/spool"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// 查询结果缓存默认有效期
+	defaultSpoolTTL = 30 * time.Minute
+	// 缓存过期后的保留时长，供进行中的同步读完剩余分页
+	spoolGracePeriod = time.Hour
+	// 写入缓存的批大小
+	spoolBatchSize = 1000
+)
+
+// spoolBuildLocks 按缓存键串行执行缓存写入，避免同一查询被并发执行多次，不同查询互不阻塞
+var spoolBuildLocks = &keyedMutex{locks: make(map[string]*keyedLock)}
+
+// keyedMutex 按键加锁，无人持有或等待的锁自动释放
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock 获取键对应的锁，返回解锁函数
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	l := k.locks[key]
+	if l == nil {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// spoolValue 缓存行中的单个值，保留驱动返回的原始类型
+type spoolValue struct {
+	K string `json:"k"` // 类型: s 文本、b 二进制(base64)、i 整数、u 无符号整数、f 浮点数、t 时间(RFC3339)
+	V string `json:"v"`
+}
+
+// spoolCacheKey 查询结果缓存键，相同连接、SQL和参数值的同步共享缓存
+func spoolCacheKey(config *models.MySQLConfig, cq *customQuery) string {
+	args, _ := json.Marshal(cq.args)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s|%s|%s|%s",
+		config.Host, config.Port, config.Database, config.Username, cq.text, args)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// acquireSpool 获取本次同步使用的查询结果缓存
+// 游标中已有缓存时沿用(保证同一次同步读取同一份结果)；否则使用未过期的缓存，都没有时执行查询写入新缓存
+func (s *MySQLService) acquireSpool(db queryer, config *models.MySQLConfig, cq *customQuery, spoolID int64) (*spool.Spool, error) {
+	store := spool.GetStore()
+	key := spoolCacheKey(config, cq)
+
+	if spoolID != 0 {
+		sp, err := store.Get(spoolID)
+		if err != nil {
+			return nil, fmt.Errorf("读取查询结果缓存失败: %w", err)
+		}
+		// 游标未签名，缓存须属于本次查询且已写入完成，防止通过修改游标读取其他查询的缓存
+		if sp == nil || sp.CacheKey != key || sp.Status != spool.StatusReady {
+			return nil, fmt.Errorf("查询结果缓存已过期清理，请重新同步")
+		}
+		store.RecordHit(sp.ID)
+		return sp, nil
+	}
+
+	unlock := spoolBuildLocks.lock(key)
+	defer unlock()
+
+	sp, err := store.FindReady(key)
+	if err != nil {
+		return nil, fmt.Errorf("读取查询结果缓存失败: %w", err)
+	}
+	if sp != nil {
+		store.RecordHit(sp.ID)
+		return sp, nil
+	}
+
+	store.RecordMiss()
+	store.CleanExpired(spoolGracePeriod)
+
+	ttl := defaultSpoolTTL
+	if config.SpoolTTL > 0 {
+		ttl = time.Duration(config.SpoolTTL) * time.Minute
+	}
+	return s.buildSpool(db, config, cq, key, ttl)
+}
+
+// buildSpool 执行自定义SQL并将完整结果写入缓存
+func (s *MySQLService) buildSpool(db queryer, config *models.MySQLConfig, cq *customQuery, key string, ttl time.Duration) (*spool.Spool, error) {
+	rows, err := db.Query("SELECT * FROM "+cq.subquery(), cq.args...)
+	if err != nil {
+		return nil, fmt.Errorf("执行SQL失败: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := readResultColumns(rows)
+	if err != nil {
+		return nil, err
+	}
+	spoolColumns := make([]spool.Column, len(columns))
+	for i, c := range columns {
+		spoolColumns[i] = spool.Column{Name: c.Name, Type: c.DBType, Precision: c.Precision, Scale: c.Scale}
+	}
+
+	store := spool.GetStore()
+	sp, err := store.Create(key, syncSourceName(config), spoolColumns, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("创建查询结果缓存失败: %w", err)
+	}
+
+	fail := func(err error) (*spool.Spool, error) {
+		store.Delete(sp.ID)
+		return nil, err
+	}
+
+	batch := make([][]byte, 0, spoolBatchSize)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return fail(err)
+		}
+
+		data, err := encodeSpoolRow(values)
+		if err != nil {
+			return fail(err)
+		}
+		batch = append(batch, data)
+
+		if len(batch) == spoolBatchSize {
+			if err := store.AppendRows(sp.ID, sp.RowCount, batch); err != nil {
+				return fail(fmt.Errorf("写入查询结果缓存失败: %w", err))
+			}
+			sp.RowCount += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fail(fmt.Errorf("执行SQL失败: %w", err))
+	}
+	if err := store.AppendRows(sp.ID, sp.RowCount, batch); err != nil {
+		return fail(fmt.Errorf("写入查询结果缓存失败: %w", err))
+	}
+	sp.RowCount += len(batch)
+
+	if err := store.MarkReady(sp.ID); err != nil {
+		return fail(fmt.Errorf("写入查询结果缓存失败: %w", err))
+	}
+	sp.Status = spool.StatusReady
+	return sp, nil
+}
+
+// getSpoolRecords 从查询结果缓存读取一页记录
+func (s *MySQLService) getSpoolRecords(sp *spool.Spool, fields []models.Field, conv *valueConverter, offset, limit int) ([]models.Record, error) {
+	rows, err := spool.GetStore().ReadRows(sp.ID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("读取查询结果缓存失败: %w", err)
+	}
+
+	columns := make([]string, len(sp.Columns))
+	for i, c := range sp.Columns {
+		columns[i] = c.Name
+	}
+
+	builder := newRecordBuilder(columns, fields, conv)
+	records := make([]models.Record, 0, len(rows))
+	for _, data := range rows {
+		values, err := decodeSpoolRow(data, len(sp.Columns))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, builder.build(values))
+	}
+	return records, nil
+}
+
+// spoolResultColumns 缓存中保存的结果集列信息
+func spoolResultColumns(sp *spool.Spool) []resultColumn {
+	columns := make([]resultColumn, len(sp.Columns))
+	for i, c := range sp.Columns {
+		columns[i] = resultColumn{Name: c.Name, DBType: c.Type, Precision: c.Precision, Scale: c.Scale}
+	}
+	return columns
+}
+
+// encodeSpoolRow 编码一行原始值
+func encodeSpoolRow(values []interface{}) ([]byte, error) {
+	row := make([]*spoolValue, len(values))
+	for i, v := range values {
+		switch val := v.(type) {
+		case nil:
+			continue
+		case []byte:
+			if utf8.Valid(val) {
+				row[i] = &spoolValue{K: "s", V: string(val)}
+			} else {
+				row[i] = &spoolValue{K: "b", V: base64.StdEncoding.EncodeToString(val)}
+			}
+		case string:
+			row[i] = &spoolValue{K: "s", V: val}
+		case int64:
+			row[i] = &spoolValue{K: "i", V: strconv.FormatInt(val, 10)}
+		case uint64:
+			row[i] = &spoolValue{K: "u", V: strconv.FormatUint(val, 10)}
+		case float64:
+			row[i] = &spoolValue{K: "f", V: strconv.FormatFloat(val, 'g', -1, 64)}
+		case float32:
+			row[i] = &spoolValue{K: "f", V: strconv.FormatFloat(float64(val), 'g', -1, 32)}
+		case time.Time:
+			row[i] = &spoolValue{K: "t", V: val.Format(time.RFC3339Nano)}
+		default:
+			row[i] = &spoolValue{K: "s", V: fmt.Sprintf("%v", val)}
+		}
+	}
+	return json.Marshal(row)
+}
+
+// decodeSpoolRow 解码一行原始值，文本按驱动的习惯还原为 []byte
+func decodeSpoolRow(data []byte, columns int) ([]interface{}, error) {
+	var row []*spoolValue
+	if err := json.Unmarshal(data, &row); err != nil {
+		return nil, fmt.Errorf("解析查询结果缓存失败: %w", err)
+	}
+
+	values := make([]interface{}, columns)
+	for i, v := range row {
+		if v == nil || i >= columns {
+			continue
+		}
+		var err error
+		switch v.K {
+		case "s":
+			values[i] = []byte(v.V)
+		case "b":
+			values[i], err = base64.StdEncoding.DecodeString(v.V)
+		case "i":
+			values[i], err = strconv.ParseInt(v.V, 10, 64)
+		case "u":
+			values[i], err = strconv.ParseUint(v.V, 10, 64)
+		case "f":
+			values[i], err = strconv.ParseFloat(v.V, 64)
+		case "t":
+			values[i], err = time.Parse(time.RFC3339Nano, v.V)
+		default:
+			err = fmt.Errorf("未知的值类型 %s", v.K)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析查询结果缓存失败: %w", err)
+		}
+	}
+	return values, nil
+}
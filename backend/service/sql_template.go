@@ -0,0 +1,355 @@
+package service
+
+import (
+	"fmt"
+	"mysql-sync-plugin/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 模板变量的时间格式：会话时区的墙上时间
+// 会话时区与源库时区一致(见 connectDB)，因此可直接与 DATE/DATETIME/TIMESTAMP 列及 NOW() 等函数的结果比较
+const (
+	templateDateLayout     = "2006-01-02"
+	templateDateTimeLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	// 模板表达式: 变量名，可带时间偏移，如 now - 7d、today + 1d
+	templateExprPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*(?:([+-])\s*(\d+)\s*([smhdw]))?$`)
+	// 模板参数名
+	templateNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// 内置变量：时间函数和请求上下文
+var builtinTemplateVars = map[string]bool{
+	"now":         true, // 当前时间
+	"today":       true, // 今天
+	"yesterday":   true, // 昨天
+	"month_start": true, // 本月第一天
+	"year_start":  true, // 本年第一天
+	"corp_id":     true, // 组织ID(钉钉 corpId / 飞书 tenantKey)
+	"user_id":     true, // 用户ID(钉钉 unionId / 飞书 baseOpenID)
+}
+
+// customQuery 解析模板变量后的自定义SQL
+type customQuery struct {
+	text   string                 // 模板变量替换为 ? 占位符后的SQL(已去除末尾分号)
+	args   []interface{}          // 占位符按出现顺序对应的参数
+	params []models.BoundSQLParam // 绑定的模板变量，用于预览展示
+}
+
+// subquery 以子查询形式引用自定义SQL
+func (q *customQuery) subquery() string {
+	return fmt.Sprintf("(%s) AS t", q.text)
+}
+
+// bind 生成查询参数：自定义SQL的参数在前，外层条件的参数在后
+func (q *customQuery) bind(args ...interface{}) []interface{} {
+	return append(append([]interface{}{}, q.args...), args...)
+}
+
+// templateTime 时间类型的变量值，dateOnly 表示按日期输出
+type templateTime struct {
+	t        time.Time
+	dateOnly bool
+}
+
+// format 按会话时区(即源库时区)格式化为墙上时间
+func (v templateTime) format(location *time.Location) string {
+	if v.dateOnly {
+		return v.t.In(location).Format(templateDateLayout)
+	}
+	return v.t.In(location).Format(templateDateTimeLayout)
+}
+
+// sqlTemplate 模板变量的取值环境
+type sqlTemplate struct {
+	params   map[string]models.SQLParam
+	values   map[string]interface{} // 已解析的参数值
+	ctx      models.Context
+	location *time.Location // 会话时区(即源库时区)，用于计算日期和格式化时间
+	now      time.Time      // 时间函数的基准时间(同一次同步内保持不变)
+}
+
+// resolveCustomSQL 解析自定义SQL中的模板变量(:name 或 {{表达式}})
+// 变量值作为预处理语句参数绑定，不拼接到SQL文本；引号、注释内的内容保持原样
+func resolveCustomSQL(config *models.MySQLConfig, ctx models.Context, location *time.Location, now time.Time) (*customQuery, error) {
+	t := &sqlTemplate{
+		params:   make(map[string]models.SQLParam),
+		values:   make(map[string]interface{}),
+		ctx:      ctx,
+		location: location,
+		now:      now,
+	}
+	for _, p := range config.SQLParams {
+		if !templateNamePattern.MatchString(p.Name) {
+			return nil, fmt.Errorf("无效的SQL参数名: %s", p.Name)
+		}
+		if builtinTemplateVars[p.Name] {
+			return nil, fmt.Errorf("SQL参数 %s 与内置变量重名", p.Name)
+		}
+		if _, ok := t.params[p.Name]; ok {
+			return nil, fmt.Errorf("SQL参数 %s 重复", p.Name)
+		}
+		t.params[p.Name] = p
+	}
+
+	sqlText := strings.TrimSuffix(strings.TrimSpace(config.CustomSQL), ";")
+	q := &customQuery{}
+	var b strings.Builder
+
+	bindVar := func(expr string) error {
+		value, err := t.eval(expr)
+		if err != nil {
+			return err
+		}
+		if v, ok := value.(templateTime); ok {
+			value = v.format(t.location)
+		}
+		b.WriteByte('?')
+		q.args = append(q.args, value)
+		q.params = append(q.params, models.BoundSQLParam{Name: expr, Value: value})
+		return nil
+	}
+
+	for i := 0; i < len(sqlText); {
+		c := sqlText[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(sqlText, i)
+			if c != '`' && strings.Contains(sqlText[i:end], "{{") {
+				return nil, fmt.Errorf("模板变量不能写在引号内，变量会作为参数绑定: %s", sqlText[i:end])
+			}
+			b.WriteString(sqlText[i:end])
+			i = end
+		case c == '#' || isLineCommentStart(sqlText, i):
+			end := strings.IndexByte(sqlText[i:], '\n')
+			if end < 0 {
+				end = len(sqlText) - i
+			}
+			b.WriteString(sqlText[i : i+end])
+			i += end
+		case strings.HasPrefix(sqlText[i:], "/*"):
+			end := strings.Index(sqlText[i+2:], "*/")
+			if end < 0 {
+				end = len(sqlText) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(sqlText[i : i+end])
+			i += end
+		case strings.HasPrefix(sqlText[i:], "{{"):
+			end := strings.Index(sqlText[i+2:], "}}")
+			if end < 0 {
+				return nil, fmt.Errorf("模板变量缺少结束的 }}")
+			}
+			if err := bindVar(strings.TrimSpace(sqlText[i+2 : i+2+end])); err != nil {
+				return nil, err
+			}
+			i += end + 4
+		case c == ':' && i+1 < len(sqlText) && isIdentStart(sqlText[i+1]) && (i == 0 || !isIdentChar(sqlText[i-1])):
+			j := i + 1
+			for j < len(sqlText) && isIdentChar(sqlText[j]) {
+				j++
+			}
+			if err := bindVar(sqlText[i+1 : j]); err != nil {
+				return nil, err
+			}
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	q.text = b.String()
+	return q, nil
+}
+
+// eval 计算模板表达式
+func (t *sqlTemplate) eval(expr string) (interface{}, error) {
+	return t.evalExpr(expr, true)
+}
+
+// evalExpr 计算模板表达式，allowParams 为 false 时只能引用内置变量(用于参数值中的表达式)
+func (t *sqlTemplate) evalExpr(expr string, allowParams bool) (interface{}, error) {
+	m := templateExprPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("无效的模板表达式: %s", expr)
+	}
+	name := m[1]
+
+	var value interface{}
+	if p, ok := t.params[name]; ok && allowParams {
+		v, err := t.paramValue(p)
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	} else {
+		v, ok := t.builtin(name)
+		if !ok {
+			return nil, fmt.Errorf("未定义的SQL参数: %s", name)
+		}
+		value = v
+	}
+
+	if m[2] == "" {
+		return value, nil
+	}
+	tv, ok := value.(templateTime)
+	if !ok {
+		return nil, fmt.Errorf("模板表达式 %s 中只有时间变量可以加减", expr)
+	}
+	n, err := strconv.Atoi(m[3])
+	if err != nil {
+		return nil, fmt.Errorf("无效的模板表达式: %s", expr)
+	}
+	if m[2] == "-" {
+		n = -n
+	}
+	switch m[4] {
+	case "d":
+		tv.t = tv.t.In(t.location).AddDate(0, 0, n)
+	case "w":
+		tv.t = tv.t.In(t.location).AddDate(0, 0, 7*n)
+	default:
+		unit := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}[m[4]]
+		tv.t = tv.t.Add(time.Duration(n) * unit)
+		tv.dateOnly = false
+	}
+	return tv, nil
+}
+
+// builtin 读取内置变量
+func (t *sqlTemplate) builtin(name string) (interface{}, bool) {
+	local := t.now.In(t.location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, t.location)
+	switch name {
+	case "now":
+		return templateTime{t: t.now}, true
+	case "today":
+		return templateTime{t: today, dateOnly: true}, true
+	case "yesterday":
+		return templateTime{t: today.AddDate(0, 0, -1), dateOnly: true}, true
+	case "month_start":
+		return templateTime{t: time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, t.location), dateOnly: true}, true
+	case "year_start":
+		return templateTime{t: time.Date(local.Year(), 1, 1, 0, 0, 0, 0, t.location), dateOnly: true}, true
+	case "corp_id":
+		return t.ctx.CorpID, true
+	case "user_id":
+		return t.ctx.UnionID, true
+	}
+	return nil, false
+}
+
+// paramValue 解析配置的参数值并转换为参数类型
+func (t *sqlTemplate) paramValue(p models.SQLParam) (interface{}, error) {
+	if v, ok := t.values[p.Name]; ok {
+		return v, nil
+	}
+
+	raw := strings.TrimSpace(p.Default)
+	var value interface{} = raw
+	if strings.HasPrefix(raw, "{{") && strings.HasSuffix(raw, "}}") {
+		v, err := t.evalExpr(strings.TrimSpace(raw[2:len(raw)-2]), false)
+		if err != nil {
+			return nil, fmt.Errorf("SQL参数 %s 的值无效: %w", p.Name, err)
+		}
+		value = v
+	}
+
+	v, err := convertTemplateParam(value, p.Type, t.location)
+	if err != nil {
+		return nil, fmt.Errorf("SQL参数 %s 的值无效: %w", p.Name, err)
+	}
+	t.values[p.Name] = v
+	return v, nil
+}
+
+// convertTemplateParam 将参数值转换为声明的类型
+func convertTemplateParam(value interface{}, paramType string, location *time.Location) (interface{}, error) {
+	tv, isTime := value.(templateTime)
+	text, _ := value.(string)
+
+	switch paramType {
+	case "", "string":
+		if isTime {
+			return tv.format(location), nil
+		}
+		return text, nil
+	case "number":
+		if isTime {
+			return nil, fmt.Errorf("数字参数不能使用时间表达式")
+		}
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是数字", text)
+		}
+		return f, nil
+	case "date":
+		if isTime {
+			return tv.t.In(location).Format(templateDateLayout), nil
+		}
+		if _, err := time.ParseInLocation(templateDateLayout, text, location); err != nil {
+			return nil, fmt.Errorf("%q 不是 YYYY-MM-DD 格式的日期", text)
+		}
+		return text, nil
+	case "datetime":
+		if isTime {
+			return tv.t.In(location).Format(templateDateTimeLayout), nil
+		}
+		if _, err := time.ParseInLocation(templateDateTimeLayout, text, location); err == nil {
+			return text, nil
+		}
+		if _, err := time.ParseInLocation(templateDateLayout, text, location); err == nil {
+			return text + " 00:00:00", nil
+		}
+		return nil, fmt.Errorf("%q 不是 YYYY-MM-DD HH:mm:ss 格式的时间", text)
+	}
+	return nil, fmt.Errorf("不支持的参数类型: %s", paramType)
+}
+
+// skipQuoted 返回从 start 开始的引号字符串(或反引号标识符)之后的位置
+func skipQuoted(s string, start int) int {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			// 连续两个引号表示转义
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// isLineCommentStart 判断是否为 "-- " 开头的单行注释(MySQL 要求 -- 后跟空白)
+func isLineCommentStart(s string, i int) bool {
+	if !strings.HasPrefix(s[i:], "--") {
+		return false
+	}
+	return i+2 == len(s) || s[i+2] == ' ' || s[i+2] == '\t' || s[i+2] == '\n' || s[i+2] == '\r'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/syncstate"
+	"sort"
+)
+
+// 删除行处理方式
+const (
+	DeleteModeReport   = "report"   // 仅在同步报告中列出
+	DeleteModeCheckbox = "checkbox" // 输出墓碑记录，_deleted 复选框为勾选
+	DeleteModeStatus   = "status"   // 输出墓碑记录，_status 单选为"已删除"
+)
+
+// 删除标记字段
+const (
+	deletedFieldColumn = "_deleted"
+	statusFieldColumn  = "_status"
+	statusActive       = "正常"
+	statusDeleted      = "已删除"
+)
+
+// 分页阶段：读取数据之后输出墓碑记录
+const pagePhaseDeleted = "deleted"
+
+// 报告模式下每批标记删除的记录数
+const vanishedBatchSize = 1000
+
+// syncTargetKey 确定同步目标标识，用于保存水位和记录集合等跨同步的状态
+// 由目标表格标识(飞书为平台提供的数据源实例标识，钉钉为 corpId 加AI表格和数据表ID)和数据源配置摘要组成，
+// 同一数据源同步到不同表格时各自保存状态；目标表格没有稳定标识时返回空
+func syncTargetKey(req *models.RecordsRequest, config *models.MySQLConfig) string {
+	target := req.TargetKey
+	if target == "" {
+		ctx := req.Context
+		if ctx.CorpID == "" || (ctx.BaseID == "" && ctx.SheetID == "") {
+			return ""
+		}
+		target = fmt.Sprintf("dingtalk:%s:%s:%s", ctx.CorpID, ctx.BaseID, ctx.SheetID)
+	}
+	return target + ":" + syncSourceDigest(config)
+}
+
+// syncSourceDigest 数据源配置摘要，配置变化后使用新的同步状态
+func syncSourceDigest(config *models.MySQLConfig) string {
+	// 已发布查询按名称和参数值区分，发布新版本后沿用原有的同步状态
+	source := fmt.Sprintf("%s|%d|%s|%s|%s|%s", config.Host, config.Port, config.Database, config.Table, config.CustomSQL,
+		sqlParamsDigest(config.SQLParams))
+	if config.SavedQuery != "" {
+		source = "saved|" + config.SavedQuery + "|" + sqlParamsDigest(config.SQLParams)
+	}
+	// 筛选条件按配置(而非编译后的参数)计入，相对日期每次同步取值不同但属于同一结果集
+	filter, _ := json.Marshal(struct {
+		Filter *models.FilterNode `json:"filter,omitempty"`
+		Sort   []models.SortField `json:"sort,omitempty"`
+	}{config.Filter, config.Sort})
+	sum := sha256.Sum256([]byte(source + "|" + string(filter) + "|" + config.WatermarkColumn))
+	return hex.EncodeToString(sum[:8])
+}
+
+// sqlParamsDigest 按参数名排序后的参数值，参数值中的内置表达式不求值，相对日期不影响摘要
+func sqlParamsDigest(params []models.SQLParam) string {
+	sorted := make([]models.SQLParam, len(params))
+	copy(sorted, params)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	b, _ := json.Marshal(sorted)
+	return string(b)
+}
+
+// requiresSyncState 同步方式是否依赖跨同步保存的状态(水位、记录集合)
+func requiresSyncState(config *models.MySQLConfig) bool {
+	return config.SyncMode == SyncModeIncremental || config.SyncMode == SyncModeCDC ||
+		config.DeleteMode != "" || config.ChangedOnly
+}
+
+// syncSourceName 数据源描述
+func syncSourceName(config *models.MySQLConfig) string {
+	if config.SavedQuery != "" {
+		return fmt.Sprintf("%s:%d/%s (已发布查询 %s)", config.Host, config.Port, config.Database, config.SavedQuery)
+	}
+	if config.Join != nil {
+		return fmt.Sprintf("%s:%d/%s.%s (多表关联)", config.Host, config.Port, config.Database, config.Join.Base)
+	}
+	if config.QueryMode == "sql" && config.CustomSQL != "" {
+		return fmt.Sprintf("%s:%d/%s (自定义SQL)", config.Host, config.Port, config.Database)
+	}
+	return fmt.Sprintf("%s:%d/%s.%s", config.Host, config.Port, config.Database, config.Table)
+}
+
+// validDeleteMode 校验删除行处理方式
+func validDeleteMode(mode string) bool {
+	switch mode {
+	case "", DeleteModeReport, DeleteModeCheckbox, DeleteModeStatus:
+		return true
+	}
+	return false
+}
+
+// appendDeleteMarkerField 追加删除标记字段(墓碑模式)
+func appendDeleteMarkerField(fields []models.Field, mode string) ([]models.Field, error) {
+	var field models.Field
+	switch mode {
+	case DeleteModeCheckbox:
+		field = models.Field{
+			ID:          "fid_" + deletedFieldColumn,
+			Name:        deletedFieldColumn,
+			Type:        "checkbox",
+			Description: "源数据中该记录已被删除",
+			Column:      deletedFieldColumn,
+		}
+	case DeleteModeStatus:
+		field = models.Field{
+			ID:          "fid_" + statusFieldColumn,
+			Name:        statusFieldColumn,
+			Type:        "singleSelect",
+			Description: "源数据中该记录的状态",
+			Property: map[string]interface{}{
+				"choices": []map[string]interface{}{
+					{"name": statusActive},
+					{"name": statusDeleted},
+				},
+			},
+			Column: statusFieldColumn,
+		}
+	default:
+		return fields, nil
+	}
+
+	if hasColumn(fields, field.Column) {
+		return nil, fmt.Errorf("列 %s 与删除标记字段重名", field.Column)
+	}
+	return append(fields, field), nil
+}
+
+// setDeleteMarker 设置记录的删除标记
+func setDeleteMarker(record *models.Record, mode string, deleted bool) {
+	switch mode {
+	case DeleteModeCheckbox:
+		record.Fields["fid_"+deletedFieldColumn] = deleted
+	case DeleteModeStatus:
+		status := statusActive
+		if deleted {
+			status = statusDeleted
+		}
+		record.Fields["fid_"+statusFieldColumn] = status
+	}
+}
+
+// tombstoneRecord 构建墓碑记录(源数据已删除，仅包含记录ID和删除标记)
+func tombstoneRecord(id, mode string) models.Record {
+	record := models.Record{
+		ID:     id,
+		Fields: make(map[string]interface{}),
+	}
+	setDeleteMarker(&record, mode, true)
+	return record
+}
+
+// emitsTombstones 判断删除行是否以墓碑记录输出
+func emitsTombstones(mode string) bool {
+	return mode == DeleteModeCheckbox || mode == DeleteModeStatus
+}
+
+// recordHash 计算记录内容摘要(按字段ID排序后序列化字段值)
+func recordHash(record models.Record) string {
+	keys := make([]string, 0, len(record.Fields))
+	for k := range record.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		value, _ := json.Marshal(record.Fields[k])
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(value)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// syncTracker 单次请求的记录集合跟踪上下文(删除行检测与内容变化检测)
+type syncTracker struct {
+	deleteMode  string
+	changedOnly bool // 只输出新增和内容变化的记录
+	targetKey   string
+	syncID      string
+	source      string
+	syncMode    string
+	fullScan    bool // 本次同步是否读取了全部数据，只有全量读取才能判断哪些记录已消失
+}
+
+// trackPage 记录本页输出的记录，并处理删除的记录
+// deleted 为本页已知被删除的记录(如变更日志中的删除)；数据读取完毕时找出本次同步未出现的记录
+// 返回本页最终输出的记录和是否还有下一页
+func (t *syncTracker) trackPage(records, deleted []models.Record, next *PageToken, hasMore bool, limit int) ([]models.Record, bool, error) {
+	store := syncstate.GetStore()
+	if err := store.StartReport(t.targetKey, t.syncID, t.source, t.syncMode); err != nil {
+		return nil, false, err
+	}
+	var delta syncstate.ReportDelta
+
+	if next.Phase == pagePhaseDeleted {
+		// 墓碑阶段：按记录ID顺序分批输出本次同步未出现的记录
+		// 平台请求下一页时上一页才算送达，此时才将其中的记录标记为已删除；
+		// 平台重试本页时重新输出相同的记录，最后一页之后再返回一个空页确认送达
+		if next.Vanished != "" {
+			marked, err := store.MarkVanished(t.targetKey, t.syncID, next.Vanished)
+			if err != nil {
+				return nil, false, err
+			}
+			delta.DeletedIDs = marked
+		}
+		ids, err := store.ListVanished(t.targetKey, t.syncID, next.Vanished, limit)
+		if err != nil {
+			return nil, false, err
+		}
+		records = records[:0]
+		for _, id := range ids {
+			records = append(records, tombstoneRecord(id, t.deleteMode))
+		}
+		hasMore = len(ids) > 0
+		if hasMore {
+			next.Vanished = ids[len(ids)-1]
+		}
+	} else {
+		var err error
+		if records, err = t.touchRecords(records, &delta); err != nil {
+			return nil, false, err
+		}
+
+		// 已知被删除的记录
+		if len(deleted) > 0 {
+			deletedIDs := make([]string, len(deleted))
+			for i := range deleted {
+				deletedIDs[i] = deleted[i].ID
+				if emitsTombstones(t.deleteMode) {
+					setDeleteMarker(&deleted[i], t.deleteMode, true)
+					records = append(records, deleted[i])
+				}
+			}
+			if err := store.MarkDeleted(t.targetKey, t.syncID, deletedIDs); err != nil {
+				return nil, false, err
+			}
+			delta.DeletedIDs = deletedIDs
+		}
+
+		// 全量数据读取完毕，找出本次同步未出现的记录
+		if !hasMore && t.fullScan && t.deleteMode != "" {
+			if t.deleteMode == DeleteModeReport {
+				for {
+					ids, err := store.TakeVanished(t.targetKey, t.syncID, vanishedBatchSize)
+					if err != nil {
+						return nil, false, err
+					}
+					delta.DeletedIDs = append(delta.DeletedIDs, ids...)
+					if len(ids) < vanishedBatchSize {
+						break
+					}
+				}
+			} else {
+				count, err := store.CountVanished(t.targetKey, t.syncID)
+				if err != nil {
+					return nil, false, err
+				}
+				if count > 0 {
+					hasMore = true
+					next.Phase = pagePhaseDeleted
+				}
+			}
+		}
+	}
+
+	if err := store.UpdateReport(t.targetKey, t.syncID, delta); err != nil {
+		return nil, false, err
+	}
+	if !hasMore {
+		if err := store.FinishReport(t.targetKey, t.syncID); err != nil {
+			return nil, false, err
+		}
+	}
+	return records, hasMore, nil
+}
+
+// touchRecords 暂存本页记录的内容摘要，统计新增、变化和未变化的记录
+// 只输出变化记录时过滤掉内容与上次完成的同步相同的记录；摘要在同步完成后才生效，
+// 同步中断或平台重试分页时这些记录会再次输出
+func (t *syncTracker) touchRecords(records []models.Record, delta *syncstate.ReportDelta) ([]models.Record, error) {
+	store := syncstate.GetStore()
+
+	ids := make([]string, len(records))
+	for i := range records {
+		ids[i] = records[i].ID
+	}
+	previous, err := store.GetRecordStates(t.targetKey, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]syncstate.RecordState, len(records))
+	kept := records[:0]
+	for i, r := range records {
+		hash := recordHash(r)
+		states[i] = syncstate.RecordState{ID: r.ID, Hash: hash}
+
+		prev, ok := previous[r.ID]
+		switch {
+		case !ok || prev.Deleted || prev.Hash == "":
+			delta.New++
+		case prev.Hash != hash:
+			delta.Changed++
+		default:
+			delta.Unchanged++
+			if t.changedOnly {
+				continue
+			}
+		}
+		setDeleteMarker(&r, t.deleteMode, false)
+		kept = append(kept, r)
+	}
+
+	if err := store.TouchRecords(t.targetKey, t.syncID, states); err != nil {
+		return nil, err
+	}
+	delta.Records = len(kept)
+	return kept, nil
+}
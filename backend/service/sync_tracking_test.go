@@ -0,0 +1,147 @@
+package service
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/syncstate"
+)
+
+func TestSyncTargetKey(t *testing.T) {
+	base := func() (*models.RecordsRequest, *models.MySQLConfig) {
+		req := &models.RecordsRequest{Context: models.Context{CorpID: "corp", BaseID: "base", SheetID: "sheet1"}}
+		config := &models.MySQLConfig{
+			Host: "db", Port: 3306, Database: "shop", Table: "orders", WatermarkColumn: "updated_at",
+			SQLParams: []models.SQLParam{{Name: "a", Default: "1"}, {Name: "b", Default: "{{today - 7d}}"}},
+		}
+		return req, config
+	}
+	baseReq, baseConfig := base()
+	baseKey := syncTargetKey(baseReq, baseConfig)
+
+	tests := []struct {
+		name   string
+		modify func(req *models.RecordsRequest, config *models.MySQLConfig)
+		same   bool
+	}{
+		{"其他数据表", func(r *models.RecordsRequest, c *models.MySQLConfig) { r.Context.SheetID = "sheet2" }, false},
+		{"其他AI表格", func(r *models.RecordsRequest, c *models.MySQLConfig) { r.Context.BaseID = "base2" }, false},
+		{"其他用户", func(r *models.RecordsRequest, c *models.MySQLConfig) { r.Context.UnionID = "user2" }, true},
+		{"其他表", func(r *models.RecordsRequest, c *models.MySQLConfig) { c.Table = "items" }, false},
+		{"其他水位列", func(r *models.RecordsRequest, c *models.MySQLConfig) { c.WatermarkColumn = "id" }, false},
+		{"参数值变化", func(r *models.RecordsRequest, c *models.MySQLConfig) { c.SQLParams[0].Default = "2" }, false},
+		{"参数顺序变化", func(r *models.RecordsRequest, c *models.MySQLConfig) {
+			c.SQLParams[0], c.SQLParams[1] = c.SQLParams[1], c.SQLParams[0]
+		}, true},
+		{"筛选条件变化", func(r *models.RecordsRequest, c *models.MySQLConfig) {
+			c.Filter = &models.FilterNode{Column: "status", Operator: "eq", Value: "paid"}
+		}, false},
+		{"排序变化", func(r *models.RecordsRequest, c *models.MySQLConfig) {
+			c.Sort = []models.SortField{{Column: "id", Desc: true}}
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, config := base()
+			tt.modify(req, config)
+			key := syncTargetKey(req, config)
+			if (key == baseKey) != tt.same {
+				t.Errorf("key = %s, base = %s, want same = %v", key, baseKey, tt.same)
+			}
+		})
+	}
+}
+
+func TestSyncTargetKeySavedQuery(t *testing.T) {
+	req := &models.RecordsRequest{TargetKey: "feishu:tenant:instance"}
+	key := func(values ...string) string {
+		config := &models.MySQLConfig{SavedQuery: "daily_orders", CustomSQL: "SELECT 1"}
+		for i, v := range values {
+			config.SQLParams = append(config.SQLParams, models.SQLParam{Name: string(rune('a' + i)), Default: v})
+		}
+		return syncTargetKey(req, config)
+	}
+
+	if key("1", "x") == key("2", "x") {
+		t.Error("参数值不同的已发布查询使用了相同的同步状态")
+	}
+	if key("1", "x") != key("1", "x") {
+		t.Error("相同参数值的同步状态标识不稳定")
+	}
+}
+
+func TestSyncTargetKeyWithoutTarget(t *testing.T) {
+	config := &models.MySQLConfig{Table: "orders"}
+	for _, ctx := range []models.Context{{}, {CorpID: "corp"}, {BaseID: "base", SheetID: "sheet"}} {
+		if key := syncTargetKey(&models.RecordsRequest{Context: ctx}, config); key != "" {
+			t.Errorf("context %+v: key = %q, want empty", ctx, key)
+		}
+	}
+}
+
+func TestTrackPageTombstoneRetry(t *testing.T) {
+	if err := syncstate.GetStore().Init(filepath.Join(t.TempDir(), "state.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer syncstate.GetStore().Close()
+
+	tracker := func(syncID string) *syncTracker {
+		return &syncTracker{deleteMode: DeleteModeCheckbox, targetKey: "target", syncID: syncID, fullScan: true}
+	}
+	page := func(ids ...string) []models.Record {
+		records := make([]models.Record, len(ids))
+		for i, id := range ids {
+			records[i] = models.Record{ID: id, Fields: map[string]interface{}{"fid_name": id}}
+		}
+		return records
+	}
+
+	// 第一次同步输出 a、b、c
+	if _, hasMore, err := tracker("s1").trackPage(page("a", "b", "c"), nil, &PageToken{}, false, 1); err != nil || hasMore {
+		t.Fatalf("s1: hasMore = %v, err = %v", hasMore, err)
+	}
+
+	// 第二次同步只剩 a，读取完毕后进入墓碑阶段
+	next := PageToken{SyncID: "s2"}
+	if _, hasMore, err := tracker("s2").trackPage(page("a"), nil, &next, false, 1); err != nil || !hasMore || next.Phase != pagePhaseDeleted {
+		t.Fatalf("s2: hasMore = %v, phase = %q, err = %v", hasMore, next.Phase, err)
+	}
+
+	steps := []struct {
+		name     string
+		vanished string // 请求中的游标
+		want     []string
+		hasMore  bool
+	}{
+		{"第一页", "", []string{"b"}, true},
+		{"重试第一页", "", []string{"b"}, true},
+		{"第二页", "b", []string{"c"}, true},
+		{"重试第二页", "b", []string{"c"}, true},
+		{"确认最后一页", "c", []string{}, false},
+	}
+	for _, step := range steps {
+		tok := next
+		tok.Vanished = step.vanished
+		records, hasMore, err := tracker("s2").trackPage(nil, nil, &tok, true, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := trackerIDs(records); !reflect.DeepEqual(got, step.want) || hasMore != step.hasMore {
+			t.Errorf("%s: records = %v, hasMore = %v, want %v, %v", step.name, got, hasMore, step.want, step.hasMore)
+		}
+		for _, r := range records {
+			if r.Fields["fid_"+deletedFieldColumn] != true {
+				t.Errorf("%s: 记录 %s 不是墓碑记录", step.name, r.ID)
+			}
+		}
+	}
+
+	// 已送达的墓碑不会在下次同步中重复输出
+	next = PageToken{SyncID: "s3"}
+	if _, hasMore, err := tracker("s3").trackPage(page("a"), nil, &next, false, 1); err != nil || hasMore {
+		t.Errorf("s3: hasMore = %v, phase = %q, err = %v", hasMore, next.Phase, err)
+	}
+}
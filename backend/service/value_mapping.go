@@ -0,0 +1,188 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// valueMapMaxOptions 值映射的最大选项数
+const valueMapMaxOptions = 5000
+
+// defaultValueMapTTL 查找表映射的默认缓存时间
+const defaultValueMapTTL = 5 * time.Minute
+
+// valueMap 已解析的值映射
+type valueMap struct {
+	labels       map[string]string // 代码 -> 显示值
+	dropUnmapped bool
+}
+
+// lookup 将源值替换为显示值，未映射的值按配置保留原值或置空
+func (m *valueMap) lookup(value interface{}) interface{} {
+	if isBlankValue(value) {
+		return nil
+	}
+	if label, ok := m.labels[strings.TrimSpace(toText(value))]; ok {
+		return label
+	}
+	if m.dropUnmapped {
+		return nil
+	}
+	return value
+}
+
+// applyValueMappings 解析字段映射中配置的值映射，将对应字段改为以显示值为选项的单选字段
+// 返回源列名到值映射的对应关系，供转换时替换值
+func (s *MySQLService) applyValueMappings(db queryer, config *models.MySQLConfig, fields []models.Field) (map[string]*valueMap, error) {
+	var result map[string]*valueMap
+	for _, m := range config.FieldMappings {
+		if m.ValueMap == nil {
+			continue
+		}
+		index := -1
+		for i := range fields {
+			if fields[i].Column == m.MysqlField {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			continue
+		}
+
+		entries, err := s.loadValueMap(db, config, m.ValueMap)
+		if err != nil {
+			return nil, fmt.Errorf("字段 %s 的值映射: %w", m.MysqlField, err)
+		}
+
+		vm := &valueMap{labels: make(map[string]string, len(entries)), dropUnmapped: m.ValueMap.DropUnmapped}
+		choices := []map[string]interface{}{}
+		seen := make(map[string]bool)
+		for _, e := range entries {
+			key := strings.TrimSpace(e.Value)
+			if _, ok := vm.labels[key]; ok {
+				continue
+			}
+			vm.labels[key] = e.Label
+			if !seen[e.Label] {
+				seen[e.Label] = true
+				choices = append(choices, map[string]interface{}{"name": e.Label})
+			}
+		}
+
+		fields[index].Type = "singleSelect"
+		fields[index].Divisor = 0
+		fields[index].Property = map[string]interface{}{"choices": choices}
+
+		if result == nil {
+			result = make(map[string]*valueMap)
+		}
+		result[m.MysqlField] = vm
+	}
+	return result, nil
+}
+
+// loadValueMap 读取值映射的代码和显示值，查找表的内容按缓存时间缓存
+func (s *MySQLService) loadValueMap(db queryer, config *models.MySQLConfig, vm *models.ValueMapping) ([]models.ValueLabel, error) {
+	if vm.Table == "" {
+		if len(vm.Values) == 0 {
+			return nil, fmt.Errorf("未配置映射值")
+		}
+		if len(vm.Values) > valueMapMaxOptions {
+			return nil, fmt.Errorf("映射值超过 %d 个", valueMapMaxOptions)
+		}
+		for _, v := range vm.Values {
+			if v.Label == "" {
+				return nil, fmt.Errorf("代码 %s 的显示值为空", v.Value)
+			}
+		}
+		return vm.Values, nil
+	}
+
+	if vm.KeyColumn == "" || vm.LabelColumn == "" {
+		return nil, fmt.Errorf("请选择查找表 %s 的代码列和显示值列", vm.Table)
+	}
+
+	// 缓存按连接用户区分，避免不同账号之间共享无权读取的数据
+	cacheKey := fmt.Sprintf("%s@%s:%d/%s.%s(%s,%s)",
+		config.Username, config.Host, config.Port, config.Database, vm.Table, vm.KeyColumn, vm.LabelColumn)
+	if entries, ok := valueMapCache.get(cacheKey); ok {
+		return entries, nil
+	}
+
+	rows, err := db.Query(fmt.Sprintf("SELECT %s, %s FROM %s ORDER BY %s LIMIT %d",
+		quoteIdentifier(vm.KeyColumn), quoteIdentifier(vm.LabelColumn), quoteIdentifier(vm.Table),
+		quoteIdentifier(vm.KeyColumn), valueMapMaxOptions+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取查找表 %s 失败: %w", vm.Table, err)
+	}
+	defer rows.Close()
+
+	var entries []models.ValueLabel
+	for rows.Next() {
+		var key, label sql.NullString
+		if err := rows.Scan(&key, &label); err != nil {
+			return nil, fmt.Errorf("读取查找表 %s 失败: %w", vm.Table, err)
+		}
+		if !key.Valid || !label.Valid || label.String == "" {
+			continue
+		}
+		entries = append(entries, models.ValueLabel{Value: key.String, Label: label.String})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取查找表 %s 失败: %w", vm.Table, err)
+	}
+	if len(entries) > valueMapMaxOptions {
+		return nil, fmt.Errorf("查找表 %s 超过 %d 行，不适合作为值映射", vm.Table, valueMapMaxOptions)
+	}
+
+	ttl := defaultValueMapTTL
+	if vm.CacheTTL > 0 {
+		ttl = time.Duration(vm.CacheTTL) * time.Second
+	}
+	valueMapCache.put(cacheKey, entries, ttl)
+	return entries, nil
+}
+
+// valueMapCache 查找表映射缓存
+var valueMapCache = &lookupCache{entries: make(map[string]*lookupEntry)}
+
+// lookupCache 带过期时间的查找表内容缓存
+type lookupCache struct {
+	mu      sync.Mutex
+	entries map[string]*lookupEntry
+}
+
+type lookupEntry struct {
+	values  []models.ValueLabel
+	expires time.Time
+}
+
+func (c *lookupCache) get(key string) ([]models.ValueLabel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.values, true
+}
+
+func (c *lookupCache) put(key string, values []models.ValueLabel, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 顺带清理已过期的缓存
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = &lookupEntry{values: values, expires: now.Add(ttl)}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/syncstate"
+	"strconv"
+)
+
+// 同步模式
+const (
+	SyncModeFull        = "full"        // 全量同步(默认)
+	SyncModeIncremental = "incremental" // 按水位列增量同步
+	SyncModeCDC         = "cdc"         // 按binlog变更日志增量同步
+)
+
+// WatermarkRange 本次增量同步的水位范围 [Low, High]
+// 在首页确定并写入分页游标，保证同一次同步的所有分页使用相同范围
+// 包含下限，上次同步之后提交的、水位值等于上次最大值的行不会遗漏；
+// 上次同步已输出且内容未变的边界记录(水位值等于下限)按记录ID和内容摘要跳过
+type WatermarkRange struct {
+	Low      *string `json:"l,omitempty"` // 上次同步完成时的水位，nil表示首次同步(全量)
+	High     *string `json:"h,omitempty"` // 本次同步开始时水位列的最大值，nil表示没有数据
+	Boundary string  `json:"b,omitempty"` // 上次同步的ID，其保存的边界记录在本次同步中跳过
+	Tail     string  `json:"t,omitempty"` // 已输出记录末尾的水位值(JSON)，用于跨分页暂存边界记录
+}
+
+// 整数类型水位列，按整数传参避免与字符串比较时按浮点数比较丢失精度
+var integerColumnTypes = map[string]bool{
+	"tinyint":   true,
+	"smallint":  true,
+	"mediumint": true,
+	"int":       true,
+	"integer":   true,
+	"bigint":    true,
+}
+
+// validSyncMode 校验同步模式
+func validSyncMode(mode string) bool {
+	return mode == "" || mode == SyncModeFull || mode == SyncModeIncremental || mode == SyncModeCDC
+}
+
+// resolveWatermarkRange 确定本次增量同步的水位范围
+func (s *MySQLService) resolveWatermarkRange(db queryer, config *models.MySQLConfig, cq *customQuery, targetKey string) (*WatermarkRange, error) {
+	var r WatermarkRange
+
+	saved, err := syncstate.GetStore().GetWatermark(targetKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取同步水位失败: %w", err)
+	}
+	// 水位列变更后已保存的水位失效，重新全量同步
+	if saved != nil && saved.Column == config.WatermarkColumn {
+		r.Low = &saved.Value
+		r.Boundary = saved.SyncID
+	}
+
+	var high sql.NullString
+	source, args := s.recordSource(config, cq)
+	query := fmt.Sprintf("SELECT CAST(MAX(%s) AS CHAR) FROM %s", quoteIdentifier(config.WatermarkColumn), source)
+	if err := db.QueryRow(query, args...).Scan(&high); err != nil {
+		return nil, fmt.Errorf("查询水位列最大值失败: %w", err)
+	}
+	if high.Valid {
+		r.High = &high.String
+	}
+
+	return &r, nil
+}
+
+// watermarkFilter 将水位范围转换为查询条件，并按水位列排序
+func watermarkFilter(r *WatermarkRange, field models.Field) queryFilter {
+	var f queryFilter
+	column := quoteIdentifier(field.Column)
+	f.orderBy = []string{column}
+
+	// 首次同步不加条件，包含水位列为NULL的行
+	if r.Low == nil {
+		return f
+	}
+	f.where(column+" >= ?", watermarkArg(*r.Low, field))
+	if r.High != nil {
+		f.where(column+" <= ?", watermarkArg(*r.High, field))
+	}
+	return f
+}
+
+// applyWatermarkBoundary 处理水位边界上的记录(记录按水位列升序输出)
+// 跳过上次同步已输出且内容未变的边界记录；本页末尾水位值相同的记录暂存为本次同步的边界，
+// 与上一页末尾水位值相同时追加，否则替换，保存水位时生效
+func applyWatermarkBoundary(targetKey, syncID string, r *WatermarkRange, field models.Field, records []models.Record) ([]models.Record, error) {
+	if len(records) == 0 {
+		return records, nil
+	}
+	store := syncstate.GetStore()
+	key := "fid_" + field.Column
+
+	hashes := make([]string, len(records))
+	ids := make([]string, len(records))
+	for i := range records {
+		hashes[i] = recordHash(records[i])
+		ids[i] = records[i].ID
+	}
+
+	tail := watermarkValueKey(records[len(records)-1].Fields[key])
+	start := len(records) - 1
+	for start > 0 && watermarkValueKey(records[start-1].Fields[key]) == tail {
+		start--
+	}
+	boundary := make([]syncstate.RecordState, 0, len(records)-start)
+	for i := start; i < len(records); i++ {
+		boundary = append(boundary, syncstate.RecordState{ID: ids[i], Hash: hashes[i]})
+	}
+	reset := start > 0 || tail != r.Tail
+	if err := store.StageBoundary(targetKey, syncID, reset, boundary); err != nil {
+		return nil, fmt.Errorf("暂存水位边界记录失败: %w", err)
+	}
+	r.Tail = tail
+
+	if r.Low == nil || r.Boundary == "" {
+		return records, nil
+	}
+	previous, err := store.GetBoundary(targetKey, r.Boundary, ids)
+	if err != nil {
+		return nil, fmt.Errorf("读取水位边界记录失败: %w", err)
+	}
+	kept := records[:0]
+	for i, rec := range records {
+		if hash, ok := previous[rec.ID]; ok && hash == hashes[i] {
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	return kept, nil
+}
+
+// watermarkValueKey 记录中水位列的值，用于比较相邻记录的水位是否相同
+func watermarkValueKey(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// watermarkArg 将水位文本转换为查询参数
+func watermarkArg(value string, field models.Field) interface{} {
+	if integerColumnTypes[baseColumnType(field.SourceType)] {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+// saveWatermark 同步完成后保存水位
+func (s *MySQLService) saveWatermark(targetKey, syncID string, config *models.MySQLConfig, column string, r *WatermarkRange) error {
+	if r == nil || r.High == nil {
+		return nil
+	}
+	return syncstate.GetStore().SaveWatermark(&syncstate.Watermark{
+		TargetKey: targetKey,
+		Source:    syncSourceName(config),
+		Column:    column,
+		Value:     *r.High,
+		SyncID:    syncID,
+	})
+}
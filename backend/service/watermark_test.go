@@ -0,0 +1,79 @@
+package service
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/syncstate"
+)
+
+func TestWatermarkFilter(t *testing.T) {
+	field := models.Field{Column: "updated_at", SourceType: "datetime"}
+	low, high := "2026-01-01 00:00:00", "2026-01-02 00:00:00"
+
+	f := watermarkFilter(&WatermarkRange{High: &high}, field)
+	if f.whereClause() != "" {
+		t.Errorf("首次同步不应有条件: %s", f.whereClause())
+	}
+
+	f = watermarkFilter(&WatermarkRange{Low: &low, High: &high}, field)
+	if want := " WHERE `updated_at` >= ? AND `updated_at` <= ?"; f.whereClause() != want {
+		t.Errorf("where = %q, want %q", f.whereClause(), want)
+	}
+	if !reflect.DeepEqual(f.args, []interface{}{low, high}) {
+		t.Errorf("args = %v", f.args)
+	}
+}
+
+func TestApplyWatermarkBoundary(t *testing.T) {
+	if err := syncstate.GetStore().Init(filepath.Join(t.TempDir(), "state.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer syncstate.GetStore().Close()
+
+	field := models.Field{Column: "ts"}
+	rec := func(id string, ts int, name string) models.Record {
+		return models.Record{ID: id, Fields: map[string]interface{}{"fid_ts": ts, "fid_name": name}}
+	}
+	apply := func(syncID string, r *WatermarkRange, records ...models.Record) []string {
+		t.Helper()
+		kept, err := applyWatermarkBoundary("target", syncID, r, field, records)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return trackerIDs(kept)
+	}
+	save := func(syncID, high string) {
+		t.Helper()
+		if err := syncstate.GetStore().SaveWatermark(&syncstate.Watermark{
+			TargetKey: "target", Source: "test", Column: "ts", Value: high, SyncID: syncID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 第一次同步：边界记录 r2、r3 跨两页
+	first := &WatermarkRange{}
+	apply("s1", first, rec("r1", 1, "a"), rec("r2", 2, "b"))
+	apply("s1", first, rec("r3", 2, "c"))
+	save("s1", "2")
+
+	// 第二次同步：包含下限，未变化的边界记录被跳过，变化的和之后提交的同水位记录照常输出
+	low := "2"
+	second := &WatermarkRange{Low: &low, Boundary: "s1"}
+	got := apply("s2", second, rec("r2", 2, "b"), rec("r3", 2, "changed"), rec("r4", 2, "late"), rec("r5", 3, "e"))
+	if want := []string{"r3", "r4", "r5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("第二次同步输出 %v, want %v", got, want)
+	}
+	save("s2", "3")
+
+	// 第三次同步：只有 r5 是上次的边界
+	low = "3"
+	third := &WatermarkRange{Low: &low, Boundary: "s2"}
+	got = apply("s3", third, rec("r4", 3, "late"), rec("r5", 3, "e"))
+	if want := []string{"r4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("第三次同步输出 %v, want %v", got, want)
+	}
+}
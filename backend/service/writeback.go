@@ -0,0 +1,582 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"mysql-sync-plugin/models"
+	"mysql-sync-plugin/writeback"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxWriteBackRecords 单次回调最多回写的记录数
+const maxWriteBackRecords = 500
+
+// writeBackNamePattern 回写目标名称出现在回调地址中，只允许英文、数字、下划线和横线
+var writeBackNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// targetConfig 回写目标的连接配置
+func targetConfig(t *writeback.Target) *models.MySQLConfig {
+	return &models.MySQLConfig{
+		Host:     t.Host,
+		Port:     t.Port,
+		Username: t.Username,
+		Password: t.Password,
+		Database: t.Database,
+		Table:    t.Table,
+		TimeZone: t.TimeZone,
+	}
+}
+
+// ValidateWriteBackTarget 校验回写目标：列的配置，以及使用可写账号能否连接并读取到这些列
+func (s *MySQLService) ValidateWriteBackTarget(t *writeback.Target) error {
+	if !writeBackNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("名称只能包含英文、数字、下划线和横线")
+	}
+	if t.Host == "" || t.Port <= 0 || t.Username == "" || t.Database == "" || t.Table == "" {
+		return fmt.Errorf("请填写完整的连接信息和数据表")
+	}
+	if len(t.KeyColumns) == 0 {
+		return fmt.Errorf("请指定主键列")
+	}
+	if len(t.WritableColumns) == 0 {
+		return fmt.Errorf("请指定允许回写的列")
+	}
+	if !writeback.ValidVersionMode(t.VersionMode) || t.VersionColumn == "" {
+		return fmt.Errorf("请指定乐观并发检查的版本列及检查方式")
+	}
+
+	keys := make(map[string]bool)
+	for _, k := range t.KeyColumns {
+		keys[k] = true
+	}
+	for _, c := range t.WritableColumns {
+		if keys[c] {
+			return fmt.Errorf("主键列 %s 不允许回写", c)
+		}
+		if c == t.VersionColumn {
+			return fmt.Errorf("版本列 %s 不允许回写", c)
+		}
+	}
+	if keys[t.VersionColumn] {
+		return fmt.Errorf("版本列不能是主键列")
+	}
+
+	if _, err := sourceLocation(targetConfig(t)); err != nil {
+		return err
+	}
+
+	db, err := s.connectDB(targetConfig(t))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fields, err := s.getTableSchema(db, t.Database, t.Table)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("数据表 %s 不存在或可写账号无权读取", t.Table)
+	}
+	types := make(map[string]string, len(fields))
+	for _, f := range fields {
+		types[f.Column] = baseColumnType(f.SourceType)
+	}
+
+	for _, c := range append(append([]string{}, t.KeyColumns...), t.WritableColumns...) {
+		if _, ok := types[c]; !ok {
+			return fmt.Errorf("列 %s 不存在", c)
+		}
+	}
+
+	primary, err := s.getPrimaryKey(db, t.Database, t.Table)
+	if err != nil {
+		return err
+	}
+	if t.KeyColumns, err = recordKeyColumns(fields, t.KeyColumns, primary); err != nil {
+		return err
+	}
+	versionType, ok := types[t.VersionColumn]
+	if !ok {
+		return fmt.Errorf("版本列 %s 不存在", t.VersionColumn)
+	}
+	if t.VersionMode == writeback.VersionModeNumber && !integerColumnTypes[versionType] {
+		return fmt.Errorf("版本列 %s 必须是整数类型", t.VersionColumn)
+	}
+	if t.VersionMode == writeback.VersionModeTimestamp && versionType != "datetime" && versionType != "timestamp" {
+		return fmt.Errorf("更新时间列 %s 必须是 DATETIME 或 TIMESTAMP 类型", t.VersionColumn)
+	}
+	return nil
+}
+
+// getPrimaryKey 按主键中的顺序读取数据表的主键列
+func (s *MySQLService) getPrimaryKey(db *sql.DB, database, table string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT COLUMN_NAME
+		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY ORDINAL_POSITION
+	`, database, table)
+	if err != nil {
+		return nil, fmt.Errorf("查询主键失败: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// recordKeyColumns 校验配置的主键列就是数据表的主键，并按记录ID中键值的顺序排列
+// 同步时记录ID按字段顺序(即表中列的顺序，见 keyColumns)拼接键值，与主键定义中的列顺序可能不同
+func recordKeyColumns(fields []models.Field, configured, primary []string) ([]string, error) {
+	if len(primary) == 0 {
+		return nil, fmt.Errorf("数据表没有主键，无法按记录ID定位要回写的记录")
+	}
+	isPrimary := make(map[string]bool, len(primary))
+	for _, c := range primary {
+		isPrimary[c] = true
+	}
+	seen := make(map[string]bool, len(configured))
+	for _, c := range configured {
+		if !isPrimary[c] || seen[c] {
+			return nil, fmt.Errorf("主键列应为数据表的主键 %s", strings.Join(primary, ", "))
+		}
+		seen[c] = true
+	}
+	if len(seen) != len(primary) {
+		return nil, fmt.Errorf("主键列应为数据表的主键 %s", strings.Join(primary, ", "))
+	}
+
+	return orderedKeyColumns(fields, configured), nil
+}
+
+// orderedKeyColumns 按字段顺序排列键列，与同步时生成记录ID的顺序一致
+func orderedKeyColumns(fields []models.Field, columns []string) []string {
+	set := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		set[c] = true
+	}
+	ordered := make([]string, 0, len(columns))
+	for _, f := range fields {
+		if set[f.Column] {
+			ordered = append(ordered, f.Column)
+		}
+	}
+	return ordered
+}
+
+// keyCondition 解码记录ID，生成按键列定位记录的条件和参数
+func keyCondition(keyColumns []string, recordID string) (string, []interface{}, error) {
+	keys, err := decodeRecordID(recordID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(keys) != len(keyColumns) {
+		return "", nil, fmt.Errorf("记录ID包含 %d 个键值，回写目标的主键有 %d 列", len(keys), len(keyColumns))
+	}
+
+	where := make([]string, len(keyColumns))
+	args := make([]interface{}, len(keyColumns))
+	for i, k := range keyColumns {
+		if keys[i] == nil {
+			return "", nil, fmt.Errorf("主键值不能为 NULL")
+		}
+		where[i] = quoteIdentifier(k) + " = ?"
+		args[i] = *keys[i]
+	}
+	return strings.Join(where, " AND "), args, nil
+}
+
+// ApplyWriteBack 将表格中修改的记录回写到回写目标
+// 每条记录在独立事务中执行：锁定记录、检查版本、更新允许回写的列并推进版本；
+// 每次尝试(包括被拒绝和冲突的)都写入审计记录，修改数据库前先登记待确认的审计记录，登记失败时不回写。
+// 重放的回调由签名中的随机串拦截(见 writeback.Store.UseNonce)，版本检查另外防止覆盖他人的修改。
+func (s *MySQLService) ApplyWriteBack(t *writeback.Target, cb *writeback.Callback, clientIP string) ([]writeback.RecordResult, error) {
+	if len(cb.Records) == 0 {
+		return nil, fmt.Errorf("回调中没有记录")
+	}
+	if len(cb.Records) > maxWriteBackRecords {
+		return nil, fmt.Errorf("单次回调最多 %d 条记录", maxWriteBackRecords)
+	}
+
+	config := targetConfig(t)
+	loc, err := sourceLocation(config)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := s.connectDB(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	fields, err := s.getTableSchema(db, t.Database, t.Table)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(fields))
+	for _, f := range fields {
+		types[f.Column] = baseColumnType(f.SourceType)
+	}
+
+	w := &recordWriter{
+		target: t,
+		db:     db,
+		conv:   &valueConverter{location: loc},
+		types:  types,
+		// 按表中列的顺序解码，兼容修正顺序前保存的回写目标
+		keyColumns: orderedKeyColumns(fields, t.KeyColumns),
+		writable:   make(map[string]bool, len(t.WritableColumns)),
+	}
+	for _, c := range t.WritableColumns {
+		w.writable[c] = true
+	}
+
+	results := make([]writeback.RecordResult, 0, len(cb.Records))
+	for _, change := range cb.Records {
+		audit := &writeback.Audit{
+			TargetID:   t.ID,
+			TargetName: t.Name,
+			EventID:    cb.EventID,
+			RecordID:   change.RecordID,
+			Operator:   cb.Operator,
+			ClientIP:   clientIP,
+		}
+		result := w.write(change, audit)
+		audit.Status = result.Status
+		audit.Error = result.Error
+		var err error
+		if audit.ID == 0 {
+			err = writeback.GetStore().AddAudit(audit)
+		} else {
+			err = writeback.GetStore().UpdateAudit(audit)
+		}
+		if err != nil {
+			// 已登记的审计记录保持待确认状态，如实告知调用方
+			result.Error = strings.TrimPrefix(result.Error+"; 更新审计记录失败: "+err.Error(), "; ")
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// recordWriter 单次回调内的记录回写
+type recordWriter struct {
+	target     *writeback.Target
+	db         *sql.DB
+	conv       *valueConverter
+	types      map[string]string // 列名 -> 列类型
+	keyColumns []string          // 按记录ID中键值顺序排列的主键列
+	writable   map[string]bool
+}
+
+// write 回写单条记录，并填充审计记录的修改内容和版本
+func (w *recordWriter) write(change writeback.RecordChange, audit *writeback.Audit) writeback.RecordResult {
+	t := w.target
+	result := writeback.RecordResult{RecordID: change.RecordID}
+	reject := func(format string, args ...interface{}) writeback.RecordResult {
+		result.Status = writeback.StatusRejected
+		result.Error = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	whereClause, keyArgs, err := keyCondition(w.keyColumns, change.RecordID)
+	if err != nil {
+		return reject("%v", err)
+	}
+
+	if len(change.Fields) == 0 {
+		return reject("没有需要回写的列")
+	}
+	columns := make([]string, 0, len(change.Fields))
+	for column := range change.Fields {
+		if !w.writable[column] {
+			return reject("列 %s 不允许回写", column)
+		}
+		if _, ok := w.types[column]; !ok {
+			return reject("列 %s 不存在", column)
+		}
+		columns = append(columns, column)
+	}
+	// 按配置顺序排列，保证审计记录和SQL稳定
+	columns = orderedColumns(t.WritableColumns, columns)
+
+	newValues := make([]interface{}, len(columns))
+	for i, column := range columns {
+		v, err := w.dbValue(change.Fields[column], w.types[column])
+		if err != nil {
+			return reject("列 %s: %v", column, err)
+		}
+		newValues[i] = v
+		audit.Changes = append(audit.Changes, writeback.ColumnChange{Column: column, New: auditText(v)})
+	}
+
+	if change.Version == nil {
+		return reject("缺少版本值，无法进行并发检查")
+	}
+	expected, err := w.versionText(change.Version)
+	if err != nil {
+		return reject("%v", err)
+	}
+	audit.ExpectedVersion = expected
+
+	fail := func(err error) writeback.RecordResult {
+		result.Status = writeback.StatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	// 修改数据库前登记审计记录，保证每次提交的修改都有审计记录
+	audit.Status = writeback.StatusPending
+	if err := writeback.GetStore().AddAudit(audit); err != nil {
+		audit.ID = 0
+		return fail(fmt.Errorf("写入审计记录失败，未回写: %w", err))
+	}
+
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fail(err)
+	}
+	defer tx.Rollback()
+
+	// 锁定记录，读取当前版本和修改前的值
+	selectColumns := []string{quoteIdentifier(t.VersionColumn)}
+	for _, c := range columns {
+		selectColumns = append(selectColumns, quoteIdentifier(c))
+	}
+	current := make([]interface{}, len(selectColumns))
+	dest := make([]interface{}, len(selectColumns))
+	for i := range current {
+		dest[i] = &current[i]
+	}
+	err = tx.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE %s FOR UPDATE",
+		strings.Join(selectColumns, ", "), quoteIdentifier(t.Table), whereClause), keyArgs...).Scan(dest...)
+	if err == sql.ErrNoRows {
+		result.Status = writeback.StatusNotFound
+		result.Error = "记录不存在"
+		return result
+	}
+	if err != nil {
+		return fail(err)
+	}
+	for i := range columns {
+		audit.Changes[i].Old = w.auditValue(current[i+1], w.types[columns[i]])
+	}
+
+	actual := w.currentVersion(current[0])
+	if actual != expected {
+		result.Status = writeback.StatusConflict
+		result.Version = actual
+		result.Error = fmt.Sprintf("记录已被修改(当前版本 %s，表格中的版本 %s)，请同步后重新编辑", actual, expected)
+		return result
+	}
+
+	// 更新允许回写的列并推进版本
+	var set []string
+	args := make([]interface{}, 0, len(columns)+1+len(keyArgs))
+	for i, c := range columns {
+		set = append(set, quoteIdentifier(c)+" = ?")
+		args = append(args, newValues[i])
+	}
+	versionColumn := quoteIdentifier(t.VersionColumn)
+	var newVersion string
+	if t.VersionMode == writeback.VersionModeNumber {
+		set = append(set, versionColumn+" = "+versionColumn+" + 1")
+		n, _ := strconv.ParseInt(actual, 10, 64)
+		newVersion = strconv.FormatInt(n+1, 10)
+	} else {
+		// 截断到秒，避免列精度不足时MySQL舍入导致返回的版本与实际写入的不一致
+		now := time.Now().Truncate(time.Second)
+		set = append(set, versionColumn+" = ?")
+		args = append(args, w.timeText(now, w.types[t.VersionColumn]))
+		newVersion = strconv.FormatInt(now.UnixMilli(), 10)
+	}
+	args = append(args, keyArgs...)
+
+	if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		quoteIdentifier(t.Table), strings.Join(set, ", "), whereClause), args...); err != nil {
+		return fail(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	audit.NewVersion = newVersion
+	result.Status = writeback.StatusApplied
+	result.Version = newVersion
+	return result
+}
+
+// orderedColumns 按允许回写的列的配置顺序排列
+func orderedColumns(order []string, columns []string) []string {
+	set := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		set[c] = true
+	}
+	var result []string
+	for _, c := range order {
+		if set[c] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// versionText 将表格中的版本值转换为规范文本：版本号为整数文本，更新时间为毫秒时间戳
+func (w *recordWriter) versionText(v interface{}) (string, error) {
+	if w.target.VersionMode == writeback.VersionModeNumber {
+		switch val := v.(type) {
+		case float64:
+			if val != float64(int64(val)) {
+				return "", fmt.Errorf("版本值 %v 不是整数", val)
+			}
+			return strconv.FormatInt(int64(val), 10), nil
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("版本值 %s 不是整数", val)
+			}
+			return strconv.FormatInt(n, 10), nil
+		}
+		return "", fmt.Errorf("无效的版本值: %v", v)
+	}
+
+	var ts interface{}
+	switch val := v.(type) {
+	case float64:
+		ts = w.conv.toTimestamp(int64(val))
+	case string:
+		ts = w.conv.parseTimestamp(val)
+	}
+	if ts == nil {
+		return "", fmt.Errorf("无效的更新时间: %v", v)
+	}
+	return strconv.FormatInt(ts.(int64), 10), nil
+}
+
+// currentVersion 将数据库中的版本值转换为与 versionText 一致的规范文本
+func (w *recordWriter) currentVersion(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if w.target.VersionMode == writeback.VersionModeNumber {
+		return keyValueText(v)
+	}
+	if ts, ok := w.conv.toTimestamp(v).(int64); ok {
+		return strconv.FormatInt(ts, 10)
+	}
+	return ""
+}
+
+// dbValue 将回调中的单元格值转换为写入数据库的参数
+// 日期列接收毫秒时间戳或文本；多选等数组以逗号拼接；链接等对象取其文本
+func (w *recordWriter) dbValue(v interface{}, columnType string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch columnType {
+	case "date", "datetime", "timestamp":
+		var ts interface{}
+		switch val := v.(type) {
+		case float64:
+			ts = w.conv.toTimestamp(int64(val))
+		case string:
+			if strings.TrimSpace(val) == "" {
+				return nil, nil
+			}
+			ts = w.conv.parseTimestamp(val)
+		}
+		if ts == nil {
+			return nil, fmt.Errorf("无效的日期: %v", v)
+		}
+		return w.timeText(time.UnixMilli(ts.(int64)), columnType), nil
+	}
+
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+	case []interface{}:
+		// 富文本分段拼接，其他数组(多选、多个链接等)以逗号分隔
+		var parts []string
+		segments := true
+		for _, item := range val {
+			text, isSegment := cellText(item)
+			segments = segments && isSegment
+			parts = append(parts, text)
+		}
+		if segments {
+			return strings.Join(parts, ""), nil
+		}
+		return strings.Join(parts, ","), nil
+	case map[string]interface{}:
+		text, _ := cellText(val)
+		return text, nil
+	}
+	return nil, fmt.Errorf("不支持的值: %v", v)
+}
+
+// cellText 取单元格值的文本，对象取 text 字段(链接、富文本分段等)
+func cellText(v interface{}) (string, bool) {
+	if m, ok := v.(map[string]interface{}); ok {
+		if text, ok := m["text"].(string); ok {
+			return text, true
+		}
+		b, _ := json.Marshal(m)
+		return string(b), false
+	}
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64), false
+	}
+	return toText(v), false
+}
+
+// timeText 将时间点格式化为写入日期列的文本
+// 会话时区与源库时区一致，DATE/DATETIME 和 TIMESTAMP 均写入源库时区的墙上时间
+func (w *recordWriter) timeText(t time.Time, columnType string) string {
+	if columnType == "date" {
+		return t.In(w.conv.location).Format("2006-01-02")
+	}
+	return t.In(w.conv.location).Format("2006-01-02 15:04:05.000")
+}
+
+// auditValue 将数据库中修改前的值转换为审计文本
+func (w *recordWriter) auditValue(v interface{}, columnType string) *string {
+	if v == nil {
+		return nil
+	}
+	if t, ok := v.(time.Time); ok {
+		text := w.conv.formatTime(t, columnType)
+		return &text
+	}
+	text := keyValueText(v)
+	return &text
+}
+
+// auditText 将写入的参数转换为审计文本
+func auditText(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	text := keyValueText(v)
+	return &text
+}
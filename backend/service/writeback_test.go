@@ -0,0 +1,100 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"mysql-sync-plugin/models"
+)
+
+func TestWriteBackKeyRoundTrip(t *testing.T) {
+	// 主键定义为 (order_no, shop_id)，表中 shop_id 在前
+	fields := []models.Field{
+		{Column: "shop_id", IsPrimary: true},
+		{Column: "name"},
+		{Column: "order_no", IsPrimary: true},
+	}
+	primary := []string{"order_no", "shop_id"}
+
+	tests := []struct {
+		name   string
+		values []interface{} // 按字段顺序排列的键值
+		want   []interface{}
+	}{
+		{"普通值", []interface{}{int64(7), "A-1"}, []interface{}{"7", "A-1"}},
+		{"下划线和分隔符", []interface{}{"a_S_N", "x__y"}, []interface{}{"a_S_N", "x__y"}},
+		{"多字节和空文本", []interface{}{"数据", ""}, []interface{}{"数据", ""}},
+	}
+
+	for _, configured := range [][]string{primary, {"shop_id", "order_no"}} {
+		keys, err := recordKeyColumns(fields, configured, primary)
+		if err != nil {
+			t.Fatalf("recordKeyColumns(%v): %v", configured, err)
+		}
+		if want := keyColumns(fields); !reflect.DeepEqual(keys, want) {
+			t.Fatalf("recordKeyColumns(%v) = %v, want %v", configured, keys, want)
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				where, args, err := keyCondition(keys, encodeRecordID(tt.values))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := "`shop_id` = ? AND `order_no` = ?"; where != want {
+					t.Errorf("where = %s, want %s", where, want)
+				}
+				if !reflect.DeepEqual(args, tt.want) {
+					t.Errorf("args = %q, want %q", args, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestRecordKeyColumnsErrors(t *testing.T) {
+	fields := []models.Field{{Column: "id"}, {Column: "tenant"}, {Column: "name"}}
+
+	tests := []struct {
+		name       string
+		configured []string
+		primary    []string
+		wantErr    string
+	}{
+		{"没有主键", []string{"id"}, nil, "没有主键"},
+		{"不是主键列", []string{"name"}, []string{"id"}, "应为数据表的主键"},
+		{"缺少主键列", []string{"id"}, []string{"id", "tenant"}, "应为数据表的主键"},
+		{"重复的列", []string{"id", "id"}, []string{"id", "tenant"}, "应为数据表的主键"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := recordKeyColumns(fields, tt.configured, tt.primary)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyConditionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr string
+	}{
+		{"键值个数不符", encodeRecordID([]interface{}{"1"}), "包含 1 个键值"},
+		{"NULL键值", encodeRecordID([]interface{}{"1", nil}), "不能为 NULL"},
+		{"摘要缩短的ID", encodeRecordID([]interface{}{strings.Repeat("x", 200), "1"}), "无法解码"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := keyCondition([]string{"a", "b"}, tt.id)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("keyCondition(%q) err = %v, want containing %q", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
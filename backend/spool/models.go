@@ -0,0 +1,39 @@
+package spool
+
+import "time"
+
+// 缓存状态
+const (
+	StatusBuilding = "building" // 正在写入
+	StatusReady    = "ready"    // 写入完成，可读取
+)
+
+// Spool 一份物化的查询结果
+type Spool struct {
+	ID        int64     `json:"id"`
+	CacheKey  string    `json:"cacheKey"` // 缓存键(连接与SQL的摘要)
+	Source    string    `json:"source"`   // 数据源描述，便于在管理后台识别
+	Columns   []Column  `json:"columns"`  // 结果集列信息(行数据按此顺序保存)
+	Status    string    `json:"status"`
+	RowCount  int       `json:"rowCount"`
+	SizeBytes int64     `json:"sizeBytes"`
+	Hits      int64     `json:"hits"` // 从该缓存读取的分页数
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Column 结果集列信息
+type Column struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`      // 结果集报告的类型
+	Precision int    `json:"precision"` // 数值精度，-1表示未知
+	Scale     int    `json:"scale"`     // 数值小数位数，-1表示未知
+}
+
+// Stats 缓存统计
+type Stats struct {
+	Hits      int64 `json:"hits"`      // 从缓存读取的分页数
+	Misses    int64 `json:"misses"`    // 需要执行查询并写入缓存的次数
+	Spools    int   `json:"spools"`    // 当前缓存数
+	SizeBytes int64 `json:"sizeBytes"` // 当前缓存总大小
+}
@@ -0,0 +1,336 @@
+package spool
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store 查询结果缓存存储
+type Store struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// GetStore 获取缓存存储单例
+func GetStore() *Store {
+	once.Do(func() {
+		instance = &Store{}
+	})
+	return instance
+}
+
+// Init 初始化数据库
+func (s *Store) Init(dbPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	// 创建缓存表
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS spools (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cache_key TEXT NOT NULL,
+		source TEXT NOT NULL,
+		columns TEXT NOT NULL,
+		status TEXT NOT NULL,
+		row_count INTEGER NOT NULL DEFAULT 0,
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		hits INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_spools_cache_key ON spools(cache_key, status, expires_at);
+	CREATE TABLE IF NOT EXISTS spool_rows (
+		spool_id INTEGER NOT NULL,
+		seq INTEGER NOT NULL,
+		data BLOB NOT NULL,
+		PRIMARY KEY (spool_id, seq)
+	);
+	CREATE TABLE IF NOT EXISTS spool_stats (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		hits INTEGER NOT NULL DEFAULT 0,
+		misses INTEGER NOT NULL DEFAULT 0
+	);
+	INSERT OR IGNORE INTO spool_stats (id) VALUES (1);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		db.Close()
+		return fmt.Errorf("创建表失败: %w", err)
+	}
+
+	// 上次退出时未写完的缓存不可用
+	if err := deleteSpools(db, "status = ?", StatusBuilding); err != nil {
+		db.Close()
+		return fmt.Errorf("清理未完成的缓存失败: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// FindReady 查找未过期的可用缓存，不存在时返回nil
+func (s *Store) FindReady(cacheKey string) (*Spool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row := s.db.QueryRow(
+		selectSpoolSQL+" WHERE cache_key = ? AND status = ? AND expires_at > ? ORDER BY id DESC LIMIT 1",
+		cacheKey, StatusReady, time.Now(),
+	)
+	return scanSpool(row)
+}
+
+// Get 按ID获取缓存，不存在时返回nil
+func (s *Store) Get(id int64) (*Spool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return scanSpool(s.db.QueryRow(selectSpoolSQL+" WHERE id = ?", id))
+}
+
+// Create 创建写入中的缓存
+func (s *Store) Create(cacheKey, source string, columns []Column, ttl time.Duration) (*Spool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sp := &Spool{
+		CacheKey:  cacheKey,
+		Source:    source,
+		Columns:   columns,
+		Status:    StatusBuilding,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	columnsJSON, _ := json.Marshal(columns)
+	result, err := s.db.Exec(
+		"INSERT INTO spools (cache_key, source, columns, status, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		sp.CacheKey, sp.Source, string(columnsJSON), sp.Status, sp.CreatedAt, sp.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if sp.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// AppendRows 追加一批行，seq 为第一行的序号(从0开始)
+func (s *Store) AppendRows(spoolID int64, seq int, rows [][]byte) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT INTO spool_rows (spool_id, seq, data) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var size int64
+	for i, data := range rows {
+		if _, err := stmt.Exec(spoolID, seq+i, data); err != nil {
+			return err
+		}
+		size += int64(len(data))
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE spools SET row_count = row_count + ?, size_bytes = size_bytes + ? WHERE id = ?",
+		len(rows), size, spoolID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkReady 标记缓存写入完成
+func (s *Store) MarkReady(spoolID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("UPDATE spools SET status = ? WHERE id = ?", StatusReady, spoolID)
+	return err
+}
+
+// ReadRows 按序号读取一页行数据
+func (s *Store) ReadRows(spoolID int64, offset, limit int) ([][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(
+		"SELECT data FROM spool_rows WHERE spool_id = ? AND seq >= ? ORDER BY seq LIMIT ?",
+		spoolID, offset, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list [][]byte
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		list = append(list, data)
+	}
+	return list, rows.Err()
+}
+
+// RecordHit 记录一次缓存命中
+func (s *Store) RecordHit(spoolID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("UPDATE spools SET hits = hits + 1 WHERE id = ?", spoolID); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("UPDATE spool_stats SET hits = hits + 1 WHERE id = 1")
+	return err
+}
+
+// RecordMiss 记录一次缓存未命中
+func (s *Store) RecordMiss() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("UPDATE spool_stats SET misses = misses + 1 WHERE id = 1")
+	return err
+}
+
+// List 获取全部缓存
+func (s *Store) List() ([]Spool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(selectSpoolSQL + " ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Spool
+	for rows.Next() {
+		sp, err := scanSpool(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *sp)
+	}
+	return list, rows.Err()
+}
+
+// GetStats 获取缓存统计
+func (s *Store) GetStats() (*Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stats Stats
+	if err := s.db.QueryRow("SELECT hits, misses FROM spool_stats WHERE id = 1").Scan(&stats.Hits, &stats.Misses); err != nil {
+		return nil, err
+	}
+	if err := s.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(size_bytes), 0) FROM spools").Scan(&stats.Spools, &stats.SizeBytes); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Delete 删除缓存
+func (s *Store) Delete(spoolID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return deleteSpools(s.db, "id = ?", spoolID)
+}
+
+// CleanExpired 删除过期超过 grace 的缓存(保留宽限期，供进行中的同步读完剩余分页)
+func (s *Store) CleanExpired(grace time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	cutoff := time.Now().Add(-grace)
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM spools WHERE expires_at < ?", cutoff).Scan(&count); err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return count, deleteSpools(s.db, "expires_at < ?", cutoff)
+}
+
+const selectSpoolSQL = "SELECT id, cache_key, source, columns, status, row_count, size_bytes, hits, created_at, expires_at FROM spools"
+
+// rowScanner *sql.Row 和 *sql.Rows 的公共接口
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSpool 扫描缓存记录，不存在时返回nil
+func scanSpool(row rowScanner) (*Spool, error) {
+	var sp Spool
+	var columns string
+	err := row.Scan(&sp.ID, &sp.CacheKey, &sp.Source, &columns, &sp.Status, &sp.RowCount, &sp.SizeBytes, &sp.Hits, &sp.CreatedAt, &sp.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(columns), &sp.Columns)
+	return &sp, nil
+}
+
+// deleteSpools 删除满足条件的缓存及其行数据
+func deleteSpools(db *sql.DB, where string, args ...interface{}) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM spool_rows WHERE spool_id IN (SELECT id FROM spools WHERE "+where+")", args...); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM spools WHERE "+where, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
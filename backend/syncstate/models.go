@@ -0,0 +1,55 @@
+package syncstate
+
+import "time"
+
+// Watermark 增量同步水位
+type Watermark struct {
+	TargetKey string    `json:"targetKey"` // 同步目标标识(钉钉 corpId+表格ID，飞书 tenantKey+bizInstanceID，加数据源配置摘要)
+	Source    string    `json:"source"`    // 数据源描述，便于在管理后台识别
+	Column    string    `json:"column"`    // 水位列
+	Value     string    `json:"value"`     // 上次同步完成时的水位值(MySQL文本格式)
+	SyncID    string    `json:"-"`         // 保存水位的同步ID，其边界记录在下次同步中跳过
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SyncReport 单次同步的报告
+type SyncReport struct {
+	ID             int64      `json:"id"`
+	TargetKey      string     `json:"targetKey"`
+	SyncID         string     `json:"syncId"`
+	Source         string     `json:"source"`
+	SyncMode       string     `json:"syncMode"`
+	RecordCount    int64      `json:"recordCount"`    // 输出的记录数(不含墓碑记录)
+	NewCount       int64      `json:"newCount"`       // 新增的记录数
+	ChangedCount   int64      `json:"changedCount"`   // 内容变化的记录数
+	UnchangedCount int64      `json:"unchangedCount"` // 内容未变化的记录数(仅输出变化记录时跳过)
+	DeletedCount   int64      `json:"deletedCount"`   // 检测到的删除记录数
+	DeletedIDs     []string   `json:"deletedIds"`     // 删除的记录ID(最多保留 MaxReportDeletedIDs 个)
+	Status         string     `json:"status"`         // running / finished
+	StartedAt      time.Time  `json:"startedAt"`
+	FinishedAt     *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ReportDelta 同步报告的增量统计
+type ReportDelta struct {
+	Records    int
+	New        int
+	Changed    int
+	Unchanged  int
+	DeletedIDs []string
+}
+
+// RecordState 记录在同步目标中的状态
+type RecordState struct {
+	ID      string
+	Hash    string // 最近一次完成的同步中的记录内容摘要，为空表示尚未成功同步过
+	Deleted bool
+}
+
+// ReportQuery 同步报告查询参数
+type ReportQuery struct {
+	TargetKey string `form:"targetKey"`
+	Page      int    `form:"page"`
+	PageSize  int    `form:"pageSize"`
+}
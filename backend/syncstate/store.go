@@ -0,0 +1,742 @@
+package syncstate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// 同步报告中最多保留的删除记录ID数
+const MaxReportDeletedIDs = 1000
+
+// 同步报告状态
+const (
+	ReportStatusRunning  = "running"
+	ReportStatusFinished = "finished"
+)
+
+// Store 同步状态存储
+type Store struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// GetStore 获取同步状态存储单例
+func GetStore() *Store {
+	once.Do(func() {
+		instance = &Store{}
+	})
+	return instance
+}
+
+// Init 初始化数据库
+func (s *Store) Init(dbPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	// 创建水位表
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS sync_watermarks (
+		target_key TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		column_name TEXT NOT NULL,
+		value TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS sync_records (
+		target_key TEXT NOT NULL,
+		record_id TEXT NOT NULL,
+		row_hash TEXT NOT NULL DEFAULT '',
+		pending_hash TEXT,
+		sync_id TEXT NOT NULL,
+		deleted INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (target_key, record_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_sync_records_sync ON sync_records(target_key, deleted, sync_id);
+	CREATE TABLE IF NOT EXISTS sync_reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		target_key TEXT NOT NULL,
+		sync_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		sync_mode TEXT NOT NULL,
+		record_count INTEGER NOT NULL DEFAULT 0,
+		new_count INTEGER NOT NULL DEFAULT 0,
+		changed_count INTEGER NOT NULL DEFAULT 0,
+		unchanged_count INTEGER NOT NULL DEFAULT 0,
+		deleted_count INTEGER NOT NULL DEFAULT 0,
+		deleted_ids TEXT NOT NULL DEFAULT '[]',
+		status TEXT NOT NULL DEFAULT 'running',
+		started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		finished_at DATETIME,
+		UNIQUE (target_key, sync_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_sync_reports_started_at ON sync_reports(started_at);
+	CREATE TABLE IF NOT EXISTS sync_boundaries (
+		target_key TEXT NOT NULL,
+		sync_id TEXT NOT NULL,
+		record_id TEXT NOT NULL,
+		row_hash TEXT NOT NULL,
+		PRIMARY KEY (target_key, sync_id, record_id)
+	);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		db.Close()
+		return fmt.Errorf("创建表失败: %w", err)
+	}
+
+	// 旧版本创建的报告表缺少变更统计列
+	if err := addMissingColumns(db, "sync_reports", map[string]string{
+		"new_count":       "INTEGER NOT NULL DEFAULT 0",
+		"changed_count":   "INTEGER NOT NULL DEFAULT 0",
+		"unchanged_count": "INTEGER NOT NULL DEFAULT 0",
+	}); err != nil {
+		db.Close()
+		return fmt.Errorf("升级表结构失败: %w", err)
+	}
+	// 旧版本创建的水位表缺少同步ID列
+	if err := addMissingColumns(db, "sync_watermarks", map[string]string{
+		"sync_id": "TEXT NOT NULL DEFAULT ''",
+	}); err != nil {
+		db.Close()
+		return fmt.Errorf("升级表结构失败: %w", err)
+	}
+	// 旧版本创建的记录表缺少暂存摘要列
+	if err := addMissingColumns(db, "sync_records", map[string]string{
+		"pending_hash": "TEXT",
+	}); err != nil {
+		db.Close()
+		return fmt.Errorf("升级表结构失败: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// addMissingColumns 为已存在的表补充缺少的列
+func addMissingColumns(db *sql.DB, table string, columns map[string]string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for name, def := range columns {
+		if existing[name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, def)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// GetWatermark 获取同步目标的水位，不存在时返回nil
+func (s *Store) GetWatermark(targetKey string) (*Watermark, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var w Watermark
+	err := s.db.QueryRow(
+		"SELECT target_key, source, column_name, value, sync_id, created_at, updated_at FROM sync_watermarks WHERE target_key = ?",
+		targetKey,
+	).Scan(&w.TargetKey, &w.Source, &w.Column, &w.Value, &w.SyncID, &w.CreatedAt, &w.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// SaveWatermark 保存水位(存在则更新)，同时启用该次同步暂存的边界记录并清除其他同步的边界记录
+func (s *Store) SaveWatermark(w *Watermark) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO sync_watermarks (target_key, source, column_name, value, sync_id, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(target_key) DO UPDATE SET
+			source = excluded.source,
+			column_name = excluded.column_name,
+			value = excluded.value,
+			sync_id = excluded.sync_id,
+			updated_at = excluded.updated_at`,
+		w.TargetKey, w.Source, w.Column, w.Value, w.SyncID, time.Now(),
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM sync_boundaries WHERE target_key = ? AND sync_id != ?", w.TargetKey, w.SyncID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListWatermarks 获取所有水位
+func (s *Store) ListWatermarks() ([]Watermark, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(
+		"SELECT target_key, source, column_name, value, created_at, updated_at FROM sync_watermarks ORDER BY updated_at DESC",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Watermark
+	for rows.Next() {
+		var w Watermark
+		if err := rows.Scan(&w.TargetKey, &w.Source, &w.Column, &w.Value, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+
+	return list, rows.Err()
+}
+
+// DeleteWatermark 删除水位，下次同步将执行全量同步
+func (s *Store) DeleteWatermark(targetKey string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM sync_watermarks WHERE target_key = ?", targetKey)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM sync_boundaries WHERE target_key = ?", targetKey); err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affected, tx.Commit()
+}
+
+// StageBoundary 暂存本次同步中水位值等于当前最大值的记录及内容摘要，reset 时先清除已暂存的记录
+// 保存水位时生效，下次同步据此跳过已输出且内容未变的边界记录
+func (s *Store) StageBoundary(targetKey, syncID string, reset bool, records []RecordState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if reset {
+		if _, err := tx.Exec("DELETE FROM sync_boundaries WHERE target_key = ? AND sync_id = ?", targetKey, syncID); err != nil {
+			return err
+		}
+	}
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO sync_boundaries (target_key, sync_id, record_id, row_hash) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range records {
+		if _, err := stmt.Exec(targetKey, syncID, r.ID, r.Hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetBoundary 查询指定同步保存的边界记录，返回 记录ID -> 内容摘要
+func (s *Store) GetBoundary(targetKey, syncID string, ids []string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]string)
+	const batchSize = 500
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+
+		args := make([]interface{}, 0, len(batch)+2)
+		args = append(args, targetKey, syncID)
+		for _, id := range batch {
+			args = append(args, id)
+		}
+		rows, err := s.db.Query(
+			"SELECT record_id, row_hash FROM sync_boundaries WHERE target_key = ? AND sync_id = ? AND record_id IN (?"+strings.Repeat(", ?", len(batch)-1)+")",
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id, hash string
+			if err := rows.Scan(&id, &hash); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			result[id] = hash
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// GetRecordStates 查询记录在同步目标中的状态，不存在的记录不在结果中
+// 内容摘要为最近一次完成的同步中的摘要，进行中或中断的同步暂存的摘要不生效
+func (s *Store) GetRecordStates(targetKey string, ids []string) (map[string]RecordState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := make(map[string]RecordState, len(ids))
+	// 分批查询，避免超出SQLite参数个数限制
+	const batchSize = 500
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+
+		args := make([]interface{}, 0, len(batch)+1)
+		args = append(args, targetKey)
+		for _, id := range batch {
+			args = append(args, id)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+
+		rows, err := s.db.Query(
+			"SELECT record_id, row_hash, deleted FROM sync_records WHERE target_key = ? AND record_id IN ("+placeholders+")",
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var st RecordState
+			if err := rows.Scan(&st.ID, &st.Hash, &st.Deleted); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			states[st.ID] = st
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return states, nil
+}
+
+// TouchRecords 记录本次同步出现的记录(重新出现的记录取消删除标记)
+// 内容摘要暂存在本次同步下，同步完成(FinishReport)后才生效，避免中断的同步导致未送达的记录被视为未变化
+func (s *Store) TouchRecords(targetKey, syncID string, records []RecordState) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO sync_records (target_key, record_id, pending_hash, sync_id, deleted, updated_at)
+		VALUES (?, ?, ?, ?, 0, ?)
+		ON CONFLICT(target_key, record_id) DO UPDATE SET
+			pending_hash = excluded.pending_hash,
+			sync_id = excluded.sync_id,
+			deleted = 0,
+			updated_at = excluded.updated_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, r := range records {
+		if _, err := stmt.Exec(targetKey, r.ID, r.Hash, syncID, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// MarkDeleted 将记录标记为已删除(如变更日志中的删除)，清除内容摘要使重新出现时按新增处理
+func (s *Store) MarkDeleted(targetKey, syncID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO sync_records (target_key, record_id, sync_id, deleted, updated_at)
+		VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT(target_key, record_id) DO UPDATE SET
+			sync_id = excluded.sync_id,
+			deleted = 1,
+			row_hash = '',
+			pending_hash = NULL,
+			updated_at = excluded.updated_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, id := range ids {
+		if _, err := stmt.Exec(targetKey, id, syncID, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CountVanished 统计本次同步未出现且尚未标记删除的记录数
+func (s *Store) CountVanished(targetKey, syncID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM sync_records WHERE target_key = ? AND deleted = 0 AND sync_id != ?",
+		targetKey, syncID,
+	).Scan(&count)
+	return count, err
+}
+
+// TakeVanished 取出一批本次同步未出现的记录ID并标记为已删除，清除内容摘要使重新出现时按新增处理
+func (s *Store) TakeVanished(targetKey, syncID string, limit int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"SELECT record_id FROM sync_records WHERE target_key = ? AND deleted = 0 AND sync_id != ? ORDER BY record_id LIMIT ?",
+		targetKey, syncID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, id := range ids {
+		if _, err := tx.Exec(
+			"UPDATE sync_records SET deleted = 1, row_hash = '', pending_hash = NULL, sync_id = ?, updated_at = ? WHERE target_key = ? AND record_id = ?",
+			syncID, now, targetKey, id,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, tx.Commit()
+}
+
+// ListVanished 按记录ID顺序列出本次同步未出现且ID大于 after 的记录，不修改状态
+func (s *Store) ListVanished(targetKey, syncID, after string, limit int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(
+		"SELECT record_id FROM sync_records WHERE target_key = ? AND deleted = 0 AND sync_id != ? AND record_id > ? ORDER BY record_id LIMIT ?",
+		targetKey, syncID, after, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkVanished 将本次同步未出现且ID不大于 upTo 的记录标记为已删除，返回本次标记的记录ID
+// 已标记的记录归属本次同步，重复调用不会再次返回
+func (s *Store) MarkVanished(targetKey, syncID, upTo string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"SELECT record_id FROM sync_records WHERE target_key = ? AND deleted = 0 AND sync_id != ? AND record_id <= ? ORDER BY record_id",
+		targetKey, syncID, upTo,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE sync_records SET deleted = 1, row_hash = '', pending_hash = NULL, sync_id = ?, updated_at = ? WHERE target_key = ? AND deleted = 0 AND sync_id != ? AND record_id <= ?",
+		syncID, time.Now(), targetKey, syncID, upTo,
+	); err != nil {
+		return nil, err
+	}
+	return ids, tx.Commit()
+}
+
+// StartReport 创建同步报告(已存在时忽略)
+func (s *Store) StartReport(targetKey, syncID, source, syncMode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(
+		"INSERT OR IGNORE INTO sync_reports (target_key, sync_id, source, sync_mode, started_at) VALUES (?, ?, ?, ?, ?)",
+		targetKey, syncID, source, syncMode, time.Now(),
+	)
+	return err
+}
+
+// UpdateReport 累加同步报告的统计
+func (s *Store) UpdateReport(targetKey, syncID string, delta ReportDelta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deletedIDs string
+	err = tx.QueryRow(
+		"SELECT deleted_ids FROM sync_reports WHERE target_key = ? AND sync_id = ?",
+		targetKey, syncID,
+	).Scan(&deletedIDs)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	json.Unmarshal([]byte(deletedIDs), &ids)
+	for _, id := range delta.DeletedIDs {
+		if len(ids) >= MaxReportDeletedIDs {
+			break
+		}
+		ids = append(ids, id)
+	}
+	data, _ := json.Marshal(ids)
+
+	if _, err := tx.Exec(`
+		UPDATE sync_reports SET
+			record_count = record_count + ?,
+			new_count = new_count + ?,
+			changed_count = changed_count + ?,
+			unchanged_count = unchanged_count + ?,
+			deleted_count = deleted_count + ?,
+			deleted_ids = ?
+		WHERE target_key = ? AND sync_id = ?`,
+		delta.Records, delta.New, delta.Changed, delta.Unchanged, len(delta.DeletedIDs), string(data), targetKey, syncID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FinishReport 标记同步报告完成，并使本次同步暂存的内容摘要生效
+func (s *Store) FinishReport(targetKey, syncID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE sync_records SET row_hash = pending_hash, pending_hash = NULL WHERE target_key = ? AND sync_id = ? AND pending_hash IS NOT NULL",
+		targetKey, syncID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"UPDATE sync_reports SET status = ?, finished_at = ? WHERE target_key = ? AND sync_id = ?",
+		ReportStatusFinished, time.Now(), targetKey, syncID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListReports 分页查询同步报告
+func (s *Store) ListReports(q *ReportQuery) ([]SyncReport, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var conditions []string
+	var args []interface{}
+	if q.TargetKey != "" {
+		conditions = append(conditions, "target_key = ?")
+		args = append(args, q.TargetKey)
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM sync_reports"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 20
+	}
+	if q.PageSize > 100 {
+		q.PageSize = 100
+	}
+
+	query := `SELECT id, target_key, sync_id, source, sync_mode, record_count, new_count, changed_count, unchanged_count,
+		deleted_count, deleted_ids, status, started_at, finished_at
+		FROM sync_reports` + whereClause + " ORDER BY id DESC LIMIT ? OFFSET ?"
+	rows, err := s.db.Query(query, append(args, q.PageSize, (q.Page-1)*q.PageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var list []SyncReport
+	for rows.Next() {
+		var r SyncReport
+		var deletedIDs string
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.TargetKey, &r.SyncID, &r.Source, &r.SyncMode, &r.RecordCount,
+			&r.NewCount, &r.ChangedCount, &r.UnchangedCount, &r.DeletedCount,
+			&deletedIDs, &r.Status, &r.StartedAt, &finishedAt); err != nil {
+			return nil, 0, err
+		}
+		json.Unmarshal([]byte(deletedIDs), &r.DeletedIDs)
+		if finishedAt.Valid {
+			r.FinishedAt = &finishedAt.Time
+		}
+		list = append(list, r)
+	}
+
+	return list, total, rows.Err()
+}
@@ -0,0 +1,100 @@
+package writeback
+
+import "time"
+
+// 乐观并发检查方式
+const (
+	VersionModeNumber    = "version"    // 数值版本列，回写时加1
+	VersionModeTimestamp = "updated_at" // 更新时间列，回写时设为当前时间
+)
+
+// 单条记录的回写结果
+const (
+	StatusApplied  = "applied"   // 已写入
+	StatusConflict = "conflict"  // 版本不一致，记录已被其他人修改
+	StatusNotFound = "not_found" // 记录不存在
+	StatusRejected = "rejected"  // 请求不合法，如修改了不允许回写的列
+	StatusFailed   = "failed"    // 数据库执行失败
+	StatusPending  = "pending"   // 写入前登记的审计状态，保持该状态表示结果未确认(如提交后服务中断)
+)
+
+// Target 回写目标：管理员配置的可回写表，使用独立的可写账号连接源库
+type Target struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"` // 唯一名称，回调地址中按名称引用
+	Host            string    `json:"host"`
+	Port            int       `json:"port"`
+	Username        string    `json:"username"`
+	Password        string    `json:"password,omitempty"` // 可写账号的密码，列表接口中不返回
+	Database        string    `json:"database"`
+	TimeZone        string    `json:"timeZone,omitempty"` // 源库时区(IANA名称或 +08:00)，同时作为会话时区
+	Table           string    `json:"table"`
+	KeyColumns      []string  `json:"keyColumns"`      // 主键列，保存时校验为数据表主键，并按表中列的顺序(即记录ID中键值的顺序)排列
+	WritableColumns []string  `json:"writableColumns"` // 允许回写的列
+	VersionColumn   string    `json:"versionColumn"`   // 乐观并发检查列
+	VersionMode     string    `json:"versionMode"`     // version 或 updated_at
+	Secret          string    `json:"secret"`          // 回调签名密钥
+	Enabled         bool      `json:"enabled"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// ValidVersionMode 校验乐观并发检查方式
+func ValidVersionMode(mode string) bool {
+	return mode == VersionModeNumber || mode == VersionModeTimestamp
+}
+
+// Callback 记录变更回调
+type Callback struct {
+	EventID  string         `json:"eventId"`  // 回调事件ID，写入审计记录便于追溯
+	Operator string         `json:"operator"` // 表格中修改记录的用户
+	Records  []RecordChange `json:"records"`
+}
+
+// RecordChange 单条记录的变更
+type RecordChange struct {
+	RecordID string                 `json:"recordId"` // 同步时生成的记录ID
+	Version  interface{}            `json:"version"`  // 表格中该记录的版本值(版本号，或更新时间的毫秒时间戳/文本)
+	Fields   map[string]interface{} `json:"fields"`   // 列名 -> 新值，null 写入 NULL
+}
+
+// RecordResult 单条记录的回写结果
+type RecordResult struct {
+	RecordID string `json:"recordId"`
+	Status   string `json:"status"`
+	Version  string `json:"version,omitempty"` // 写入后的版本值；冲突时为数据库中的当前版本
+	Error    string `json:"error,omitempty"`
+}
+
+// ColumnChange 审计记录中单列的修改
+type ColumnChange struct {
+	Column string  `json:"column"`
+	Old    *string `json:"old"`
+	New    *string `json:"new"`
+}
+
+// Audit 回写审计记录，每条记录的每次回写尝试一条
+type Audit struct {
+	ID              int64          `json:"id"`
+	TargetID        int64          `json:"targetId"`
+	TargetName      string         `json:"targetName"`
+	EventID         string         `json:"eventId"`
+	RecordID        string         `json:"recordId"`
+	Operator        string         `json:"operator"`
+	ClientIP        string         `json:"clientIp"`
+	Changes         []ColumnChange `json:"changes"`
+	ExpectedVersion string         `json:"expectedVersion"`
+	NewVersion      string         `json:"newVersion"`
+	Status          string         `json:"status"`
+	Error           string         `json:"error"`
+	CreatedAt       time.Time      `json:"createdAt"`
+}
+
+// AuditQuery 审计记录查询条件
+type AuditQuery struct {
+	TargetID int64  `form:"targetId"`
+	Status   string `form:"status"`
+	RecordID string `form:"recordId"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"pageSize"`
+}
@@ -0,0 +1,52 @@
+package writeback
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+// 回调签名请求头
+const (
+	HeaderTimestamp = "X-Writeback-Timestamp"
+	HeaderNonce     = "X-Writeback-Nonce"
+	HeaderSignature = "X-Writeback-Signature"
+)
+
+// signatureMaxSkew 回调时间戳允许的误差，有效期内的重放由随机串检查拦截(见 Store.UseNonce)
+const signatureMaxSkew = 300
+
+// maxNonceLength 随机串最大长度
+const maxNonceLength = 128
+
+// Sign 计算回调签名: Base64(HMAC-SHA256(secret, timestamp + nonce + body))
+func Sign(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + nonce))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature 校验回调签名及时间戳，返回失败原因
+func VerifySignature(secret, timestamp, nonce, signature string, body []byte) (bool, string) {
+	if timestamp == "" || nonce == "" || signature == "" {
+		return false, "缺少签名信息"
+	}
+	if len(nonce) > maxNonceLength {
+		return false, "随机串过长"
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false, "时间戳格式错误"
+	}
+	now := time.Now().Unix()
+	if now-ts > signatureMaxSkew || ts-now > signatureMaxSkew {
+		return false, "请求已过期"
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, nonce, body)), []byte(signature)) {
+		return false, "签名验证失败"
+	}
+	return true, ""
+}
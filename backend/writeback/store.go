@@ -0,0 +1,377 @@
+package writeback
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store 回写存储(回写目标和审计记录)
+type Store struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// GetStore 获取回写存储单例
+func GetStore() *Store {
+	once.Do(func() {
+		instance = &Store{}
+	})
+	return instance
+}
+
+// Init 初始化数据库
+func (s *Store) Init(dbPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	// 创建回写目标和审计表
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS writeback_targets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		host TEXT NOT NULL,
+		port INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		password TEXT NOT NULL,
+		database_name TEXT NOT NULL,
+		time_zone TEXT NOT NULL DEFAULT '',
+		table_name TEXT NOT NULL,
+		key_columns TEXT NOT NULL,
+		writable_columns TEXT NOT NULL,
+		version_column TEXT NOT NULL,
+		version_mode TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS writeback_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		target_id INTEGER NOT NULL,
+		target_name TEXT NOT NULL,
+		event_id TEXT NOT NULL DEFAULT '',
+		record_id TEXT NOT NULL,
+		operator TEXT NOT NULL DEFAULT '',
+		client_ip TEXT NOT NULL DEFAULT '',
+		changes TEXT NOT NULL,
+		expected_version TEXT NOT NULL DEFAULT '',
+		new_version TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_writeback_audit_target ON writeback_audit(target_id, id);
+	CREATE TABLE IF NOT EXISTS writeback_nonces (
+		target_id INTEGER NOT NULL,
+		nonce TEXT NOT NULL,
+		expires_at INTEGER NOT NULL,
+		PRIMARY KEY (target_id, nonce)
+	);
+	CREATE INDEX IF NOT EXISTS idx_writeback_nonces_expires ON writeback_nonces(expires_at);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		db.Close()
+		return fmt.Errorf("创建表失败: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+const targetColumns = `id, name, host, port, username, password, database_name, time_zone, table_name,
+	key_columns, writable_columns, version_column, version_mode, secret, enabled, created_at, updated_at`
+
+// scanTarget 扫描回写目标
+func scanTarget(scanner interface{ Scan(...interface{}) error }) (*Target, error) {
+	var t Target
+	var keyColumns, writableColumns string
+	if err := scanner.Scan(&t.ID, &t.Name, &t.Host, &t.Port, &t.Username, &t.Password, &t.Database, &t.TimeZone, &t.Table,
+		&keyColumns, &writableColumns, &t.VersionColumn, &t.VersionMode, &t.Secret, &t.Enabled, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(keyColumns), &t.KeyColumns)
+	json.Unmarshal([]byte(writableColumns), &t.WritableColumns)
+	return &t, nil
+}
+
+// ListTargets 获取所有回写目标
+func (s *Store) ListTargets() ([]Target, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query("SELECT " + targetColumns + " FROM writeback_targets ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Target
+	for rows.Next() {
+		t, err := scanTarget(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	return list, rows.Err()
+}
+
+// GetTarget 根据ID获取回写目标
+func (s *Store) GetTarget(id int64) (*Target, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := scanTarget(s.db.QueryRow("SELECT "+targetColumns+" FROM writeback_targets WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// FindTarget 根据名称获取回写目标
+func (s *Store) FindTarget(name string) (*Target, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := scanTarget(s.db.QueryRow("SELECT "+targetColumns+" FROM writeback_targets WHERE name = ?", name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// SaveTarget 保存回写目标(ID为0时新建)，密码为空时保留原密码，密钥为空时保留原密钥(新建时自动生成)
+func (s *Store) SaveTarget(t *Target) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyColumns, err := json.Marshal(t.KeyColumns)
+	if err != nil {
+		return err
+	}
+	writableColumns, err := json.Marshal(t.WritableColumns)
+	if err != nil {
+		return err
+	}
+
+	if t.ID == 0 {
+		if t.Secret == "" {
+			if t.Secret, err = NewSecret(); err != nil {
+				return err
+			}
+		}
+		result, err := s.db.Exec(
+			`INSERT INTO writeback_targets (name, host, port, username, password, database_name, time_zone, table_name,
+				key_columns, writable_columns, version_column, version_mode, secret, enabled) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			t.Name, t.Host, t.Port, t.Username, t.Password, t.Database, t.TimeZone, t.Table,
+			string(keyColumns), string(writableColumns), t.VersionColumn, t.VersionMode, t.Secret, t.Enabled,
+		)
+		if err != nil {
+			return uniqueNameError(err, t.Name)
+		}
+		t.ID, err = result.LastInsertId()
+		return err
+	}
+
+	query := `UPDATE writeback_targets SET name = ?, host = ?, port = ?, username = ?, database_name = ?, time_zone = ?, table_name = ?,
+		key_columns = ?, writable_columns = ?, version_column = ?, version_mode = ?, enabled = ?, updated_at = ?`
+	args := []interface{}{t.Name, t.Host, t.Port, t.Username, t.Database, t.TimeZone, t.Table,
+		string(keyColumns), string(writableColumns), t.VersionColumn, t.VersionMode, t.Enabled, time.Now()}
+	if t.Password != "" {
+		query += ", password = ?"
+		args = append(args, t.Password)
+	}
+	if t.Secret != "" {
+		query += ", secret = ?"
+		args = append(args, t.Secret)
+	}
+	query += " WHERE id = ?"
+	args = append(args, t.ID)
+
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return uniqueNameError(err, t.Name)
+	}
+	return nil
+}
+
+// DeleteTarget 删除回写目标，审计记录保留
+func (s *Store) DeleteTarget(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec("DELETE FROM writeback_targets WHERE id = ?", id)
+	return err
+}
+
+// uniqueNameError 将名称唯一约束冲突转换为可读的错误
+func uniqueNameError(err error, name string) error {
+	if strings.Contains(err.Error(), "UNIQUE") {
+		return fmt.Errorf("回写目标名称 %s 已存在", name)
+	}
+	return err
+}
+
+// NewSecret 生成回调签名密钥
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成密钥失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// AddAudit 写入审计记录
+func (s *Store) AddAudit(a *Audit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, err := json.Marshal(a.Changes)
+	if err != nil {
+		return err
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+
+	result, err := s.db.Exec(
+		`INSERT INTO writeback_audit (target_id, target_name, event_id, record_id, operator, client_ip, changes,
+			expected_version, new_version, status, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.TargetID, a.TargetName, a.EventID, a.RecordID, a.Operator, a.ClientIP, string(changes),
+		a.ExpectedVersion, a.NewVersion, a.Status, a.Error, a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	a.ID, err = result.LastInsertId()
+	return err
+}
+
+// UseNonce 登记回调的随机串，在时间戳有效期内已使用过的返回 false(重放的回调)
+func (s *Store) UseNonce(targetID int64, nonce string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+	if _, err := s.db.Exec("DELETE FROM writeback_nonces WHERE expires_at < ?", now); err != nil {
+		return false, err
+	}
+	// 时间戳允许前后各 signatureMaxSkew 秒的误差，随机串需保留到该时间戳的请求全部过期
+	result, err := s.db.Exec(
+		"INSERT OR IGNORE INTO writeback_nonces (target_id, nonce, expires_at) VALUES (?, ?, ?)",
+		targetID, nonce, now+2*signatureMaxSkew,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UpdateAudit 更新审计记录的修改内容和结果
+func (s *Store) UpdateAudit(a *Audit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, err := json.Marshal(a.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(
+		"UPDATE writeback_audit SET changes = ?, expected_version = ?, new_version = ?, status = ?, error = ? WHERE id = ?",
+		string(changes), a.ExpectedVersion, a.NewVersion, a.Status, a.Error, a.ID,
+	)
+	return err
+}
+
+// QueryAudit 分页查询审计记录(新记录在前)
+func (s *Store) QueryAudit(q *AuditQuery) ([]Audit, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var conditions []string
+	var args []interface{}
+	if q.TargetID > 0 {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, q.TargetID)
+	}
+	if q.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, q.Status)
+	}
+	if q.RecordID != "" {
+		conditions = append(conditions, "record_id = ?")
+		args = append(args, q.RecordID)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM writeback_audit "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 20
+	}
+
+	rows, err := s.db.Query(
+		`SELECT id, target_id, target_name, event_id, record_id, operator, client_ip, changes,
+			expected_version, new_version, status, error, created_at
+		FROM writeback_audit `+whereClause+` ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, q.PageSize, (q.Page-1)*q.PageSize)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var list []Audit
+	for rows.Next() {
+		var a Audit
+		var changes string
+		if err := rows.Scan(&a.ID, &a.TargetID, &a.TargetName, &a.EventID, &a.RecordID, &a.Operator, &a.ClientIP, &changes,
+			&a.ExpectedVersion, &a.NewVersion, &a.Status, &a.Error, &a.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		json.Unmarshal([]byte(changes), &a.Changes)
+		list = append(list, a)
+	}
+	return list, total, rows.Err()
+}
@@ -0,0 +1,86 @@
+import { Form, Input, Button, Select, Space } from 'antd';
+import { ComputedField } from './api';
+
+const { Option } = Select;
+
+// 计算字段可选的类型
+const computedTypes: [string, string][] = [
+  ['text', '文本'],
+  ['number', '数字'],
+  ['date', '日期'],
+  ['checkbox', '复选框'],
+  ['currency', '货币'],
+  ['hyperlink', '链接'],
+  ['phone', '电话'],
+  ['progress', '进度'],
+  ['rating', '评分'],
+];
+
+interface ComputedFieldsEditorProps {
+  columns: string[];               // 可引用的源字段
+  value: ComputedField[];
+  onChange: (fields: ComputedField[]) => void;
+  onApply: () => void;             // 重新预览以校验表达式并查看计算结果
+  loading: boolean;
+}
+
+// 计算字段配置：名称、类型和表达式
+function ComputedFieldsEditor({ columns, value, onChange, onApply, loading }: ComputedFieldsEditorProps) {
+  const update = (index: number, field: ComputedField) => {
+    onChange(value.map((f, i) => (i === index ? field : f)));
+  };
+
+  return (
+    <Form.Item
+      label="计算字段"
+      extra={
+        <>
+          <div>
+            表达式示例：first_name &amp; " " &amp; last_name、amount * 1.13、status == 1 ? "启用" : "停用"、
+            format_date(created_at, "YYYY-MM-DD")、lookup({'{"0": "待处理", "1": "已完成"}'}, state)、regex_extract(order_no, "(\d+)$", 1)
+          </div>
+          {columns.length > 0 && <div>可引用的字段：{columns.join('、')}</div>}
+        </>
+      }
+    >
+      {value.map((field, index) => (
+        <Space key={index} style={{ display: 'flex', marginBottom: 8 }} align="start">
+          <Input
+            placeholder="字段名"
+            style={{ width: 140 }}
+            value={field.name}
+            onChange={e => update(index, { ...field, name: e.target.value })}
+          />
+          <Select
+            style={{ width: 100 }}
+            value={field.type || 'text'}
+            onChange={type => update(index, { ...field, type })}
+          >
+            {computedTypes.map(([type, label]) => (
+              <Option key={type} value={type}>{label}</Option>
+            ))}
+          </Select>
+          <Input
+            placeholder="表达式"
+            style={{ width: 320, fontFamily: 'monospace' }}
+            value={field.expression}
+            onChange={e => update(index, { ...field, expression: e.target.value })}
+          />
+          <Button type="link" danger size="small" onClick={() => onChange(value.filter((_, i) => i !== index))}>
+            删除
+          </Button>
+        </Space>
+      ))}
+      <Space>
+        <Button onClick={() => onChange([...value, { name: '', type: 'text', expression: '' }])}>
+          添加计算字段
+        </Button>
+        <Button onClick={onApply} loading={loading}>
+          应用并预览
+        </Button>
+      </Space>
+    </Form.Item>
+  );
+}
+
+export default ComputedFieldsEditor;
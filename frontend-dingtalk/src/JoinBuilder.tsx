@@ -0,0 +1,248 @@
+import { useEffect, useState } from 'react';
+import { Form, Input, Button, Select, Space, Checkbox, Card, Tag, message } from 'antd';
+import { MySQLConfig, JoinConfig, JoinTable, JoinKey, JoinSchema, getJoinSchema } from './api';
+
+const { Option } = Select;
+
+interface JoinBuilderProps {
+  connection: Partial<MySQLConfig>;  // 连接信息及数据库
+  tables: string[];                  // 数据库中的表
+  value: JoinConfig;
+  onChange: (join: JoinConfig) => void;
+}
+
+// 关联表在SQL中的名称
+const joinAlias = (t: JoinTable) => t.alias || t.table;
+
+// 多表关联配置：主表、依次关联的表及关联键、选取的列
+function JoinBuilder({ connection, tables, value, onChange }: JoinBuilderProps) {
+  const [schema, setSchema] = useState<JoinSchema>({ columns: {}, foreignKeys: [] });
+
+  // 涉及的表变化时重新获取列和外键
+  const tableKey = [value.base, ...value.joins.map(j => j.table)].filter(Boolean).join(',');
+  useEffect(() => {
+    if (!value.base) {
+      setSchema({ columns: {}, foreignKeys: [] });
+      return;
+    }
+    getJoinSchema({ ...connection, queryMode: 'join', join: value } as MySQLConfig)
+      .then(setSchema)
+      .catch((error: any) => message.error('获取表结构失败: ' + (error.message || '未知错误')));
+  }, [tableKey]);
+
+  // 已出现的表：[别名, 表名]
+  const knownTables = (before: number): [string, string][] => [
+    [value.base, value.base],
+    ...value.joins.slice(0, before).filter(j => j.table).map(j => [joinAlias(j), j.table] as [string, string]),
+  ];
+
+  // 根据外键推荐新关联表与已出现的表之间的关联键
+  const suggestKeys = (table: string, before: number): JoinKey[] => {
+    for (const [alias, name] of knownTables(before)) {
+      for (const fk of schema.foreignKeys) {
+        if (fk.table === table && fk.refTable === name) {
+          return fk.columns.map((col, i) => ({ leftTable: alias, leftColumn: fk.refColumns[i], rightColumn: col }));
+        }
+        if (fk.refTable === table && fk.table === name) {
+          return fk.columns.map((col, i) => ({ leftTable: alias, leftColumn: col, rightColumn: fk.refColumns[i] }));
+        }
+      }
+    }
+    return [];
+  };
+
+  // 与已出现的表有外键关系、尚未关联的表
+  const suggestedTables = Array.from(new Set(
+    schema.foreignKeys.flatMap(fk => {
+      const known = knownTables(value.joins.length).map(([, name]) => name);
+      if (known.includes(fk.table) && !known.includes(fk.refTable)) return [fk.refTable];
+      if (known.includes(fk.refTable) && !known.includes(fk.table)) return [fk.table];
+      return [];
+    })
+  ));
+
+  const handleBaseChange = (base: string) => {
+    onChange({ base, joins: [], columns: [] });
+  };
+
+  const updateJoin = (index: number, join: JoinTable) => {
+    const joins = value.joins.map((j, i) => (i === index ? join : j));
+    // 别名或表变化后，移除引用了已不存在的表的列
+    const aliases = new Set([value.base, ...joins.map(joinAlias)]);
+    onChange({ ...value, joins, columns: value.columns.filter(c => aliases.has(c.table)) });
+  };
+
+  const addJoin = (table = '') => {
+    const join: JoinTable = { table, type: 'left', on: table ? suggestKeys(table, value.joins.length) : [] };
+    onChange({ ...value, joins: [...value.joins, join] });
+  };
+
+  const removeJoin = (index: number) => {
+    const removed = joinAlias(value.joins[index]);
+    onChange({
+      ...value,
+      joins: value.joins.filter((_, i) => i !== index),
+      columns: value.columns.filter(c => c.table !== removed),
+    });
+  };
+
+  const handleJoinTableChange = (index: number, table: string) => {
+    updateJoin(index, { ...value.joins[index], table, on: suggestKeys(table, index) });
+  };
+
+  const updateKey = (index: number, keyIndex: number, key: JoinKey) => {
+    const join = value.joins[index];
+    updateJoin(index, { ...join, on: join.on.map((k, i) => (i === keyIndex ? key : k)) });
+  };
+
+  // 某个表选中的列
+  const selectedColumns = (alias: string) => value.columns.filter(c => c.table === alias).map(c => c.column);
+
+  const handleColumnsChange = (alias: string, columns: string[]) => {
+    onChange({
+      ...value,
+      columns: [
+        ...value.columns.filter(c => c.table !== alias),
+        ...columns.map(column => ({ table: alias, column })),
+      ],
+    });
+  };
+
+  return (
+    <>
+      <Form.Item label="主表">
+        <Select
+          placeholder="请选择主表"
+          showSearch
+          value={value.base || undefined}
+          onChange={handleBaseChange}
+        >
+          {tables.map(t => (
+            <Option key={t} value={t}>{t}</Option>
+          ))}
+        </Select>
+      </Form.Item>
+
+      {value.joins.map((join, index) => {
+        const alias = joinAlias(join);
+        const leftOptions = knownTables(index).flatMap(([a, name]) =>
+          (schema.columns[name] || []).map(col => ({ value: `${a}.${col}`, table: a, column: col }))
+        );
+        return (
+          <Card
+            key={index}
+            size="small"
+            title={`关联 ${index + 1}`}
+            extra={<Button type="link" danger size="small" onClick={() => removeJoin(index)}>删除</Button>}
+            style={{ marginBottom: 12 }}
+          >
+            <Space wrap style={{ marginBottom: 8 }}>
+              <Select
+                placeholder="关联的表"
+                showSearch
+                style={{ width: 180 }}
+                value={join.table || undefined}
+                onChange={t => handleJoinTableChange(index, t)}
+              >
+                {tables.map(t => (
+                  <Option key={t} value={t}>{t}</Option>
+                ))}
+              </Select>
+              <Input
+                placeholder="别名(可选)"
+                style={{ width: 120 }}
+                value={join.alias}
+                onChange={e => updateJoin(index, { ...join, alias: e.target.value || undefined })}
+              />
+              <Select
+                style={{ width: 140 }}
+                value={join.type}
+                onChange={type => updateJoin(index, { ...join, type })}
+              >
+                <Option value="left">左关联(保留主表)</Option>
+                <Option value="inner">内关联(仅匹配)</Option>
+              </Select>
+            </Space>
+            {join.on.map((key, keyIndex) => (
+              <Space key={keyIndex} style={{ display: 'flex', marginBottom: 8 }}>
+                <Select
+                  placeholder="已关联表的列"
+                  showSearch
+                  style={{ width: 200 }}
+                  value={key.leftTable && key.leftColumn ? `${key.leftTable}.${key.leftColumn}` : undefined}
+                  onChange={(v: string) => {
+                    const option = leftOptions.find(o => o.value === v);
+                    if (option) {
+                      updateKey(index, keyIndex, { ...key, leftTable: option.table, leftColumn: option.column });
+                    }
+                  }}
+                >
+                  {leftOptions.map(o => (
+                    <Option key={o.value} value={o.value}>{o.value}</Option>
+                  ))}
+                </Select>
+                <span>=</span>
+                <Select
+                  placeholder={`${alias || '本表'}的列`}
+                  showSearch
+                  style={{ width: 180 }}
+                  value={key.rightColumn || undefined}
+                  onChange={(col: string) => updateKey(index, keyIndex, { ...key, rightColumn: col })}
+                >
+                  {(schema.columns[join.table] || []).map(col => (
+                    <Option key={col} value={col}>{col}</Option>
+                  ))}
+                </Select>
+                <Button
+                  type="link"
+                  size="small"
+                  onClick={() => updateJoin(index, { ...join, on: join.on.filter((_, i) => i !== keyIndex) })}
+                >
+                  移除
+                </Button>
+              </Space>
+            ))}
+            <Button
+              size="small"
+              disabled={!join.table}
+              onClick={() => updateJoin(index, { ...join, on: [...join.on, { leftTable: '', leftColumn: '', rightColumn: '' }] })}
+            >
+              添加关联键
+            </Button>
+          </Card>
+        );
+      })}
+
+      {value.base && (
+        <Form.Item>
+          <Space wrap>
+            <Button onClick={() => addJoin()}>添加关联表</Button>
+            {suggestedTables.length > 0 && <span style={{ color: '#666' }}>按外键推荐:</span>}
+            {suggestedTables.map(t => (
+              <Tag key={t} color="blue" style={{ cursor: 'pointer' }} onClick={() => addJoin(t)}>
+                + {t}
+              </Tag>
+            ))}
+          </Space>
+        </Form.Item>
+      )}
+
+      {value.base && (
+        <Form.Item label="选取的列">
+          {knownTables(value.joins.length).map(([alias, name]) => (
+            <div key={alias} style={{ marginBottom: 8 }}>
+              <div style={{ fontWeight: 500 }}>{alias === name ? name : `${alias} (${name})`}</div>
+              <Checkbox.Group
+                options={schema.columns[name] || []}
+                value={selectedColumns(alias)}
+                onChange={cols => handleColumnsChange(alias, cols as string[])}
+              />
+            </div>
+          ))}
+        </Form.Item>
+      )}
+    </>
+  );
+}
+
+export default JoinBuilder;
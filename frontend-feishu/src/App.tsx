@@ -1,7 +1,10 @@
 import { useEffect, useState } from 'react';
 import { Form, Input, Button, Select, message, Steps, Space, Table, Divider, Radio } from 'antd';
 import { bitable } from '@lark-base-open/connector-api';
-import { MySQLConfig, FieldMapping, getDatabases, getTables, getTableFields, previewSQL } from './api';
+import { MySQLConfig, FieldMapping, SavedQueryInfo, DataPreview, JoinConfig, ComputedField, ValueMapping, FeishuTableConfig, MultiTableConfig, getDatabases, getTables, getTableFields, previewSQL, getSavedQueries } from './api';
+import JoinBuilder from './JoinBuilder';
+import ComputedFieldsEditor from './ComputedFieldsEditor';
+import ValueMapEditor from './ValueMapEditor';
 import './App.css';
 
 const { Step } = Steps;
@@ -52,10 +55,11 @@ interface FieldInfo {
   type: string;
   isPrimary: boolean;
   description?: string;
+  warnings?: string[];   // 预览数据的告警
 }
 
 // 取数模式类型
-type QueryMode = 'table' | 'sql';
+type QueryMode = 'table' | 'sql' | 'saved' | 'join';
 
 function App() {
   const [sdkReady, setSdkReady] = useState(false);
@@ -71,10 +75,26 @@ function App() {
   const [tables, setTables] = useState<string[]>([]);
   const [fields, setFields] = useState<FieldInfo[]>([]);
   const [fieldMappings, setFieldMappings] = useState<FieldMapping[]>([]);
+  const [preview, setPreview] = useState<DataPreview | null>(null);
+  const [valueMapField, setValueMapField] = useState<string | null>(null);  // 正在设置值映射的字段
 
   // 取数模式
   const [queryMode, setQueryMode] = useState<QueryMode>('table');
   const [customSQL, setCustomSQL] = useState('');
+  const [join, setJoin] = useState<JoinConfig>({ base: '', joins: [], columns: [] });
+
+  // 计算字段
+  const [computedFields, setComputedFields] = useState<ComputedField[]>([]);
+
+  // 多表数据源：已加入的表
+  const [tableConfigs, setTableConfigs] = useState<FeishuTableConfig[]>([]);
+  const [tableID, setTableID] = useState('');
+  const [tableName, setTableName] = useState('');
+
+  // 已发布查询
+  const [savedQueries, setSavedQueries] = useState<SavedQueryInfo[]>([]);
+  const [savedQuery, setSavedQuery] = useState<SavedQueryInfo | null>(null);
+  const [paramValues, setParamValues] = useState<Record<string, string>>({});
 
   useEffect(() => {
     // 初始化飞书SDK
@@ -143,7 +163,10 @@ function App() {
       setLoading(true);
       setTables([]);
       setFields([]);
+      setPreview(null);
       setFieldMappings([]);
+      setJoin({ base: '', joins: [], columns: [] });
+      setComputedFields([]);
       form.setFieldsValue({ table: undefined });
 
       const config = {
@@ -165,26 +188,46 @@ function App() {
     }
   };
 
+  // 已填写完整的计算字段
+  const activeComputedFields = computedFields.filter(f => f.name.trim() && f.expression.trim());
+
+  // 初始化字段映射，默认别名等于原字段名；重新预览时保留已配置的别名和值映射
+  const initFieldMappings = (fieldList: FieldInfo[]) => {
+    setFieldMappings(prev =>
+      fieldList.map(f => {
+        const existing = prev.find(m => m.mysqlField === f.name);
+        return { ...existing, mysqlField: f.name, aliasField: existing?.aliasField || f.name };
+      })
+    );
+  };
+
+  // 预览时只携带值映射，别名保持原字段名，预览数据仍按原字段名展示
+  const valueMapMappings: FieldMapping[] = fieldMappings
+    .filter(m => m.valueMap)
+    .map(m => ({ mysqlField: m.mysqlField, aliasField: m.mysqlField, valueMap: m.valueMap }));
+
   // 数据表选择变化时，获取字段列表
   const handleTableChange = async (table: string) => {
     try {
       setLoading(true);
+      if (table !== mysqlConfig.table) {
+        setFieldMappings([]);
+      }
 
       const config = {
         ...mysqlConfig,
         table,
+        computedFields: activeComputedFields,
+        fieldMappings: table === mysqlConfig.table ? valueMapMappings : [],
       } as MySQLConfig;
 
-      const fieldList = await getTableFields(config);
+      const preview = await getTableFields(config);
+      const fieldList = preview.fields;
       setFields(fieldList);
+      setPreview(preview);
       setMysqlConfig(prev => ({ ...prev, table }));
 
-      // 初始化字段映射，默认别名等于原字段名
-      const mappings: FieldMapping[] = fieldList.map((f: FieldInfo) => ({
-        mysqlField: f.name,
-        aliasField: f.name,
-      }));
-      setFieldMappings(mappings);
+      initFieldMappings(fieldList);
     } catch (error: any) {
       message.error('获取字段列表失败: ' + (error.message || '未知错误'));
     } finally {
@@ -205,28 +248,158 @@ function App() {
       const config = {
         ...mysqlConfig,
         customSQL: customSQL.trim(),
+        computedFields: activeComputedFields,
+        fieldMappings: valueMapMappings,
       } as MySQLConfig;
 
-      const fieldList = await previewSQL(config);
+      const preview = await previewSQL(config);
+      const fieldList = preview.fields;
       setFields(fieldList);
+      setPreview(preview);
 
-      // 初始化字段映射
-      const mappings: FieldMapping[] = fieldList.map((f: FieldInfo) => ({
-        mysqlField: f.name,
-        aliasField: f.name,
-      }));
-      setFieldMappings(mappings);
+      initFieldMappings(fieldList);
 
       message.success(`SQL执行成功，共${fieldList.length}个字段`);
     } catch (error: any) {
       message.error('SQL执行失败: ' + (error.message || '未知错误'));
       setFields([]);
+      setPreview(null);
+      setFieldMappings([]);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // 关联配置变化后需重新预览
+  const handleJoinChange = (value: JoinConfig) => {
+    setJoin(value);
+    setFields([]);
+    setPreview(null);
+    setFieldMappings([]);
+  };
+
+  // 预览多表关联，获取字段列表
+  const handlePreviewJoin = async () => {
+    if (!join.base || join.columns.length === 0) {
+      message.warning('请选择主表和要同步的列');
+      return;
+    }
+
+    try {
+      setLoading(true);
+
+      const preview = await previewSQL({
+        ...mysqlConfig,
+        queryMode: 'join',
+        join,
+        computedFields: activeComputedFields,
+        fieldMappings: valueMapMappings,
+      } as MySQLConfig);
+      const fieldList = preview.fields;
+      setFields(fieldList);
+      setPreview(preview);
+
+      initFieldMappings(fieldList);
+
+      message.success(`关联查询执行成功，共${fieldList.length}个字段`);
+    } catch (error: any) {
+      message.error('关联查询执行失败: ' + (error.message || '未知错误'));
+      setFields([]);
+      setPreview(null);
+      setFieldMappings([]);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // 使用已发布查询：无需连接服务器，直接选择管理员发布的查询
+  const handleUseSavedQuery = async () => {
+    try {
+      setLoading(true);
+      const list = await getSavedQueries();
+      if (list.length === 0) {
+        message.warning('暂无已发布的查询');
+        return;
+      }
+      setSavedQueries(list);
+      setQueryMode('saved');
+      setCurrentStep(1);
+    } catch (error: any) {
+      message.error('获取已发布查询失败: ' + (error.message || '未知错误'));
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // 选择已发布查询，参数值初始化为默认值
+  const handleSavedQueryChange = (name: string) => {
+    const query = savedQueries.find(q => q.name === name) || null;
+    setSavedQuery(query);
+    setParamValues(Object.fromEntries((query?.params || []).map(p => [p.name, p.default])));
+    setFields([]);
+    setPreview(null);
+    setFieldMappings([]);
+  };
+
+  // 已发布查询的配置：连接信息和SQL由服务端按名称读取
+  const buildSavedQueryConfig = (): MySQLConfig => ({
+    host: '',
+    port: 0,
+    database: '',
+    username: '',
+    password: '',
+    queryMode: 'saved',
+    savedQuery: savedQuery?.name,
+    paramValues,
+    computedFields: activeComputedFields,
+  });
+
+  // 预览已发布查询，获取字段列表
+  const handlePreviewSavedQuery = async () => {
+    if (!savedQuery) {
+      message.warning('请选择已发布查询');
+      return;
+    }
+
+    try {
+      setLoading(true);
+
+      const preview = await previewSQL({ ...buildSavedQueryConfig(), fieldMappings: valueMapMappings });
+      const fieldList = preview.fields;
+      setFields(fieldList);
+      setPreview(preview);
+
+      initFieldMappings(fieldList);
+
+      message.success(`查询执行成功，共${fieldList.length}个字段`);
+    } catch (error: any) {
+      message.error('查询执行失败: ' + (error.message || '未知错误'));
+      setFields([]);
+      setPreview(null);
       setFieldMappings([]);
     } finally {
       setLoading(false);
     }
   };
 
+  // 计算字段或值映射变化后按当前取数模式重新预览
+  const handleRefreshPreview = () => {
+    if (queryMode === 'table') {
+      const table = form.getFieldValue('table');
+      if (!table) {
+        message.warning('请选择数据表');
+        return;
+      }
+      handleTableChange(table);
+    } else if (queryMode === 'sql') {
+      handlePreviewSQL();
+    } else if (queryMode === 'join') {
+      handlePreviewJoin();
+    } else {
+      handlePreviewSavedQuery();
+    }
+  };
+
   // 更新字段映射
   const handleFieldMappingChange = (mysqlField: string, aliasField: string) => {
     setFieldMappings(prev =>
@@ -236,67 +409,184 @@ function App() {
     );
   };
 
+  // 更新字段的值映射
+  const handleValueMapChange = (valueMap?: ValueMapping) => {
+    setFieldMappings(prev =>
+      prev.map(m =>
+        m.mysqlField === valueMapField ? { ...m, valueMap } : m
+      )
+    );
+    setValueMapField(null);
+    message.info('值映射已更新，点击"重新预览"查看映射结果');
+  };
+
   // 取数模式切换
   const handleQueryModeChange = (mode: QueryMode) => {
     setQueryMode(mode);
     setFields([]);
+    setPreview(null);
     setFieldMappings([]);
     if (mode === 'table') {
       setCustomSQL('');
     }
+    if (mode !== 'join') {
+      setJoin({ base: '', joins: [], columns: [] });
+    }
+  };
+
+  // 按取数模式校验并生成当前表的配置，校验未通过时返回 null
+  const buildConfig = async (): Promise<MySQLConfig | null> => {
+    if (queryMode === 'saved') {
+      if (fields.length === 0) {
+        message.error('请先预览查询获取字段列表');
+        return null;
+      }
+
+      return {
+        ...buildSavedQueryConfig(),
+        fieldMappings: fieldMappings,
+      };
+    }
+
+    if (queryMode === 'join') {
+      const values = await form.validateFields(['database']);
+      if (fields.length === 0) {
+        message.error('请先预览关联查询获取字段列表');
+        return null;
+      }
+
+      return {
+        ...mysqlConfig as Omit<MySQLConfig, 'table' | 'database'>,
+        database: values.database,
+        queryMode: 'join',
+        join,
+        computedFields: activeComputedFields,
+        fieldMappings: fieldMappings,
+      };
+    }
+
+    if (queryMode === 'table') {
+      const values = await form.validateFields(['database', 'table']);
+      return {
+        ...mysqlConfig as Omit<MySQLConfig, 'table' | 'database'>,
+        database: values.database,
+        table: values.table,
+        queryMode: 'table',
+        computedFields: activeComputedFields,
+        fieldMappings: fieldMappings,
+      };
+    }
+
+    const values = await form.validateFields(['database']);
+    if (!customSQL.trim()) {
+      message.error('请输入SQL语句');
+      return null;
+    }
+    if (fields.length === 0) {
+      message.error('请先预览SQL获取字段列表');
+      return null;
+    }
+
+    return {
+      ...mysqlConfig as Omit<MySQLConfig, 'table' | 'database'>,
+      database: values.database,
+      queryMode: 'sql',
+      customSQL: customSQL.trim(),
+      computedFields: activeComputedFields,
+      fieldMappings: fieldMappings,
+    };
   };
 
   // 步骤2: 保存配置
   const handleSaveConfig = async () => {
     try {
       setLoading(true);
-
-      // 根据取数模式验证
-      if (queryMode === 'table') {
-        const values = await form.validateFields(['database', 'table']);
-        const config: MySQLConfig = {
-          ...mysqlConfig as Omit<MySQLConfig, 'table' | 'database'>,
-          database: values.database,
-          table: values.table,
-          queryMode: 'table',
-          fieldMappings: fieldMappings,
-        };
-
+      const config = await buildConfig();
+      if (config) {
         await saveConfigToFeishu(config);
+      }
+    } catch (error: any) {
+      if (error.errorFields) {
+        message.error('请完成所有配置');
       } else {
-        const values = await form.validateFields(['database']);
-        if (!customSQL.trim()) {
-          message.error('请输入SQL语句');
-          setLoading(false);
-          return;
-        }
-        if (fields.length === 0) {
-          message.error('请先预览SQL获取字段列表');
-          setLoading(false);
-          return;
-        }
+        message.error('保存失败: ' + (error.message || '未知错误'));
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
 
-        const config: MySQLConfig = {
-          ...mysqlConfig as Omit<MySQLConfig, 'table' | 'database'>,
-          database: values.database,
-          queryMode: 'sql',
-          customSQL: customSQL.trim(),
-          fieldMappings: fieldMappings,
-        };
+  // 将当前表的配置加入多表数据源，连接信息统一保存在顶层
+  const handleAddTable = async () => {
+    if (!/^[A-Za-z0-9_]+$/.test(tableID)) {
+      message.warning('表标识只能包含英文、数字、下划线');
+      return;
+    }
+    if (tableConfigs.some(t => t.tableID === tableID)) {
+      message.warning(`表标识 ${tableID} 已存在`);
+      return;
+    }
 
-        await saveConfigToFeishu(config);
+    try {
+      setLoading(true);
+      const config = await buildConfig();
+      if (!config) {
+        return;
       }
+
+      const table: FeishuTableConfig = { ...config, tableID, tableName: tableName.trim() || undefined };
+      delete table.host;
+      delete table.port;
+      delete table.username;
+      delete table.password;
+      setTableConfigs(prev => [...prev, table]);
+      setTableID('');
+      setTableName('');
+      message.success(`已加入表 ${tableID}，可继续配置下一张表`);
     } catch (error: any) {
       if (error.errorFields) {
         message.error('请完成所有配置');
       } else {
-        message.error('保存失败: ' + (error.message || '未知错误'));
+        message.error('加入失败: ' + (error.message || '未知错误'));
       }
     } finally {
       setLoading(false);
     }
   };
 
+  // 保存多表数据源配置
+  const handleSaveMultiTable = async () => {
+    const config: MultiTableConfig = {
+      version: 2,
+      host: mysqlConfig.host || '',
+      port: mysqlConfig.port || 0,
+      database: mysqlConfig.database || '',
+      username: mysqlConfig.username || '',
+      password: mysqlConfig.password || '',
+      tables: tableConfigs,
+    };
+    try {
+      setLoading(true);
+      await saveConfigToFeishu(config);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  // 多表数据源中表的取数方式说明
+  const describeTable = (t: FeishuTableConfig): string => {
+    switch (t.queryMode) {
+      case 'sql':
+        return '自定义SQL';
+      case 'join':
+        return `多表关联 ${t.join?.base || ''}`;
+      case 'saved':
+        return `已发布查询 ${t.savedQuery || ''}`;
+      default:
+        return `数据表 ${t.table || ''}`;
+    }
+  };
+
   // 保存配置到飞书
   const saveConfigToFeishu = async (config: MySQLConfig) => {
     try {
@@ -315,9 +605,15 @@ function App() {
     setDatabases([]);
     setTables([]);
     setFields([]);
+    setPreview(null);
     setFieldMappings([]);
     setQueryMode('table');
     setCustomSQL('');
+    setJoin({ base: '', joins: [], columns: [] });
+    setComputedFields([]);
+    setTableConfigs([]);
+    setSavedQuery(null);
+    setParamValues({});
     form.setFieldsValue({ database: undefined, table: undefined });
   };
 
@@ -346,6 +642,22 @@ function App() {
     message.success('已应用所有备注');
   };
 
+  // 预览单元格的显示文本
+  const formatPreviewValue = (value: any): string => {
+    if (value === null || value === undefined) {
+      return '';
+    }
+    return typeof value === 'object' ? JSON.stringify(value) : String(value);
+  };
+
+  // 数据预览表格列：按字段映射显示名展示同步时的转换结果
+  const previewColumns = fieldMappings.map(m => ({
+    title: m.aliasField || m.mysqlField,
+    key: m.mysqlField,
+    ellipsis: true,
+    render: (_: any, record: { fields: Record<string, any> }) => formatPreviewValue(record.fields[`fid_${m.mysqlField}`]),
+  }));
+
   // 检查是否有任何字段有备注
   const hasAnyDescription = fields.some(f => f.description);
 
@@ -356,11 +668,22 @@ function App() {
       dataIndex: 'mysqlField',
       key: 'mysqlField',
       width: '25%',
+      render: (text: string) => {
+        const warnings = fields.find(f => f.name === text)?.warnings || [];
+        return (
+          <>
+            <div>{text}</div>
+            {warnings.map(w => (
+              <div key={w} style={{ color: '#fa8c16', fontSize: 12 }}>{w}</div>
+            ))}
+          </>
+        );
+      },
     },
     {
       title: '数据库备注',
       key: 'description',
-      width: '25%',
+      width: '20%',
       render: (_: any, record: FieldMapping) => {
         const description = getFieldDescription(record.mysqlField);
         return description ? (
@@ -374,7 +697,7 @@ function App() {
       title: '多维表格显示名',
       dataIndex: 'aliasField',
       key: 'aliasField',
-      width: '35%',
+      width: '30%',
       render: (text: string, record: FieldMapping) => (
         <Input
           value={text}
@@ -383,10 +706,27 @@ function App() {
         />
       ),
     },
+    {
+      title: '值映射',
+      key: 'valueMap',
+      width: '12%',
+      render: (_: any, record: FieldMapping) => {
+        // 计算字段不支持值映射
+        if (computedFields.some(c => c.name === record.mysqlField)) {
+          return null;
+        }
+        const vm = record.valueMap;
+        return (
+          <Button type="link" size="small" onClick={() => setValueMapField(record.mysqlField)}>
+            {vm ? (vm.table ? `查找表 ${vm.table}` : `${vm.values?.length || 0} 个值`) : '设置'}
+          </Button>
+        );
+      },
+    },
     {
       title: '操作',
       key: 'action',
-      width: '15%',
+      width: '13%',
       render: (_: any, record: FieldMapping) => {
         const description = getFieldDescription(record.mysqlField);
         return description ? (
@@ -465,43 +805,91 @@ function App() {
                 连接服务器
               </Button>
             </Form.Item>
+
+            <Form.Item>
+              <Button onClick={handleUseSavedQuery} loading={loading} block>
+                使用已发布查询
+              </Button>
+            </Form.Item>
           </div>
         )}
 
         {/* 步骤2: 选择数据库、数据表和配置字段映射 */}
         {currentStep === 1 && (
           <div>
-            <Form.Item
-              label="选择数据库"
-              name="database"
-              rules={[{ required: true, message: '请选择数据库' }]}
-            >
-              <Select
-                placeholder="请选择数据库"
-                showSearch
-                onChange={handleDatabaseChange}
-                filterOption={(input, option) =>
-                  (option?.children as string).toLowerCase().includes(input.toLowerCase())
-                }
-              >
-                {databases.map(db => (
-                  <Option key={db} value={db}>
-                    {db}
-                  </Option>
-                ))}
-              </Select>
-            </Form.Item>
+            {queryMode !== 'saved' && (
+              <>
+                <Form.Item
+                  label="选择数据库"
+                  name="database"
+                  rules={[{ required: true, message: '请选择数据库' }]}
+                >
+                  <Select
+                    placeholder="请选择数据库"
+                    showSearch
+                    onChange={handleDatabaseChange}
+                    filterOption={(input, option) =>
+                      (option?.children as string).toLowerCase().includes(input.toLowerCase())
+                    }
+                  >
+                    {databases.map(db => (
+                      <Option key={db} value={db}>
+                        {db}
+                      </Option>
+                    ))}
+                  </Select>
+                </Form.Item>
 
-            {/* 取数模式选择 */}
-            <Form.Item label="取数模式">
-              <Radio.Group
-                value={queryMode}
-                onChange={e => handleQueryModeChange(e.target.value)}
-              >
-                <Radio.Button value="table">选择数据表</Radio.Button>
-                <Radio.Button value="sql">自定义SQL</Radio.Button>
-              </Radio.Group>
-            </Form.Item>
+                {/* 取数模式选择 */}
+                <Form.Item label="取数模式">
+                  <Radio.Group
+                    value={queryMode}
+                    onChange={e => handleQueryModeChange(e.target.value)}
+                  >
+                    <Radio.Button value="table">选择数据表</Radio.Button>
+                    <Radio.Button value="sql">自定义SQL</Radio.Button>
+                    <Radio.Button value="join">多表关联</Radio.Button>
+                  </Radio.Group>
+                </Form.Item>
+              </>
+            )}
+
+            {/* 已发布查询模式 */}
+            {queryMode === 'saved' && (
+              <>
+                <Form.Item label="已发布查询">
+                  <Select
+                    placeholder="请选择查询"
+                    showSearch
+                    value={savedQuery?.name}
+                    onChange={handleSavedQueryChange}
+                  >
+                    {savedQueries.map(q => (
+                      <Option key={q.name} value={q.name}>
+                        {q.name}
+                      </Option>
+                    ))}
+                  </Select>
+                  {savedQuery?.description && (
+                    <div style={{ color: '#666', marginTop: 4 }}>{savedQuery.description}</div>
+                  )}
+                </Form.Item>
+                {savedQuery?.params?.map(p => (
+                  <Form.Item key={p.name} label={`参数: ${p.name}`}>
+                    <Input
+                      value={paramValues[p.name]}
+                      onChange={e => setParamValues(prev => ({ ...prev, [p.name]: e.target.value }))}
+                      placeholder={p.default}
+                    />
+                  </Form.Item>
+                ))}
+                <Form.Item>
+                  <Button onClick={handlePreviewSavedQuery} loading={loading} disabled={!savedQuery}>
+                    预览查询（获取字段）
+                  </Button>
+                </Form.Item>
+              </>
+            )}
 
             {/* 选择数据表模式 */}
             {queryMode === 'table' && (
@@ -528,6 +916,27 @@ function App() {
               </Form.Item>
             )}
 
+            {/* 多表关联模式 */}
+            {queryMode === 'join' && (
+              <>
+                <JoinBuilder
+                  connection={mysqlConfig}
+                  tables={tables}
+                  value={join}
+                  onChange={handleJoinChange}
+                />
+                <Form.Item>
+                  <Button
+                    onClick={handlePreviewJoin}
+                    loading={loading}
+                    disabled={!join.base || join.columns.length === 0}
+                  >
+                    预览关联（获取字段）
+                  </Button>
+                </Form.Item>
+              </>
+            )}
+
             {/* 自定义SQL模式 */}
             {queryMode === 'sql' && (
               <Form.Item label="自定义SQL">
@@ -550,6 +959,17 @@ function App() {
               </Form.Item>
             )}
 
+            {/* 计算字段 */}
+            {(fields.length > 0 || computedFields.length > 0) && (
+              <ComputedFieldsEditor
+                columns={fields.map(f => f.name).filter(name => !computedFields.some(c => c.name === name))}
+                value={computedFields}
+                onChange={setComputedFields}
+                onApply={handleRefreshPreview}
+                loading={loading}
+              />
+            )}
+
             {/* 字段映射配置 */}
             {fields.length > 0 && (
               <>
@@ -572,9 +992,94 @@ function App() {
                   size="small"
                   style={{ marginBottom: 24 }}
                 />
+                <ValueMapEditor
+                  field={valueMapField}
+                  value={fieldMappings.find(m => m.mysqlField === valueMapField)?.valueMap}
+                  connection={mysqlConfig}
+                  tables={queryMode === 'saved' ? [] : tables}
+                  onOk={handleValueMapChange}
+                  onCancel={() => setValueMapField(null)}
+                />
               </>
             )}
 
+            {/* 数据预览 */}
+            {fields.length > 0 && preview && (
+              <>
+                <Divider>数据预览</Divider>
+                <div style={{ color: '#666', marginBottom: 12 }}>
+                  预计共 {preview.estimatedTotal} 行，预览查询耗时 {preview.elapsedMs} ms，以下为前 {preview.records.length} 行同步时的转换结果
+                  <Button type="link" size="small" onClick={handleRefreshPreview} loading={loading}>
+                    重新预览
+                  </Button>
+                </div>
+                {preview.explain && (
+                  <div style={{ color: '#666', marginBottom: 12 }}>
+                    <div>
+                      执行计划：估算代价 {preview.explain.queryCost.toFixed(0)}，估算检查 {preview.explain.estimatedRows} 行
+                    </div>
+                    {preview.explain.tables.map((t, i) => (
+                      <div key={i} style={{ fontSize: 12, color: t.accessType === 'ALL' ? '#fa8c16' : undefined }}>
+                        {t.table}: {t.accessType === 'ALL' ? '全表扫描' : t.accessType}{t.key ? `(索引 ${t.key})` : ''}，每次扫描约 {t.rows} 行
+                      </div>
+                    ))}
+                    {preview.explain.warnings?.map(w => (
+                      <div key={w} style={{ color: '#fa8c16' }}>{w}</div>
+                    ))}
+                  </div>
+                )}
+                <Table
+                  dataSource={preview.records}
+                  columns={previewColumns}
+                  rowKey={(record, index) => record.id || String(index)}
+                  pagination={false}
+                  size="small"
+                  scroll={{ x: 'max-content' }}
+                  style={{ marginBottom: 24 }}
+                />
+              </>
+            )}
+
+            {/* 多表数据源 */}
+            <Divider>多表数据源</Divider>
+            <div style={{ color: '#666', marginBottom: 12 }}>
+              一个数据源可同步多张表：配置好当前表后填写表标识加入列表，全部加入后保存多表配置
+            </div>
+            {tableConfigs.map(t => (
+              <div key={t.tableID} style={{ marginBottom: 8 }}>
+                <span style={{ fontWeight: 500 }}>{t.tableID}</span>
+                {t.tableName && <span>（{t.tableName}）</span>}
+                <span style={{ color: '#666' }}>：{describeTable(t)}</span>
+                <Button
+                  type="link"
+                  danger
+                  size="small"
+                  onClick={() => setTableConfigs(prev => prev.filter(x => x.tableID !== t.tableID))}
+                >
+                  移除
+                </Button>
+              </div>
+            ))}
+            <Form.Item>
+              <Space wrap>
+                <Input
+                  placeholder="表标识(英文、数字、下划线)"
+                  style={{ width: 200 }}
+                  value={tableID}
+                  onChange={e => setTableID(e.target.value.trim())}
+                />
+                <Input
+                  placeholder="表名称(可选)"
+                  style={{ width: 160 }}
+                  value={tableName}
+                  onChange={e => setTableName(e.target.value)}
+                />
+                <Button onClick={handleAddTable} loading={loading} disabled={fields.length === 0 || !tableID}>
+                  加入多表数据源
+                </Button>
+              </Space>
+            </Form.Item>
+
             <Form.Item>
               <Space>
                 <Button onClick={handlePrevious}>上一步</Button>
@@ -586,6 +1091,11 @@ function App() {
                 >
                   保存配置
                 </Button>
+                {tableConfigs.length > 0 && (
+                  <Button type="primary" onClick={handleSaveMultiTable} loading={loading}>
+                    保存多表配置（{tableConfigs.length} 张表）
+                  </Button>
+                )}
               </Space>
             </Form.Item>
           </div>
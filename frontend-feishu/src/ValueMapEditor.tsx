@@ -0,0 +1,157 @@
+import { useEffect, useState } from 'react';
+import { Modal, Form, Input, InputNumber, Select, Radio, Checkbox, Button, message } from 'antd';
+import { MySQLConfig, ValueMapping, getJoinSchema } from './api';
+
+const { Option } = Select;
+const { TextArea } = Input;
+
+interface ValueMapEditorProps {
+  field: string | null;              // 正在编辑的源字段，为空时关闭
+  value?: ValueMapping;
+  connection: Partial<MySQLConfig>;  // 连接信息及数据库，用于读取查找表的列
+  tables: string[];                  // 可作为查找表的表，为空时只能内联配置
+  onOk: (valueMap?: ValueMapping) => void;
+  onCancel: () => void;
+}
+
+// 内联映射的文本形式：每行一个 代码=显示值
+const formatValues = (vm?: ValueMapping) => (vm?.values || []).map(v => `${v.value}=${v.label}`).join('\n');
+
+const parseValues = (text: string) =>
+  text
+    .split('\n')
+    .map(line => line.trim())
+    .filter(line => line.includes('='))
+    .map(line => {
+      const i = line.indexOf('=');
+      return { value: line.slice(0, i).trim(), label: line.slice(i + 1).trim() };
+    })
+    .filter(v => v.label);
+
+// 值映射配置：将字段中的代码替换为显示值
+function ValueMapEditor({ field, value, connection, tables, onOk, onCancel }: ValueMapEditorProps) {
+  const [source, setSource] = useState<'inline' | 'table'>('inline');
+  const [text, setText] = useState('');
+  const [table, setTable] = useState<string>();
+  const [keyColumn, setKeyColumn] = useState<string>();
+  const [labelColumn, setLabelColumn] = useState<string>();
+  const [cacheTTL, setCacheTTL] = useState<number | null>(null);
+  const [dropUnmapped, setDropUnmapped] = useState(false);
+  const [columns, setColumns] = useState<string[]>([]);
+
+  // 打开时载入已有配置
+  useEffect(() => {
+    if (!field) {
+      return;
+    }
+    setSource(value?.table ? 'table' : 'inline');
+    setText(formatValues(value));
+    setTable(value?.table);
+    setKeyColumn(value?.keyColumn);
+    setLabelColumn(value?.labelColumn);
+    setCacheTTL(value?.cacheTTL ?? null);
+    setDropUnmapped(!!value?.dropUnmapped);
+  }, [field]);
+
+  // 读取查找表的列
+  useEffect(() => {
+    if (!table) {
+      setColumns([]);
+      return;
+    }
+    getJoinSchema({ ...connection, queryMode: 'join', join: { base: table, joins: [], columns: [] } } as MySQLConfig)
+      .then(schema => setColumns(schema.columns[table] || []))
+      .catch((error: any) => message.error('获取查找表的列失败: ' + (error.message || '未知错误')));
+  }, [table]);
+
+  const handleOk = () => {
+    if (source === 'inline') {
+      const values = parseValues(text);
+      if (values.length === 0) {
+        message.warning('请按 代码=显示值 的格式填写映射');
+        return;
+      }
+      onOk({ values, dropUnmapped });
+      return;
+    }
+    if (!table || !keyColumn || !labelColumn) {
+      message.warning('请选择查找表及其代码列和显示值列');
+      return;
+    }
+    onOk({ table, keyColumn, labelColumn, cacheTTL: cacheTTL || undefined, dropUnmapped });
+  };
+
+  return (
+    <Modal
+      title={`值映射: ${field || ''}`}
+      open={!!field}
+      onCancel={onCancel}
+      footer={[
+        value && <Button key="clear" danger onClick={() => onOk(undefined)}>清除映射</Button>,
+        <Button key="cancel" onClick={onCancel}>取消</Button>,
+        <Button key="ok" type="primary" onClick={handleOk}>确定</Button>,
+      ]}
+    >
+      <Form layout="vertical">
+        <Form.Item label="映射来源">
+          <Radio.Group value={source} onChange={e => setSource(e.target.value)}>
+            <Radio value="inline">直接填写</Radio>
+            <Radio value="table" disabled={tables.length === 0}>同库查找表</Radio>
+          </Radio.Group>
+        </Form.Item>
+
+        {source === 'inline' && (
+          <Form.Item label="映射值" extra="每行一个，格式为 代码=显示值，显示值按顺序作为单选选项">
+            <TextArea rows={6} value={text} onChange={e => setText(e.target.value)} placeholder={'0=待处理\n1=处理中\n2=已完成'} />
+          </Form.Item>
+        )}
+
+        {source === 'table' && (
+          <>
+            <Form.Item label="查找表">
+              <Select
+                placeholder="请选择查找表"
+                showSearch
+                value={table}
+                onChange={t => {
+                  setTable(t);
+                  setKeyColumn(undefined);
+                  setLabelColumn(undefined);
+                }}
+              >
+                {tables.map(t => (
+                  <Option key={t} value={t}>{t}</Option>
+                ))}
+              </Select>
+            </Form.Item>
+            <Form.Item label="代码列">
+              <Select placeholder="与字段值对应的列" value={keyColumn} onChange={setKeyColumn}>
+                {columns.map(c => (
+                  <Option key={c} value={c}>{c}</Option>
+                ))}
+              </Select>
+            </Form.Item>
+            <Form.Item label="显示值列">
+              <Select placeholder="替换后显示的列" value={labelColumn} onChange={setLabelColumn}>
+                {columns.map(c => (
+                  <Option key={c} value={c}>{c}</Option>
+                ))}
+              </Select>
+            </Form.Item>
+            <Form.Item label="缓存时间(秒)" extra="查找表的内容在该时间内不会重新读取，默认 300 秒">
+              <InputNumber min={1} value={cacheTTL} onChange={v => setCacheTTL(v)} placeholder="300" />
+            </Form.Item>
+          </>
+        )}
+
+        <Form.Item>
+          <Checkbox checked={dropUnmapped} onChange={e => setDropUnmapped(e.target.checked)}>
+            未映射的值写入空值(默认保留原值)
+          </Checkbox>
+        </Form.Item>
+      </Form>
+    </Modal>
+  );
+}
+
+export default ValueMapEditor;
@@ -16,6 +16,80 @@ const api = axios.create({
 export interface FieldMapping {
   mysqlField: string;  // MySQL原始字段名
   aliasField: string;  // AI表格显示的别名
+  valueMap?: ValueMapping;  // 值映射，将代码替换为显示值并作为单选字段输出
+}
+
+// 值映射：内联配置代码与显示值，或从同库的查找表读取(已发布查询模式仅支持内联配置)
+export interface ValueMapping {
+  values?: ValueLabel[];           // 内联映射，按顺序作为单选选项
+  table?: string;                  // 查找表
+  keyColumn?: string;              // 查找表的代码列
+  labelColumn?: string;            // 查找表的显示值列
+  cacheTTL?: number;               // 查找表缓存时间(秒)，默认 300
+  dropUnmapped?: boolean;          // 未映射的值写入空值，默认保留原值
+}
+
+export interface ValueLabel {
+  value: string;
+  label: string;
+}
+
+// 自定义SQL模板参数，SQL中以 :name 或 {{name}} 引用
+export interface SQLParam {
+  name: string;
+  type?: 'string' | 'number' | 'date' | 'datetime';
+  default: string;  // 参数值，可使用内置表达式，如 {{today - 7d}}
+}
+
+// 多表关联配置
+export interface JoinConfig {
+  base: string;                    // 主表
+  joins: JoinTable[];              // 依次关联的表
+  columns: JoinColumn[];           // 选取的列
+}
+
+// 关联的表
+export interface JoinTable {
+  table: string;
+  alias?: string;                  // 表别名，同一张表关联多次时必填
+  type: 'left' | 'inner';          // 关联方式
+  on: JoinKey[];                   // 关联键，多个键之间为 AND
+}
+
+// 关联键：已关联表的列 = 本表的列
+export interface JoinKey {
+  leftTable: string;
+  leftColumn: string;
+  rightColumn: string;
+}
+
+// 选取的列
+export interface JoinColumn {
+  table: string;                   // 表名或别名
+  column: string;
+  alias?: string;                  // 结果列名
+}
+
+// 外键关系
+export interface ForeignKey {
+  name: string;
+  table: string;
+  columns: string[];
+  refTable: string;
+  refColumns: string[];
+}
+
+// 关联配置所需的表结构
+export interface JoinSchema {
+  columns: Record<string, string[]>;  // 表名 -> 列名
+  foreignKeys: ForeignKey[];
+}
+
+// 计算字段：由表达式基于同一行的字段值计算，可引用前面的计算字段
+export interface ComputedField {
+  name: string;
+  type?: string;                   // 字段类型: text/number/date/checkbox/currency/hyperlink/phone/progress/rating，默认 text
+  expression: string;              // 表达式，如 first_name & " " & last_name
 }
 
 // MySQL配置接口
@@ -26,9 +100,27 @@ export interface MySQLConfig {
   username: string;
   password: string;
   table?: string;
-  queryMode?: 'table' | 'sql';     // 取数模式
+  queryMode?: 'table' | 'sql' | 'saved' | 'join';  // 取数模式
   customSQL?: string;              // 自定义SQL语句
+  sqlParams?: SQLParam[];          // 自定义SQL模板参数
+  join?: JoinConfig;               // 多表关联配置
+  savedQuery?: string;             // 已发布查询名称
+  savedQueryVersion?: number;      // 已发布查询版本，为空时使用最新版本
+  paramValues?: Record<string, string>;  // 已发布查询的参数值
   fieldMappings?: FieldMapping[];  // 字段映射配置
+  computedFields?: ComputedField[];  // 计算字段
+}
+
+// 多表数据源中的一张表：表标识及该表的取数配置，未填写的项沿用顶层配置
+export interface FeishuTableConfig extends Partial<MySQLConfig> {
+  tableID: string;                 // 表标识，只能包含英文、数字、下划线
+  tableName?: string;              // 表名称，为空时使用数据表名
+}
+
+// 多表数据源配置：连接信息在顶层，各表的取数配置在 tables 中
+export interface MultiTableConfig extends MySQLConfig {
+  version: 2;
+  tables: FeishuTableConfig[];
 }
 
 // 测试MySQL连接
@@ -62,22 +154,70 @@ export const getTables = async (config: Omit<MySQLConfig, 'table'>): Promise<str
   }
 };
 
-// 获取表字段列表
-export const getTableFields = async (config: MySQLConfig): Promise<any[]> => {
+// 获取表字段列表及数据预览
+export const getTableFields = async (config: MySQLConfig): Promise<DataPreview> => {
   try {
     const response = await api.post('/api/fields', config);
-    return response.data.data || [];
+    const data = response.data.data || {};
+    return { ...data, fields: data.fields || [], records: data.records || [] };
   } catch (error) {
     console.error('获取表字段失败:', error);
-    return [];
+    return { fields: [], records: [], estimatedTotal: 0, elapsedMs: 0 };
   }
 };
 
-// 预览SQL执行结果（获取字段列表）
-export const previewSQL = async (config: MySQLConfig): Promise<any[]> => {
+// 获取多表关联所需的列和外键
+export const getJoinSchema = async (config: MySQLConfig): Promise<JoinSchema> => {
+  const response = await api.post('/api/join_schema', config);
+  if (response.data.code !== 0) {
+    throw new Error(response.data.msg || '获取表结构失败');
+  }
+  return response.data.data;
+};
+
+// 已发布查询(不含SQL和连接信息)
+export interface SavedQueryInfo {
+  name: string;
+  description: string;
+  owner: string;
+  version: number;
+  params: SQLParam[];
+}
+
+// 获取已发布查询列表
+export const getSavedQueries = async (): Promise<SavedQueryInfo[]> => {
+  const response = await api.post('/api/saved_queries');
+  if (response.data.code !== 0) {
+    throw new Error(response.data.msg || '获取已发布查询失败');
+  }
+  return response.data.data || [];
+};
+
+// 执行计划摘要
+export interface ExplainSummary {
+  queryCost: number;                            // 优化器估算的查询代价
+  estimatedRows: number;                        // 估算检查的行数
+  tables: { table: string; accessType: string; key?: string; rows: number }[];
+  warnings?: string[];                          // 超出查询代价限制的项
+}
+
+// 字段与数据预览结果
+export interface DataPreview {
+  fields: any[];                                // 字段列表，warnings 为预览数据的告警
+  records: { id: string; fields: Record<string, any> }[]; // 前若干行，已按同步时的方式转换
+  estimatedTotal: number;                       // 估算的记录总数
+  elapsedMs: number;                            // 预览查询耗时(毫秒)
+  resolvedSQL?: string;                         // 模板变量替换为 ? 占位符后的SQL
+  params?: { name: string; value: any }[];      // 按占位符顺序绑定的参数值
+  explain?: ExplainSummary;                     // 执行计划摘要(自定义SQL或有筛选条件时)
+}
+
+// 预览SQL执行结果（获取字段列表、数据预览及模板变量替换后的SQL）
+export const previewSQL = async (config: MySQLConfig): Promise<DataPreview> => {
   const response = await api.post('/api/preview_sql', config);
   if (response.data.code !== 0) {
     throw new Error(response.data.msg || 'SQL执行失败');
   }
-  return response.data.data || [];
+  const data = response.data.data || {};
+  return { ...data, fields: data.fields || [], records: data.records || [] };
 };